@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AuditRecord is a structured audit entry for one request. It never holds
+// prompt or response content, only hashes of them. Records form a hash chain:
+// Hash covers the record and the previous record's hash, so a record that is
+// altered, removed or reordered breaks every later Hash.
+type AuditRecord struct {
+	ID               string    `json:"id"`
+	Timestamp        time.Time `json:"timestamp"`
+	Origin           string    `json:"origin,omitempty"`
+	User             string    `json:"user,omitempty"`
+	Tenant           string    `json:"tenant,omitempty"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	RequestHash      string    `json:"request_hash"`
+	ResponseHash     string    `json:"response_hash,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	PrevHash         string    `json:"prev_hash"`
+	Hash             string    `json:"hash"`
+}
+
+// AuditSink stores audit records, for example in append-only storage.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record AuditRecord) error
+}
+
+// AuditProvider wraps a Provider and writes an AuditRecord for every request,
+// successful or not, for regulated environments that need a tamper-evident
+// trail of who called which model when. Recording fails closed: if the sink
+// rejects a record, Chat returns the sink's error instead of the response.
+type AuditProvider struct {
+	Provider
+	sink AuditSink
+
+	mu   sync.Mutex
+	prev string
+}
+
+// NewAuditProvider creates a provider that audits requests to sink. prevHash
+// continues an existing chain; pass "" to start a new one.
+func NewAuditProvider(inner Provider, sink AuditSink, prevHash string) *AuditProvider {
+	return &AuditProvider{Provider: inner, sink: sink, prev: prevHash}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *AuditProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and records its audit entry.
+func (p *AuditProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+
+	record := AuditRecord{
+		ID:        newEventID(),
+		Timestamp: time.Now().UTC(),
+		Provider:  p.ID(),
+		Model:     req.Model,
+	}
+	record.Origin, _ = OriginFromContext(ctx)
+	record.User, _ = UserIDFromContext(ctx)
+	record.Tenant, _ = TenantFromContext(ctx)
+	if data, merr := MarshalPersistedRequest(req); merr == nil {
+		record.RequestHash = contentHash(data)
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		if resp.Model != "" {
+			record.Model = resp.Model
+		}
+		if resp.Usage != nil {
+			record.PromptTokens = resp.Usage.PromptTokens
+			record.CompletionTokens = resp.Usage.CompletionTokens
+		}
+		record.ResponseHash = ResponseHash(resp)
+	}
+
+	if aerr := p.write(ctx, record); aerr != nil {
+		return nil, fmt.Errorf("audit: %w", aerr)
+	}
+	return resp, err
+}
+
+// write chains record onto the previous one and hands it to the sink. The
+// lock keeps records in chain order.
+func (p *AuditProvider) write(ctx context.Context, record AuditRecord) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	record.PrevHash = p.prev
+	record.Hash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	record.Hash = contentHash(data)
+
+	if err := p.sink.WriteAudit(ctx, record); err != nil {
+		return err
+	}
+	p.prev = record.Hash
+	return nil
+}
+
+// VerifyAuditChain reports the index of the first record whose hash does not
+// match its contents or its predecessor, or -1 if the chain is intact.
+func VerifyAuditChain(records []AuditRecord) int {
+	for i, record := range records {
+		if i > 0 && record.PrevHash != records[i-1].Hash {
+			return i
+		}
+		want := record.Hash
+		record.Hash = ""
+		data, err := json.Marshal(record)
+		if err != nil || contentHash(data) != want {
+			return i
+		}
+	}
+	return -1
+}
+
+// ResponseHash returns the SHA-256 of a response's content and tool calls,
+// for auditing it without storing it.
+func ResponseHash(resp *ChatResponse) string {
+	data, _ := json.Marshal(struct {
+		Content   string     `json:"content"`
+		ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	}{resp.Content, resp.ToolCalls})
+	return contentHash(data)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
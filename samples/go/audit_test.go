@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// auditLog is an AuditSink collecting records, failing while err is set.
+type auditLog struct {
+	records []AuditRecord
+	err     error
+}
+
+func (l *auditLog) WriteAudit(_ context.Context, record AuditRecord) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.records = append(l.records, record)
+	return nil
+}
+
+func TestAuditProvider(t *testing.T) {
+	errDown := errors.New("down")
+	log := &auditLog{}
+	inner := &fakeProvider{id: "p", chat: scripted(
+		outcome{resp: &ChatResponse{Content: "secret answer", Model: "m-2024", Usage: &UsageStats{PromptTokens: 3, CompletionTokens: 4}}},
+		outcome{err: errDown},
+	)}
+	p := NewAuditProvider(inner, log, "")
+	ctx := WithOrigin(WithUserID(WithTenant(context.Background(), "acme"), "u1"), "search")
+	req := &ChatRequest{Model: "m", Messages: userMessages("secret question")}
+
+	if _, err := p.Chat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Chat(ctx, req); !errors.Is(err, errDown) {
+		t.Fatalf("err = %v, want %v", err, errDown)
+	}
+
+	if len(log.records) != 2 {
+		t.Fatalf("records = %d, want 2", len(log.records))
+	}
+	ok, failed := log.records[0], log.records[1]
+	if ok.Origin != "search" || ok.User != "u1" || ok.Tenant != "acme" || ok.Provider != "p" || ok.Model != "m-2024" ||
+		ok.PromptTokens != 3 || ok.CompletionTokens != 4 || ok.ResponseHash == "" || ok.RequestHash == "" {
+		t.Errorf("record = %+v", ok)
+	}
+	if failed.Error != errDown.Error() || failed.ResponseHash != "" || failed.RequestHash != ok.RequestHash {
+		t.Errorf("failed record = %+v", failed)
+	}
+	for _, r := range log.records {
+		if strings.Contains(r.Error+r.Model+r.Origin, "secret") {
+			t.Errorf("record holds content: %+v", r)
+		}
+	}
+	if ok.PrevHash != "" || failed.PrevHash != ok.Hash || VerifyAuditChain(log.records) != -1 {
+		t.Error("records do not form an intact chain")
+	}
+
+	// The chain continues across providers and survives a rejected write.
+	log.err = errors.New("disk full")
+	next := NewAuditProvider(&fakeProvider{}, log, failed.Hash)
+	if _, err := next.Chat(context.Background(), req); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("err = %v, want the sink's failure", err)
+	}
+	log.err = nil
+	if _, err := next.Chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if VerifyAuditChain(log.records) != -1 || len(log.records) != 3 {
+		t.Errorf("chain broken after a rejected write: %+v", log.records)
+	}
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	log := &auditLog{}
+	p := NewAuditProvider(&fakeProvider{}, log, "")
+	for range 4 {
+		p.Chat(context.Background(), &ChatRequest{Model: "m"})
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(records []AuditRecord) []AuditRecord
+		want   int
+	}{
+		{"intact", func(r []AuditRecord) []AuditRecord { return r }, -1},
+		{"altered", func(r []AuditRecord) []AuditRecord { r[2].PromptTokens = 99; return r }, 2},
+		{"removed", func(r []AuditRecord) []AuditRecord { return slices.Delete(r, 1, 2) }, 1},
+		{"reordered", func(r []AuditRecord) []AuditRecord { r[1], r[2] = r[2], r[1]; return r }, 1},
+		{"rehashed", func(r []AuditRecord) []AuditRecord { r[3].Hash = "forged"; return r }, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyAuditChain(tt.tamper(slices.Clone(log.records))); got != tt.want {
+				t.Errorf("VerifyAuditChain = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// BuiltinTool names a tool hosted and run by the provider itself.
+type BuiltinTool string
+
+const (
+	BuiltinWebSearch       BuiltinTool = "web_search"
+	BuiltinCodeInterpreter BuiltinTool = "code_interpreter"
+)
+
+// ErrBuiltinToolNotSupported is returned when a request enables a built-in
+// tool the provider does not offer.
+var ErrBuiltinToolNotSupported = errors.New("provider does not support built-in tool")
+
+// MetadataBuiltinToolCalls lists the []BuiltinToolCall the provider made
+// while producing a response.
+const MetadataBuiltinToolCalls = "builtin_tool_calls"
+
+// BuiltinToolCall describes one use of a built-in tool.
+type BuiltinToolCall struct {
+	Tool    BuiltinTool
+	Query   string   // The search query, for web search
+	Sources []string // URLs consulted, for web search
+	Code    string   // The code run, for the code interpreter
+}
+
+// checkBuiltinTools fails requests enabling built-in tools p does not declare.
+func checkBuiltinTools(p Provider, req *ChatRequest) error {
+	supported := CapabilitiesOf(p).BuiltinTools
+	for _, t := range req.BuiltinTools {
+		if !slices.Contains(supported, t) {
+			return fmt.Errorf("%w: %s on %s", ErrBuiltinToolNotSupported, t, p.ID())
+		}
+	}
+	return nil
+}
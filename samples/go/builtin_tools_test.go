@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheckBuiltinTools(t *testing.T) {
+	chat := NewOpenAIProvider(OpenAIConfig{ID: "openai"})
+	responses := &ResponsesProvider{api: chat}
+	tests := []struct {
+		name    string
+		p       Provider
+		tools   []BuiltinTool
+		wantErr bool
+	}{
+		{"none requested", chat, nil, false},
+		{"chat completions", chat, []BuiltinTool{BuiltinWebSearch}, true},
+		{"responses", responses, []BuiltinTool{BuiltinWebSearch, BuiltinCodeInterpreter}, false},
+		{"decorated responses", NewRetryProvider(responses, RetryConfig{}), []BuiltinTool{BuiltinCodeInterpreter}, false},
+		{"unknown tool", responses, []BuiltinTool{"file_search"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBuiltinTools(tt.p, &ChatRequest{BuiltinTools: tt.tools})
+			if got := errors.Is(err, ErrBuiltinToolNotSupported); got != tt.wantErr {
+				t.Errorf("err = %v, want ErrBuiltinToolNotSupported: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuiltinToolCallsRecorded(t *testing.T) {
+	var wire responsesResponse
+	if err := json.Unmarshal([]byte(`{"id":"resp_1","status":"completed","output":[
+		{"type":"web_search_call","action":{"query":"weather oslo","sources":[{"url":"https://a"},{"url":"https://b"}]}},
+		{"type":"web_search_call"},
+		{"type":"code_interpreter_call","code":"print(1)"}]}`), &wire); err != nil {
+		t.Fatal(err)
+	}
+	want := []BuiltinToolCall{
+		{Tool: BuiltinWebSearch, Query: "weather oslo", Sources: []string{"https://a", "https://b"}},
+		{Tool: BuiltinWebSearch},
+		{Tool: BuiltinCodeInterpreter, Code: "print(1)"},
+	}
+	if got := fromResponsesResponse(&wire).Metadata[MetadataBuiltinToolCalls]; !reflect.DeepEqual(got, want) {
+		t.Errorf("builtin tool calls = %+v, want %+v", got, want)
+	}
+}
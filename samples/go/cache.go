@@ -0,0 +1,227 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MetadataCacheHit is set to true on responses served from the cache.
+const MetadataCacheHit = "cache_hit"
+
+// CacheEntry is a cached response and the schema version it was produced under.
+type CacheEntry struct {
+	Response      *ChatResponse `json:"response"`
+	SchemaVersion int           `json:"schema_version"`
+	StoredAt      time.Time     `json:"stored_at"`
+}
+
+// Cache stores responses by request key.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+}
+
+// MemoryCache is an in-process Cache.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]*CacheEntry
+}
+
+// NewMemoryCache creates an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]*CacheEntry)}
+}
+
+// Get returns the entry stored under key.
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+// Set stores entry under key.
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// ResponseMigrator upgrades a cached response from one schema version to the next.
+type ResponseMigrator func(resp *ChatResponse) (*ChatResponse, error)
+
+// CacheConfig configures a CachingProvider.
+type CacheConfig struct {
+	// TTL is how long entries are served. Zero means entries never expire.
+	TTL time.Duration
+
+	// SchemaVersion is the current version of the structured-output schema.
+	// Entries stored under a different version are not served as-is.
+	SchemaVersion int
+
+	// Migrations upgrade entries from the keyed version to the next one. An
+	// older entry is migrated step by step to SchemaVersion; if any step is
+	// missing or fails, the entry is treated as a miss.
+	Migrations map[int]ResponseMigrator
+
+	// Eligible, if set, decides which requests may be cached, replacing the
+	// reproducibility rule: only requests it accepts are cached, whatever their
+	// temperature. Use it to keep personalized prompts out of the cache.
+	Eligible func(req *ChatRequest) bool
+
+	// RefreshOnBypass stores the fresh response of a request sent with
+	// WithNoCache, replacing the cached one. Otherwise bypassed requests leave
+	// the cache untouched.
+	RefreshOnBypass bool
+}
+
+type noCacheKey struct{}
+
+// WithNoCache returns a context whose requests skip the cache read and always
+// reach the provider, for debugging or freshness-critical calls.
+func WithNoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// NoCacheFromContext reports whether WithNoCache was set on ctx.
+func NoCacheFromContext(ctx context.Context) bool {
+	noCache, _ := ctx.Value(noCacheKey{}).(bool)
+	return noCache
+}
+
+// CachePromptIDs returns a CacheConfig.Eligible predicate accepting only
+// requests for the given stored prompts.
+func CachePromptIDs(ids ...string) func(req *ChatRequest) bool {
+	return func(req *ChatRequest) bool {
+		return req.PromptID != "" && slices.Contains(ids, req.PromptID)
+	}
+}
+
+// CachingProvider wraps a Provider and caches reproducible responses: requests
+// with Temperature 0, or with a Seed when the wrapped provider declares seed
+// support. The seed is part of the cache key, so different seeds are cached
+// separately. CacheConfig.Eligible overrides which requests are cached.
+type CachingProvider struct {
+	Provider
+	cache  Cache
+	config CacheConfig
+}
+
+// NewCachingProvider creates a provider that caches responses in cache.
+func NewCachingProvider(inner Provider, cache Cache, config CacheConfig) *CachingProvider {
+	return &CachingProvider{Provider: inner, cache: cache, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CachingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat serves eligible requests from the cache, calling the wrapped provider on a miss.
+func (p *CachingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if !p.cacheable(req) {
+		return p.Provider.Chat(ctx, req)
+	}
+
+	key, err := cacheKey(req)
+	if err != nil {
+		return p.Provider.Chat(ctx, req)
+	}
+	bypass := NoCacheFromContext(ctx)
+	if !bypass {
+		if resp, ok := p.lookup(key); ok {
+			resp.SetMetadata(MetadataCacheHit, true)
+			return resp, nil
+		}
+	}
+
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if !bypass || p.config.RefreshOnBypass {
+		p.store(key, resp, time.Now())
+	}
+	return resp, nil
+}
+
+func (p *CachingProvider) cacheable(req *ChatRequest) bool {
+	if p.config.Eligible != nil {
+		return p.config.Eligible(req)
+	}
+	if req.Temperature == 0 {
+		return true
+	}
+	return req.Seed != nil && CapabilitiesOf(p.Provider).Seed
+}
+
+// lookup returns a copy of the cached response for key, migrating it to the
+// current schema version if needed.
+func (p *CachingProvider) lookup(key string) (*ChatResponse, bool) {
+	entry, ok := p.cache.Get(key)
+	if !ok || entry.Response == nil {
+		return nil, false
+	}
+	if p.config.TTL > 0 && time.Since(entry.StoredAt) > p.config.TTL {
+		return nil, false
+	}
+
+	resp := cloneResponse(entry.Response)
+	if entry.SchemaVersion == p.config.SchemaVersion {
+		return resp, true
+	}
+	if entry.SchemaVersion > p.config.SchemaVersion {
+		return nil, false
+	}
+
+	for v := entry.SchemaVersion; v < p.config.SchemaVersion; v++ {
+		migrate, ok := p.config.Migrations[v]
+		if !ok {
+			return nil, false
+		}
+		var err error
+		if resp, err = migrate(resp); err != nil || resp == nil {
+			return nil, false
+		}
+	}
+
+	p.store(key, resp, entry.StoredAt)
+	return cloneResponse(resp), true
+}
+
+func (p *CachingProvider) store(key string, resp *ChatResponse, storedAt time.Time) {
+	p.cache.Set(key, &CacheEntry{
+		Response:      cloneResponse(resp),
+		SchemaVersion: p.config.SchemaVersion,
+		StoredAt:      storedAt,
+	})
+}
+
+// cacheKey returns a stable hash of the request's persisted form, which unlike
+// the wire format includes client-side options such as Grammar and
+// BuiltinTools that change the response.
+func cacheKey(req *ChatRequest) (string, error) {
+	data, err := MarshalPersistedRequest(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cloneResponse copies a response deeply enough that annotating the copy does
+// not affect the original.
+func cloneResponse(resp *ChatResponse) *ChatResponse {
+	out := *resp
+	out.Metadata = maps.Clone(resp.Metadata)
+	if resp.Usage != nil {
+		usage := *resp.Usage
+		out.Usage = &usage
+	}
+	return &out
+}
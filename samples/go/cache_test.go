@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// countingChat returns a Chat function whose responses number the calls made.
+func countingChat() func(context.Context, *ChatRequest) (*ChatResponse, error) {
+	n := 0
+	return func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		n++
+		return &ChatResponse{Content: strconv.Itoa(n), Model: req.Model}, nil
+	}
+}
+
+// seedProvider is a fakeProvider that declares seed support.
+type seedProvider struct{ *fakeProvider }
+
+func (seedProvider) Capabilities() Capabilities { return Capabilities{Seed: true} }
+
+func TestCachingProvider(t *testing.T) {
+	seed := int64(7)
+	tests := []struct {
+		name       string
+		seeded     bool
+		config     CacheConfig
+		first      ChatRequest
+		second     ChatRequest
+		ctx        context.Context
+		wantCached bool
+	}{
+		{"deterministic repeat", false, CacheConfig{}, ChatRequest{Model: "m"}, ChatRequest{Model: "m"}, nil, true},
+		{"sampled", false, CacheConfig{}, ChatRequest{Model: "m", Temperature: 0.7}, ChatRequest{Model: "m", Temperature: 0.7}, nil, false},
+		{"seeded on a seed provider", true, CacheConfig{}, ChatRequest{Temperature: 0.7, Seed: &seed}, ChatRequest{Temperature: 0.7, Seed: &seed}, nil, true},
+		{"seeded without seed support", false, CacheConfig{}, ChatRequest{Temperature: 0.7, Seed: &seed}, ChatRequest{Temperature: 0.7, Seed: &seed}, nil, false},
+		{"different grammar", false, CacheConfig{}, ChatRequest{Grammar: "root ::= \"a\""}, ChatRequest{Grammar: "root ::= \"b\""}, nil, false},
+		{"different builtin tools", false, CacheConfig{}, ChatRequest{}, ChatRequest{BuiltinTools: []BuiltinTool{BuiltinWebSearch}}, nil, false},
+		{"bypassed", false, CacheConfig{}, ChatRequest{}, ChatRequest{}, WithNoCache(context.Background()), false},
+		{"ineligible prompt", false, CacheConfig{Eligible: CachePromptIDs("faq")}, ChatRequest{PromptID: "chat"}, ChatRequest{PromptID: "chat"}, nil, false},
+		{"eligible prompt", false, CacheConfig{Eligible: CachePromptIDs("faq")}, ChatRequest{PromptID: "faq", Temperature: 1}, ChatRequest{PromptID: "faq", Temperature: 1}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inner Provider = &fakeProvider{chat: countingChat()}
+			if tt.seeded {
+				inner = seedProvider{inner.(*fakeProvider)}
+			}
+			p := NewCachingProvider(inner, NewMemoryCache(), tt.config)
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if _, err := p.Chat(context.Background(), &tt.first); err != nil {
+				t.Fatal(err)
+			}
+			resp, err := p.Chat(ctx, &tt.second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cached := resp.Content == "1"; cached != tt.wantCached {
+				t.Errorf("served from cache: %v, want %v", cached, tt.wantCached)
+			}
+			if hit := resp.Metadata[MetadataCacheHit] == true; hit != tt.wantCached {
+				t.Errorf("cache hit metadata: %v, want %v", hit, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestCachingProviderExpiryAndMigration(t *testing.T) {
+	key, _ := cacheKey(&ChatRequest{Model: "m"})
+	tests := []struct {
+		name        string
+		entry       CacheEntry
+		config      CacheConfig
+		wantContent string
+	}{
+		{"fresh", CacheEntry{Response: &ChatResponse{Content: "cached"}, StoredAt: time.Now()}, CacheConfig{TTL: time.Hour}, "cached"},
+		{"expired", CacheEntry{Response: &ChatResponse{Content: "cached"}, StoredAt: time.Now().Add(-2 * time.Hour)}, CacheConfig{TTL: time.Hour}, "1"},
+		{
+			"migrated",
+			CacheEntry{Response: &ChatResponse{Content: "v1"}, SchemaVersion: 1, StoredAt: time.Now()},
+			CacheConfig{SchemaVersion: 2, Migrations: map[int]ResponseMigrator{1: func(r *ChatResponse) (*ChatResponse, error) {
+				r.Content += "->v2"
+				return r, nil
+			}}},
+			"v1->v2",
+		},
+		{"no migration path", CacheEntry{Response: &ChatResponse{Content: "v1"}, SchemaVersion: 1, StoredAt: time.Now()}, CacheConfig{SchemaVersion: 2}, "1"},
+		{"from a newer schema", CacheEntry{Response: &ChatResponse{Content: "v3"}, SchemaVersion: 3, StoredAt: time.Now()}, CacheConfig{SchemaVersion: 2}, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMemoryCache()
+			entry := tt.entry
+			cache.Set(key, &entry)
+			p := NewCachingProvider(&fakeProvider{chat: countingChat()}, cache, tt.config)
+			resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", resp.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestCachePromptIDs(t *testing.T) {
+	eligible := CachePromptIDs("faq", "help")
+	tests := []struct {
+		promptID string
+		want     bool
+	}{
+		{"faq", true},
+		{"help", true},
+		{"chat", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := eligible(&ChatRequest{PromptID: tt.promptID}); got != tt.want {
+			t.Errorf("eligible(%q) = %v, want %v", tt.promptID, got, tt.want)
+		}
+	}
+	if CachePromptIDs()(&ChatRequest{}) {
+		t.Error("an empty ID list accepted a request without a stored prompt")
+	}
+}
+
+func TestCachingProviderBypass(t *testing.T) {
+	tests := []struct {
+		name      string
+		refresh   bool
+		wantAfter string // Content served from the cache after the bypassed call
+	}{
+		{"bypass leaves the cache", false, "1"},
+		{"bypass refreshes the cache", true, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewCachingProvider(&fakeProvider{chat: countingChat()}, NewMemoryCache(), CacheConfig{RefreshOnBypass: tt.refresh})
+			req := &ChatRequest{Model: "m"}
+			if _, err := p.Chat(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			bypassed, err := p.Chat(WithNoCache(context.Background()), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bypassed.Content != "2" || bypassed.Metadata[MetadataCacheHit] != nil {
+				t.Errorf("bypassed call = %+v, want a fresh response", bypassed)
+			}
+			after, err := p.Chat(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after.Content != tt.wantAfter {
+				t.Errorf("cached content = %q, want %q", after.Content, tt.wantAfter)
+			}
+		})
+	}
+	if NoCacheFromContext(context.Background()) || !NoCacheFromContext(WithNoCache(context.Background())) {
+		t.Error("NoCacheFromContext does not reflect WithNoCache")
+	}
+}
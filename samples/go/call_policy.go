@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Errors returned by CallPolicyProvider for the call type its policy forbids.
+var (
+	ErrBlockingCallForbidden  = errors.New("blocking calls are forbidden; use ChatStream")
+	ErrStreamingCallForbidden = errors.New("streaming calls are forbidden; use Chat")
+)
+
+// CallPolicy restricts which call types a provider accepts.
+type CallPolicy int
+
+const (
+	AllowAllCalls CallPolicy = iota // Chat and ChatStream are both allowed
+	StreamingOnly                   // Chat is rejected, e.g. to avoid head-of-line blocking
+	BlockingOnly                    // ChatStream is rejected, e.g. to keep infrastructure simple
+)
+
+// CallPolicyProvider wraps a StreamingProvider and rejects the call type its
+// policy forbids, enforcing a deployment's architectural constraints at the
+// library boundary.
+type CallPolicyProvider struct {
+	StreamingProvider
+	policy CallPolicy
+}
+
+// NewCallPolicyProvider creates a provider that enforces policy.
+func NewCallPolicyProvider(inner StreamingProvider, policy CallPolicy) *CallPolicyProvider {
+	return &CallPolicyProvider{StreamingProvider: inner, policy: policy}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CallPolicyProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// Chat forwards the request unless blocking calls are forbidden.
+func (p *CallPolicyProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if p.policy == StreamingOnly {
+		return nil, fmt.Errorf("%s: %w", p.ID(), ErrBlockingCallForbidden)
+	}
+	return p.StreamingProvider.Chat(ctx, req)
+}
+
+// ChatStream starts a stream unless streaming calls are forbidden.
+func (p *CallPolicyProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	if p.policy == BlockingOnly {
+		return nil, fmt.Errorf("%s: %w", p.ID(), ErrStreamingCallForbidden)
+	}
+	return p.StreamingProvider.ChatStream(ctx, req)
+}
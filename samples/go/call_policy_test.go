@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCallPolicyProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        CallPolicy
+		wantChatErr   error
+		wantStreamErr error
+	}{
+		{name: "allow all", policy: AllowAllCalls},
+		{name: "streaming only", policy: StreamingOnly, wantChatErr: ErrBlockingCallForbidden},
+		{name: "blocking only", policy: BlockingOnly, wantStreamErr: ErrStreamingCallForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := streamingReply("ok")
+			p := NewCallPolicyProvider(inner, tt.policy)
+
+			_, err := p.Chat(context.Background(), &ChatRequest{})
+			if !errors.Is(err, tt.wantChatErr) {
+				t.Errorf("Chat err = %v, want %v", err, tt.wantChatErr)
+			}
+			chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+			if !errors.Is(err, tt.wantStreamErr) {
+				t.Errorf("ChatStream err = %v, want %v", err, tt.wantStreamErr)
+			}
+			if chunks != nil {
+				collectStream(chunks)
+			}
+
+			want := 2
+			if tt.wantChatErr != nil || tt.wantStreamErr != nil {
+				want = 1
+			}
+			if got := len(inner.requests()); got != want {
+				t.Errorf("forwarded %d calls, want %d", got, want)
+			}
+		})
+	}
+}
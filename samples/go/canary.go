@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// Canary variants recorded in response metadata under MetadataCanaryVariant.
+const (
+	CanaryVariantStable = "stable"
+	CanaryVariantCanary = "canary"
+
+	MetadataCanaryVariant = "canary_variant"
+)
+
+type sessionKeyKey struct{}
+
+// WithSessionKey returns a context carrying a session or user key used for
+// deterministic traffic assignment.
+func WithSessionKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKeyKey{}, key)
+}
+
+// SessionKeyFromContext returns the key set by WithSessionKey.
+func SessionKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(sessionKeyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// CanaryConfig configures a CanaryProvider.
+type CanaryConfig struct {
+	StableModel string // Defaults to the request's model
+	CanaryModel string
+
+	// Weight is the fraction of sessions, between 0 and 1, sent to CanaryModel.
+	Weight float64
+}
+
+// CanaryProvider wraps a Provider and splits traffic between a stable and a
+// canary model version. Assignment is a hash of the session key, so a session
+// always sees the same variant; requests without a session key use the stable model.
+type CanaryProvider struct {
+	Provider
+	config CanaryConfig
+}
+
+// NewCanaryProvider creates a provider that routes a weighted share of sessions to the canary model.
+func NewCanaryProvider(inner Provider, config CanaryConfig) *CanaryProvider {
+	return &CanaryProvider{Provider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CanaryProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Variant returns the variant assigned to a session key.
+func (p *CanaryProvider) Variant(key string) string {
+	if key == "" || p.config.Weight <= 0 {
+		return CanaryVariantStable
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	if float64(h.Sum64()%10000) < p.config.Weight*10000 {
+		return CanaryVariantCanary
+	}
+	return CanaryVariantStable
+}
+
+// Chat routes the request to the session's variant and tags the response with it.
+func (p *CanaryProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	key, _ := SessionKeyFromContext(ctx)
+	variant := p.Variant(key)
+
+	routed := *req
+	if p.config.StableModel != "" {
+		routed.Model = p.config.StableModel
+	}
+	if variant == CanaryVariantCanary && p.config.CanaryModel != "" {
+		routed.Model = p.config.CanaryModel
+	}
+
+	resp, err := p.Provider.Chat(ctx, &routed)
+	if err != nil {
+		return nil, err
+	}
+	resp.SetMetadata(MetadataCanaryVariant, variant)
+	return resp, nil
+}
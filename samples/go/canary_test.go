@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestCanarySplitRatio(t *testing.T) {
+	tests := []struct {
+		weight float64
+	}{{0}, {0.1}, {0.5}, {1}}
+	const sessions = 20000
+	for _, tt := range tests {
+		p := NewCanaryProvider(&fakeProvider{}, CanaryConfig{StableModel: "v1", CanaryModel: "v2", Weight: tt.weight})
+		canary := 0
+		for i := range sessions {
+			if p.Variant(fmt.Sprintf("user-%d", i)) == CanaryVariantCanary {
+				canary++
+			}
+		}
+		if got := float64(canary) / sessions; math.Abs(got-tt.weight) > 0.02 {
+			t.Errorf("weight %v: canary share = %.3f", tt.weight, got)
+		}
+	}
+}
+
+func TestCanaryRouting(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      CanaryConfig
+		key         string
+		wantModel   string
+		wantVariant string
+	}{
+		{"canary session", CanaryConfig{StableModel: "v1", CanaryModel: "v2", Weight: 1}, "user-1", "v2", CanaryVariantCanary},
+		{"stable session", CanaryConfig{StableModel: "v1", CanaryModel: "v2", Weight: 0}, "user-1", "v1", CanaryVariantStable},
+		{"no session key", CanaryConfig{StableModel: "v1", CanaryModel: "v2", Weight: 1}, "", "v1", CanaryVariantStable},
+		{"stable defaults to request model", CanaryConfig{CanaryModel: "v2", Weight: 0}, "user-1", "requested", CanaryVariantStable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = WithSessionKey(ctx, tt.key)
+			}
+			resp, err := NewCanaryProvider(inner, tt.config).Chat(ctx, &ChatRequest{Model: "requested"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := inner.requests()[0].Model; got != tt.wantModel {
+				t.Errorf("model = %q, want %q", got, tt.wantModel)
+			}
+			if got := resp.Metadata[MetadataCanaryVariant]; got != tt.wantVariant {
+				t.Errorf("variant = %v, want %q", got, tt.wantVariant)
+			}
+		})
+	}
+}
+
+func TestCanaryAssignmentIsDeterministic(t *testing.T) {
+	config := CanaryConfig{StableModel: "v1", CanaryModel: "v2", Weight: 0.5}
+	a, b := NewCanaryProvider(&fakeProvider{}, config), NewCanaryProvider(&fakeProvider{}, config)
+	for i := range 100 {
+		key := fmt.Sprintf("session-%d", i)
+		first := a.Variant(key)
+		if a.Variant(key) != first || b.Variant(key) != first {
+			t.Fatalf("session %q assigned inconsistently", key)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package llm
+
+// Capabilities describes optional features a provider supports. The zero
+// value is the conservative assumption for providers that do not declare any.
+type Capabilities struct {
+	Tools bool // Accepts tool definitions and "tool" role messages
+	Seed  bool // Produces reproducible output for a given ChatRequest.Seed
+
+	Grammar bool // Constrains output to ChatRequest.Grammar
+
+	JSONMode         bool // Accepts ResponseFormatJSONObject
+	StructuredOutput bool // Accepts ResponseFormatJSONSchema with strict schemas
+
+	BuiltinTools []BuiltinTool // Provider-hosted tools accepted in ChatRequest.BuiltinTools
+
+	// MaxEmbeddingBatch is the most inputs an Embedder accepts per request.
+	// Zero means unknown; DefaultEmbeddingBatch is assumed.
+	MaxEmbeddingBatch int
+}
+
+// CapabilityProvider is implemented by providers that declare their capabilities.
+type CapabilityProvider interface {
+	Capabilities() Capabilities
+}
+
+// Unwrapper is implemented by decorators, which return the provider they wrap.
+type Unwrapper interface {
+	Unwrap() Provider
+}
+
+// CapabilitiesOf returns the capabilities p declares, or the zero Capabilities
+// if it declares none. Decorators that do not declare capabilities of their
+// own are unwrapped, so a feature stays visible however deeply its provider
+// is wrapped.
+func CapabilitiesOf(p Provider) Capabilities {
+	for p != nil {
+		if cp, ok := p.(CapabilityProvider); ok {
+			return cp.Capabilities()
+		}
+		u, ok := p.(Unwrapper)
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+	return Capabilities{}
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// grammarProvider is a fakeStreamer that declares grammar support.
+type grammarProvider struct{ *fakeStreamer }
+
+func (grammarProvider) Capabilities() Capabilities { return Capabilities{Grammar: true, Seed: true} }
+
+func TestCapabilitiesOfUnwrapsDecorators(t *testing.T) {
+	base := grammarProvider{streamingReply("ok")}
+	tests := []struct {
+		name string
+		p    Provider
+		want bool // Whether grammar support is visible
+	}{
+		{"undecorated", base, true},
+		{"one decorator", NewRetryProvider(base, RetryConfig{}), true},
+		{"nested decorators", NewRetryProvider(NewCachingProvider(NewLimitedStreamProvider(base, StreamLimits{}), NewMemoryCache(), CacheConfig{}), RetryConfig{}), true},
+		{"model list cache", NewModelListCache(NewRetryProvider(base, RetryConfig{}), time.Minute), true},
+		{"no declaration", NewRetryProvider(&fakeProvider{}, RetryConfig{}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CapabilitiesOf(tt.p).Grammar; got != tt.want {
+				t.Errorf("Grammar = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilityChecksSeeThroughDecorators(t *testing.T) {
+	decorated := NewRetryProvider(grammarProvider{streamingReply("ok")}, RetryConfig{})
+	if err := checkGrammar(decorated, &ChatRequest{Grammar: `root ::= "a"`}); err != nil {
+		t.Errorf("grammar rejected through a decorator: %v", err)
+	}
+	plain := NewRetryProvider(&fakeProvider{}, RetryConfig{})
+	if err := checkGrammar(plain, &ChatRequest{Grammar: `root ::= "a"`}); !errors.Is(err, ErrGrammarNotSupported) {
+		t.Errorf("err = %v, want ErrGrammarNotSupported", err)
+	}
+
+	inner := &fakeProvider{}
+	tools := NewToolRoleProvider(NewRetryProvider(toolProvider{inner}, RetryConfig{}), ToolMessagesReject)
+	if _, err := tools.Chat(context.Background(), &ChatRequest{Tools: []ToolDefinition{{Name: "f"}}}); err != nil {
+		t.Errorf("tools rejected through a decorator: %v", err)
+	}
+}
+
+func TestSeededCachingThroughDecorators(t *testing.T) {
+	seed := int64(1)
+	inner := &fakeProvider{chat: countingChat()}
+	p := NewCachingProvider(NewRetryProvider(seedProvider{inner}, RetryConfig{}), NewMemoryCache(), CacheConfig{})
+	for i := 0; i < 2; i++ {
+		if _, err := p.Chat(context.Background(), &ChatRequest{Temperature: 0.9, Seed: &seed}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(inner.requests()); n != 1 {
+		t.Errorf("provider called %d times, want 1", n)
+	}
+}
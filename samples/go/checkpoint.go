@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// CheckpointPolicy configures when a CheckpointSession summarizes its history.
+// A checkpoint is due after EveryTurns user turns or once the turns since the
+// last checkpoint reach EveryTokens; zero disables either trigger.
+type CheckpointPolicy struct {
+	EveryTurns  int
+	EveryTokens int
+
+	// Counter measures EveryTokens. Defaults to HeuristicTokenCounter.
+	Counter TokenCounter
+
+	// Summarizer produces the summary. Defaults to the session's provider.
+	Summarizer Provider
+
+	// SummaryModel is the model asked for the summary. Defaults to the
+	// session's model.
+	SummaryModel string
+}
+
+// CheckpointSession is a conversation kept bounded by periodic summarization
+// checkpoints, for long-running agent sessions. At each checkpoint every turn
+// so far, together with the previous summary, is replaced by a new summary;
+// the system messages and the turns after the checkpoint are sent as is.
+// Checkpoints never separate tool results from the call that requested them.
+// A CheckpointSession is not safe for concurrent use.
+type CheckpointSession struct {
+	provider Provider
+	model    string
+	policy   CheckpointPolicy
+
+	system      []Message
+	summary     string
+	turns       []Message
+	userTurns   int
+	checkpoints int
+}
+
+// NewCheckpointSession starts a session on provider and model with the given
+// system messages.
+func NewCheckpointSession(provider Provider, model string, policy CheckpointPolicy, system ...Message) *CheckpointSession {
+	if policy.Counter == nil {
+		policy.Counter = HeuristicTokenCounter{}
+	}
+	if policy.Summarizer == nil {
+		policy.Summarizer = provider
+	}
+	if policy.SummaryModel == "" {
+		policy.SummaryModel = model
+	}
+	return &CheckpointSession{provider: provider, model: model, policy: policy, system: system}
+}
+
+// Send adds msg to the conversation, taking a checkpoint first if one is due,
+// and sends the conversation. The response is added to the history. If a
+// checkpoint was taken, the response's metadata records the number of turns
+// it replaced.
+func (s *CheckpointSession) Send(ctx context.Context, msg Message) (*ChatResponse, error) {
+	replaced := 0
+	if msg.Role != "tool" && s.due() {
+		n, err := s.Checkpoint(ctx)
+		if err != nil {
+			return nil, err
+		}
+		replaced = n
+	}
+
+	s.turns = append(s.turns, msg)
+	if msg.Role == "user" {
+		s.userTurns++
+	}
+	resp, err := s.provider.Chat(ctx, &ChatRequest{Model: s.model, Messages: s.Messages()})
+	if err != nil {
+		s.turns = s.turns[:len(s.turns)-1]
+		if msg.Role == "user" {
+			s.userTurns--
+		}
+		return nil, err
+	}
+
+	s.turns = append(s.turns, Message{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls})
+	if replaced > 0 {
+		resp.SetMetadata(MetadataCompressedTurns, replaced)
+	}
+	return resp, nil
+}
+
+// Checkpoint summarizes the history now and returns the number of turns
+// replaced.
+func (s *CheckpointSession) Checkpoint(ctx context.Context) (int, error) {
+	if len(s.turns) == 0 {
+		return 0, nil
+	}
+	turns := s.turns
+	if s.summary != "" {
+		turns = append([]Message{{Role: "system", Content: s.summary}}, turns...)
+	}
+	summary, err := summarizeTurns(ctx, s.policy.Summarizer, s.policy.SummaryModel, turns)
+	if err != nil {
+		return 0, fmt.Errorf("checkpoint conversation: %w", err)
+	}
+
+	n := len(s.turns)
+	s.summary, s.turns, s.userTurns = summary, nil, 0
+	s.checkpoints++
+	return n, nil
+}
+
+// Messages returns the conversation as it is next sent: the system messages,
+// the latest summary and the turns since the checkpoint.
+func (s *CheckpointSession) Messages() []Message {
+	msgs := slices.Clone(s.system)
+	if s.summary != "" {
+		msgs = append(msgs, Message{Role: "system", Content: "Summary of the earlier conversation:\n" + s.summary})
+	}
+	return append(msgs, s.turns...)
+}
+
+// Checkpoints returns the number of checkpoints taken.
+func (s *CheckpointSession) Checkpoints() int {
+	return s.checkpoints
+}
+
+func (s *CheckpointSession) due() bool {
+	if s.policy.EveryTurns > 0 && s.userTurns >= s.policy.EveryTurns {
+		return true
+	}
+	return s.policy.EveryTokens > 0 && len(s.turns) > 0 &&
+		s.policy.Counter.CountMessages(s.model, s.turns) >= s.policy.EveryTokens
+}
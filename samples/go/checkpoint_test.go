@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointSession(t *testing.T) {
+	user := func(s string) Message { return Message{Role: "user", Content: s} }
+	tool := Message{Role: "tool", Content: "result", ToolCallID: "1"}
+
+	tests := []struct {
+		name            string
+		policy          CheckpointPolicy
+		send            []Message
+		wantCheckpoints int
+		wantReplaced    any // MetadataCompressedTurns on the last response
+		wantTurns       int // Non-system messages sent with the last request
+	}{
+		{
+			name:      "no policy",
+			send:      []Message{user("a"), user("b"), user("c")},
+			wantTurns: 5,
+		},
+		{
+			name:            "every two user turns",
+			policy:          CheckpointPolicy{EveryTurns: 2},
+			send:            []Message{user("a"), user("b"), user("c")},
+			wantCheckpoints: 1,
+			wantReplaced:    4,
+			wantTurns:       1,
+		},
+		{
+			name:            "tool results are not separated from their call",
+			policy:          CheckpointPolicy{EveryTurns: 2},
+			send:            []Message{user("a"), user("b"), tool},
+			wantCheckpoints: 0,
+			wantTurns:       5,
+		},
+		{
+			name:            "token threshold",
+			policy:          CheckpointPolicy{EveryTokens: 6, Counter: wordCounter{}},
+			send:            []Message{user("aaaa"), user("b")},
+			wantCheckpoints: 1,
+			wantReplaced:    2,
+			wantTurns:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			summarizer := &fakeProvider{chat: replyWith("the gist")}
+			tt.policy.Summarizer = summarizer
+			s := NewCheckpointSession(inner, "m", tt.policy, Message{Role: "system", Content: "be brief"})
+
+			var resp *ChatResponse
+			for _, msg := range tt.send {
+				var err error
+				if resp, err = s.Send(context.Background(), msg); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if s.Checkpoints() != tt.wantCheckpoints || len(summarizer.requests()) != tt.wantCheckpoints {
+				t.Errorf("checkpoints = %d with %d summaries, want %d", s.Checkpoints(), len(summarizer.requests()), tt.wantCheckpoints)
+			}
+			if got := resp.Metadata[MetadataCompressedTurns]; got != tt.wantReplaced {
+				t.Errorf("replaced = %v, want %v", got, tt.wantReplaced)
+			}
+			calls := inner.requests()
+			sent := calls[len(calls)-1].Messages
+			if sent[0].Content != "be brief" {
+				t.Errorf("first message = %+v, want the system prompt", sent[0])
+			}
+			turns := 0
+			for _, m := range sent {
+				if m.Role != "system" {
+					turns++
+				}
+			}
+			if turns != tt.wantTurns {
+				t.Errorf("sent %d turns, want %d: %+v", turns, tt.wantTurns, sent)
+			}
+			if tt.wantCheckpoints > 0 && !strings.Contains(sent[1].Content, "the gist") {
+				t.Errorf("second message = %+v, want the summary", sent[1])
+			}
+		})
+	}
+}
+
+func TestCheckpointSessionFailures(t *testing.T) {
+	errDown := errors.New("down")
+	summarizer := &fakeProvider{chat: scripted(outcome{err: errDown})}
+	inner := &fakeProvider{}
+	s := NewCheckpointSession(inner, "m", CheckpointPolicy{EveryTurns: 1, Summarizer: summarizer})
+
+	if _, err := s.Send(context.Background(), Message{Role: "user", Content: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Send(context.Background(), Message{Role: "user", Content: "b"}); !errors.Is(err, errDown) {
+		t.Fatalf("err = %v, want the summarizer's failure", err)
+	}
+	if got := s.Messages(); len(got) != 2 || s.Checkpoints() != 0 {
+		t.Errorf("history after a failed checkpoint = %+v, want it unchanged", got)
+	}
+
+	inner.chat = scripted(outcome{err: errDown})
+	s = NewCheckpointSession(inner, "m", CheckpointPolicy{})
+	if _, err := s.Send(context.Background(), Message{Role: "user", Content: "a"}); !errors.Is(err, errDown) {
+		t.Fatalf("err = %v, want %v", err, errDown)
+	}
+	if got := s.Messages(); len(got) != 0 {
+		t.Errorf("history after a failed send = %+v, want it empty", got)
+	}
+}
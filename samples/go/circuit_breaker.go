@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests to a model whose breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Requests flow normally
+	BreakerOpen     BreakerState = "open"      // Requests are rejected until the open period ends
+	BreakerHalfOpen BreakerState = "half_open" // One trial request is let through
+)
+
+// BreakerConfig configures a ModelBreakerProvider. Zero fields use the defaults.
+type BreakerConfig struct {
+	FailureThreshold int           // Consecutive transient failures that open the breaker (default 5)
+	OpenFor          time.Duration // How long the breaker stays open before a trial request (default 30s)
+}
+
+// ModelBreaker is the breaker state of one model.
+type ModelBreaker struct {
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures"` // Consecutive transient failures
+	OpenedAt time.Time    `json:"opened_at,omitzero"`
+}
+
+// ModelBreakerProvider wraps a Provider with a circuit breaker per model, so
+// one overloaded model does not take the whole provider out of rotation.
+// Only transient failures, as classified by IsTransient, count towards
+// opening a breaker.
+type ModelBreakerProvider struct {
+	Provider
+	config BreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	breakers map[string]*ModelBreaker
+	trials   map[string]bool // Models with a half-open trial in flight
+}
+
+// NewModelBreakerProvider creates a provider with per-model circuit breakers.
+func NewModelBreakerProvider(inner Provider, config BreakerConfig) *ModelBreakerProvider {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenFor <= 0 {
+		config.OpenFor = 30 * time.Second
+	}
+	return &ModelBreakerProvider{
+		Provider: inner,
+		config:   config,
+		now:      time.Now,
+		breakers: make(map[string]*ModelBreaker),
+		trials:   make(map[string]bool),
+	}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ModelBreakerProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request unless the model's breaker is open.
+func (p *ModelBreakerProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	trial, err := p.admit(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.Chat(ctx, req)
+	p.record(req.Model, trial, err)
+	return resp, err
+}
+
+// Breaker returns the breaker state of model.
+func (p *ModelBreakerProvider) Breaker(model string) ModelBreaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.breaker(model)
+}
+
+// Breakers returns the breaker state of every model seen so far.
+func (p *ModelBreakerProvider) Breakers() map[string]ModelBreaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]ModelBreaker, len(p.breakers))
+	for model := range p.breakers {
+		out[model] = p.breaker(model)
+	}
+	return out
+}
+
+// breaker returns model's state, reporting an open breaker whose period has
+// ended as half-open. The caller must hold p.mu.
+func (p *ModelBreakerProvider) breaker(model string) ModelBreaker {
+	b, ok := p.breakers[model]
+	if !ok {
+		return ModelBreaker{State: BreakerClosed}
+	}
+	out := *b
+	if out.State == BreakerOpen && p.now().Sub(out.OpenedAt) >= p.config.OpenFor {
+		out.State = BreakerHalfOpen
+	}
+	return out
+}
+
+// admit rejects the request if model's breaker is open, letting a single
+// trial through once the open period has ended. It reports whether the
+// request is that trial, which only its own outcome may resolve.
+func (p *ModelBreakerProvider) admit(model string) (trial bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.breaker(model).State {
+	case BreakerOpen:
+		return false, fmt.Errorf("%w: %s/%s", ErrCircuitOpen, p.ID(), model)
+	case BreakerHalfOpen:
+		if p.trials[model] {
+			return false, fmt.Errorf("%w: %s/%s (trial in progress)", ErrCircuitOpen, p.ID(), model)
+		}
+		p.trials[model] = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates model's breaker with the outcome of a request. trial is
+// what admit returned for it; a request admitted before the breaker opened
+// does not end a trial in flight.
+func (p *ModelBreakerProvider) record(model string, trial bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if trial {
+		delete(p.trials, model)
+	}
+
+	b := p.breakers[model]
+	if b == nil {
+		b = &ModelBreaker{State: BreakerClosed}
+		p.breakers[model] = b
+	}
+	if err == nil || !IsTransient(err) {
+		if err == nil || trial {
+			*b = ModelBreaker{State: BreakerClosed}
+		}
+		return
+	}
+
+	b.Failures++
+	if trial || b.Failures >= p.config.FailureThreshold {
+		b.State, b.OpenedAt = BreakerOpen, p.now()
+	}
+}
@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestModelBreakerProvider(t *testing.T) {
+	transient := &ProviderError{StatusCode: http.StatusServiceUnavailable}
+	permanent := &ProviderError{StatusCode: http.StatusBadRequest}
+
+	type step struct {
+		after     time.Duration // Clock advance before the call
+		model     string
+		err       error // What the inner provider returns if called
+		wantOpen  bool  // Whether the call is rejected by the breaker
+		wantState BreakerState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after consecutive transient failures",
+			steps: []step{
+				{model: "a", err: transient, wantState: BreakerClosed},
+				{model: "a", err: transient, wantState: BreakerOpen},
+				{model: "a", wantOpen: true, wantState: BreakerOpen},
+				{model: "b", wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "success resets the count",
+			steps: []step{
+				{model: "a", err: transient, wantState: BreakerClosed},
+				{model: "a", wantState: BreakerClosed},
+				{model: "a", err: transient, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "permanent failures do not count",
+			steps: []step{
+				{model: "a", err: permanent, wantState: BreakerClosed},
+				{model: "a", err: permanent, wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "successful trial closes",
+			steps: []step{
+				{model: "a", err: transient},
+				{model: "a", err: transient, wantState: BreakerOpen},
+				{after: time.Minute, model: "a", wantState: BreakerClosed},
+			},
+		},
+		{
+			name: "failed trial reopens",
+			steps: []step{
+				{model: "a", err: transient},
+				{model: "a", err: transient, wantState: BreakerOpen},
+				{after: time.Minute, model: "a", err: transient, wantState: BreakerOpen},
+				{after: 30 * time.Second, model: "a", wantOpen: true, wantState: BreakerOpen},
+				{after: 30 * time.Second, model: "a", wantState: BreakerClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var next error
+			inner := &fakeProvider{chat: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+				if next != nil {
+					return nil, next
+				}
+				return &ChatResponse{Content: "ok"}, nil
+			}}
+			p := NewModelBreakerProvider(inner, BreakerConfig{FailureThreshold: 2, OpenFor: time.Minute})
+			now := time.Now()
+			p.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				next = s.err
+				calls := len(inner.requests())
+				_, err := p.Chat(context.Background(), &ChatRequest{Model: s.model})
+				if open := errors.Is(err, ErrCircuitOpen); open != s.wantOpen {
+					t.Fatalf("step %d: err = %v, want open %v", i, err, s.wantOpen)
+				}
+				if s.wantOpen && len(inner.requests()) != calls {
+					t.Errorf("step %d: rejected call was forwarded", i)
+				}
+				if s.wantState != "" {
+					if got := p.Breaker(s.model).State; got != s.wantState {
+						t.Errorf("step %d: state = %s, want %s", i, got, s.wantState)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestModelBreakerSingleTrial(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	inner := &fakeProvider{chat: scripted(outcome{err: &ProviderError{StatusCode: http.StatusBadGateway}})}
+	p := NewModelBreakerProvider(inner, BreakerConfig{FailureThreshold: 1, OpenFor: time.Minute})
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	p.Chat(context.Background(), &ChatRequest{Model: "a"})
+
+	now = now.Add(time.Minute)
+	if got := p.Breakers()["a"].State; got != BreakerHalfOpen {
+		t.Fatalf("state = %s, want %s", got, BreakerHalfOpen)
+	}
+	inner.chat = func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		close(started)
+		<-release
+		return &ChatResponse{}, nil
+	}
+	done := make(chan error)
+	go func() {
+		_, err := p.Chat(context.Background(), &ChatRequest{Model: "a"})
+		done <- err
+	}()
+	<-started
+	if _, err := p.Chat(context.Background(), &ChatRequest{Model: "a"}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second request during the trial: err = %v, want %v", err, ErrCircuitOpen)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Breaker("a").State; got != BreakerClosed {
+		t.Errorf("state after the trial = %s, want %s", got, BreakerClosed)
+	}
+}
+
+func TestModelBreakerStragglerDoesNotEndTrial(t *testing.T) {
+	release := map[string]chan struct{}{"straggler": make(chan struct{}), "trial": make(chan struct{})}
+	started := make(chan string)
+	inner := &fakeProvider{chat: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		switch name := req.Messages[0].Content; name {
+		case "failure":
+			return nil, &ProviderError{StatusCode: http.StatusBadGateway}
+		case "straggler", "trial":
+			started <- name
+			<-release[name]
+			if name == "straggler" {
+				return nil, &ProviderError{StatusCode: http.StatusBadRequest}
+			}
+		}
+		return &ChatResponse{}, nil
+	}}
+	p := NewModelBreakerProvider(inner, BreakerConfig{FailureThreshold: 1, OpenFor: time.Minute})
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	chat := func(name string) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := p.Chat(context.Background(), &ChatRequest{Model: "a", Messages: userMessages(name)})
+			done <- err
+		}()
+		return done
+	}
+
+	straggler := chat("straggler") // Admitted while the breaker is closed
+	<-started
+	<-chat("failure") // Opens the breaker
+	now = now.Add(time.Minute)
+	trial := chat("trial")
+	<-started
+
+	close(release["straggler"])
+	<-straggler
+	if got := p.Breaker("a").State; got != BreakerHalfOpen {
+		t.Errorf("state after the straggler = %s, want %s", got, BreakerHalfOpen)
+	}
+	if err := <-chat("probe"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("request during the trial: err = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	close(release["trial"])
+	if err := <-trial; err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Breaker("a").State; got != BreakerClosed {
+		t.Errorf("state after the trial = %s, want %s", got, BreakerClosed)
+	}
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// CoalesceConfig configures CoalescingProvider. At least one of the fields
+// should be set; with neither, content is only emitted at the end of the stream.
+type CoalesceConfig struct {
+	// MinBytes emits buffered content once it reaches this size.
+	MinBytes int
+
+	// FlushInterval emits buffered content this long after it started
+	// accumulating, bounding the latency coalescing adds.
+	FlushInterval time.Duration
+}
+
+// CoalescingProvider wraps a StreamingProvider and merges small chunks into
+// larger ones, for consumers where per-chunk overhead (a network write or a
+// UI render) outweighs the latency of waiting for more text. Content and its
+// order are unchanged.
+type CoalescingProvider struct {
+	StreamingProvider
+	config CoalesceConfig
+}
+
+// NewCoalescingProvider creates a provider that coalesces stream chunks per config.
+func NewCoalescingProvider(inner StreamingProvider, config CoalesceConfig) *CoalescingProvider {
+	return &CoalescingProvider{StreamingProvider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CoalescingProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream relays the stream with small chunks merged.
+func (p *CoalescingProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		var buf strings.Builder
+		var carry runeCarry
+		var deadline <-chan time.Time
+
+		// flush emits the buffer, holding back a trailing partial rune unless
+		// the stream is ending.
+		flush := func(final bool) {
+			content := carry.complete(buf.String())
+			if final {
+				content += carry.flush()
+			}
+			if content != "" {
+				emit(StreamChunk{Content: content})
+			}
+			buf.Reset()
+			deadline = nil
+		}
+
+		for {
+			select {
+			case chunk, ok := <-in:
+				if chunk = normalizeChunk(streamCtx, chunk, ok); chunk.Done {
+					flush(true)
+					return chunk
+				}
+				if buf.Len() == 0 {
+					deadline = timerChan(p.config.FlushInterval)
+				}
+				buf.WriteString(chunk.Content)
+				if p.config.MinBytes > 0 && buf.Len() >= p.config.MinBytes {
+					flush(false)
+				}
+			case <-deadline:
+				flush(false)
+			case <-streamCtx.Done():
+				flush(true)
+				return StreamChunk{Done: true, Reason: ctxEndReason(streamCtx), Err: streamCtx.Err()}
+			}
+		}
+	}), nil
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestCoalescingProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		inner  *fakeStreamer
+		config CoalesceConfig
+		want   []string
+	}{
+		{"by size", streamingReply("a", "b", "c", "d", "e"), CoalesceConfig{MinBytes: 3}, []string{"abc", "de"}},
+		{"large chunks pass through", streamingReply("hello", "world"), CoalesceConfig{MinBytes: 3}, []string{"hello", "world"}},
+		{"no limits emits at the end", streamingReply("a", "b"), CoalesceConfig{}, []string{"ab"}},
+		{"split rune held back", streamingReply("h\xc3", "\xa9llo"), CoalesceConfig{MinBytes: 2}, []string{"h", "éllo"}},
+		{"by interval", pacedStreamer(30*time.Millisecond, textChunks("a", "b")...), CoalesceConfig{MinBytes: 100, FlushInterval: 10 * time.Millisecond}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := NewCoalescingProvider(tt.inner, tt.config).ChatStream(context.Background(), &ChatRequest{Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			var final StreamChunk
+			for c := range chunks {
+				if c.Done {
+					final = c
+					continue
+				}
+				if !utf8.ValidString(c.Content) {
+					t.Errorf("chunk %q is not valid UTF-8", c.Content)
+				}
+				got = append(got, c.Content)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+			if final.Reason != StreamCompleted {
+				t.Errorf("final = %+v", final)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package llm
+
+import "strings"
+
+// FenceBoundary marks a FencedChunk that opens or closes a code block.
+type FenceBoundary int
+
+const (
+	FenceNone  FenceBoundary = iota // Content inside or outside a block
+	FenceOpen                       // The opening fence line, e.g. "```go\n"
+	FenceClose                      // The closing fence line
+)
+
+// FencedChunk is a stream chunk annotated with its position relative to
+// markdown code fences, so a UI can switch rendering modes while streaming.
+type FencedChunk struct {
+	StreamChunk
+
+	InCode   bool          // The content is inside a code block (fence lines count as inside)
+	Language string        // The opening fence's info string, e.g. "go"; empty if none
+	Boundary FenceBoundary // Set on the chunk carrying a fence line
+}
+
+// AnnotateCodeFences splits and annotates the chunks of in at code-fence
+// boundaries. Content is forwarded unchanged apart from the splitting; text
+// that could still turn out to be a fence is held until its line completes.
+// The terminal chunk is forwarded last. Consumers must drain the channel.
+func AnnotateCodeFences(in <-chan StreamChunk) <-chan FencedChunk {
+	out := make(chan FencedChunk)
+	go func() {
+		defer close(out)
+
+		var tracker FenceTracker
+		for chunk := range in {
+			if chunk.Done {
+				for _, seg := range tracker.Flush() {
+					out <- seg
+				}
+				out <- FencedChunk{StreamChunk: chunk, InCode: tracker.inCode, Language: tracker.lang}
+				continue
+			}
+			for _, seg := range tracker.Feed(chunk.Content) {
+				out <- seg
+			}
+		}
+	}()
+	return out
+}
+
+// FenceTracker is the state machine behind AnnotateCodeFences, for consumers
+// that receive content some other way. The zero value is ready to use.
+type FenceTracker struct {
+	inCode      bool
+	fence       string // The opening fence's run of backticks or tildes
+	lang        string
+	pending     string // The start of a line that may be a fence
+	midLine     bool   // Part of the current line was emitted, so it is not a fence
+	annotations []FencedChunk
+}
+
+// Feed consumes the next piece of content and returns the annotated segments
+// that can be emitted so far.
+func (t *FenceTracker) Feed(content string) []FencedChunk {
+	t.annotations = nil
+	for content != "" {
+		line, rest, complete := cutLine(content)
+		content = rest
+
+		if t.midLine {
+			t.emit(line, FenceNone)
+			t.midLine = !complete
+			continue
+		}
+
+		t.pending += line
+		switch {
+		case complete:
+			t.endLine()
+		case !mayBeFence(t.pending):
+			t.emit(t.pending, FenceNone)
+			t.pending, t.midLine = "", true
+		}
+	}
+	return t.annotations
+}
+
+// Flush returns any held content at the end of the stream.
+func (t *FenceTracker) Flush() []FencedChunk {
+	t.annotations = nil
+	if t.pending != "" {
+		t.endLine()
+	}
+	t.midLine = false
+	return t.annotations
+}
+
+// endLine classifies the complete line held in pending and emits it.
+func (t *FenceTracker) endLine() {
+	line := t.pending
+	t.pending = ""
+
+	fence, info, ok := parseFence(line)
+	switch {
+	case ok && !t.inCode:
+		t.inCode, t.fence, t.lang = true, fence, ""
+		if fields := strings.Fields(info); len(fields) > 0 {
+			t.lang = fields[0]
+		}
+		t.emit(line, FenceOpen)
+	case ok && t.inCode && fence[0] == t.fence[0] && len(fence) >= len(t.fence) && strings.TrimSpace(info) == "":
+		t.emit(line, FenceClose)
+		t.inCode, t.fence, t.lang = false, "", ""
+	default:
+		t.emit(line, FenceNone)
+	}
+}
+
+func (t *FenceTracker) emit(text string, boundary FenceBoundary) {
+	if text == "" {
+		return
+	}
+	t.annotations = append(t.annotations, FencedChunk{
+		StreamChunk: StreamChunk{Content: text},
+		InCode:      t.inCode,
+		Language:    t.lang,
+		Boundary:    boundary,
+	})
+}
+
+// cutLine splits s after its first newline, reporting whether one was found.
+func cutLine(s string) (line, rest string, complete bool) {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i+1], s[i+1:], true
+	}
+	return s, "", false
+}
+
+// parseFence recognizes a fence line: up to three spaces of indentation, a run
+// of at least three backticks or tildes, then the info string.
+func parseFence(line string) (fence, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || trimmed == "" || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return "", "", false
+	}
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, trimmed[:1]))
+	if n < 3 {
+		return "", "", false
+	}
+	info = strings.TrimSpace(trimmed[n:])
+	if trimmed[0] == '`' && strings.Contains(info, "`") {
+		return "", "", false
+	}
+	return trimmed[:n], info, true
+}
+
+// mayBeFence reports whether an incomplete line could still become a fence.
+func mayBeFence(partial string) bool {
+	trimmed := strings.TrimLeft(partial, " ")
+	if len(partial)-len(trimmed) > 3 {
+		return false
+	}
+	for _, fence := range []string{"```", "~~~"} {
+		if strings.HasPrefix(fence, trimmed) || strings.HasPrefix(trimmed, fence) {
+			return true
+		}
+	}
+	return false
+}
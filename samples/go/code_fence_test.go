@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateCodeFences(t *testing.T) {
+	// Segments are rendered as "<boundary>|<in code>|<language>|<content>".
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			"plain text",
+			[]string{"hello ", "world"},
+			[]string{"0|false||hello ", "0|false||world"},
+		},
+		{
+			"fence split across chunks",
+			[]string{"Try:\n`", "``go\nfmt.Println()\n``", "`\ndone"},
+			[]string{"0|false||Try:\n", "1|true|go|```go\n", "0|true|go|fmt.Println()\n", "2|true|go|```\n", "0|false||done"},
+		},
+		{
+			"tilde fence closed only by tildes",
+			[]string{"~~~\n```\n~~~\n"},
+			[]string{"1|true||~~~\n", "0|true||```\n", "2|true||~~~\n"},
+		},
+		{
+			"backticks mid-line are not a fence",
+			[]string{"use ```go``` inline\n"},
+			[]string{"0|false||use ```go``` inline\n"},
+		},
+		{
+			"indented four spaces is not a fence",
+			[]string{"    ```\n"},
+			[]string{"0|false||    ```\n"},
+		},
+		{
+			"unterminated fence flushed at the end",
+			[]string{"```py"},
+			[]string{"1|true|py|```py"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan StreamChunk, len(tt.chunks)+1)
+			for _, c := range tt.chunks {
+				in <- StreamChunk{Content: c}
+			}
+			in <- StreamChunk{Done: true, Reason: StreamCompleted}
+			close(in)
+
+			var got []string
+			var content strings.Builder
+			var final FencedChunk
+			for seg := range AnnotateCodeFences(in) {
+				if seg.Done {
+					final = seg
+					continue
+				}
+				got = append(got, fmt.Sprintf("%d|%v|%s|%s", seg.Boundary, seg.InCode, seg.Language, seg.Content))
+				content.WriteString(seg.Content)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("segments:\n%q\nwant:\n%q", got, tt.want)
+			}
+			if content.String() != strings.Join(tt.chunks, "") {
+				t.Errorf("content %q was altered", content.String())
+			}
+			if !final.Done || final.Reason != StreamCompleted {
+				t.Errorf("final = %+v", final)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrIncoherentConversation is returned for conversations whose role
+// transitions a strict backend would reject.
+var ErrIncoherentConversation = errors.New("incoherent conversation")
+
+// MetadataCoherenceRepaired is set to true on responses to conversations a
+// lenient CoherenceProvider had to repair.
+const MetadataCoherenceRepaired = "coherence_repaired"
+
+// CoherenceError reports the first invalid role transition in a conversation.
+type CoherenceError struct {
+	Index  int // The offending message
+	Reason string
+}
+
+func (e *CoherenceError) Error() string {
+	return fmt.Sprintf("%v: message %d: %s", ErrIncoherentConversation, e.Index, e.Reason)
+}
+
+// Is reports whether target is ErrIncoherentConversation.
+func (e *CoherenceError) Is(target error) bool {
+	return target == ErrIncoherentConversation
+}
+
+// CoherenceProvider wraps a Provider and checks a conversation's role
+// transitions before dispatch: no two assistant messages are adjacent, every
+// tool message directly follows the assistant message whose call it answers,
+// every tool call is answered, and the conversation ends with a user or tool
+// message. In strict mode violations are rejected with a CoherenceError. In
+// lenient mode they are repaired: orphaned tool results and unanswered tool
+// calls are dropped, adjacent assistant messages are merged and a trailing
+// assistant message is removed.
+type CoherenceProvider struct {
+	Provider
+	lenient bool
+}
+
+// NewCoherenceProvider creates a provider that validates, or if lenient
+// repairs, role transitions.
+func NewCoherenceProvider(inner Provider, lenient bool) *CoherenceProvider {
+	return &CoherenceProvider{Provider: inner, lenient: lenient}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CoherenceProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat validates or repairs the conversation and forwards it.
+func (p *CoherenceProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	err := ValidateCoherence(req.Messages)
+	if err == nil {
+		return p.Provider.Chat(ctx, req)
+	}
+	if !p.lenient {
+		return nil, err
+	}
+
+	repaired := *req
+	repaired.Messages = repairCoherence(req.Messages)
+	resp, err := p.Provider.Chat(ctx, &repaired)
+	if err != nil {
+		return nil, err
+	}
+	resp.SetMetadata(MetadataCoherenceRepaired, true)
+	return resp, nil
+}
+
+// ValidateCoherence returns a CoherenceError for the first invalid role
+// transition in messages, or nil; see CoherenceProvider for the rules.
+func ValidateCoherence(messages []Message) error {
+	for i := 0; i < len(messages); i++ {
+		m := messages[i]
+		switch {
+		case m.Role == "tool":
+			return &CoherenceError{Index: i, Reason: "tool message does not follow a tool call"}
+		case m.Role == "assistant" && i > 0 && messages[i-1].Role == "assistant":
+			return &CoherenceError{Index: i, Reason: "adjacent assistant messages"}
+		case m.Role == "assistant" && len(m.ToolCalls) > 0:
+			answered := make(map[string]bool)
+			for i+1 < len(messages) && messages[i+1].Role == "tool" {
+				i++
+				id := messages[i].ToolCallID
+				if answered[id] || !slices.ContainsFunc(m.ToolCalls, func(c ToolCall) bool { return c.ID == id }) {
+					return &CoherenceError{Index: i, Reason: fmt.Sprintf("tool result for unknown or answered call %q", id)}
+				}
+				answered[id] = true
+			}
+			for _, call := range m.ToolCalls {
+				if !answered[call.ID] {
+					return &CoherenceError{Index: i, Reason: fmt.Sprintf("tool call %q has no result", call.ID)}
+				}
+			}
+		}
+	}
+	if n := len(messages); n > 0 && messages[n-1].Role == "assistant" {
+		return &CoherenceError{Index: n - 1, Reason: "conversation ends with an assistant message"}
+	}
+	return nil
+}
+
+// repairCoherence returns a copy of messages with its role transitions repaired.
+func repairCoherence(messages []Message) []Message {
+	// Pair tool results with their calls, dropping whatever is unmatched.
+	paired := make([]Message, 0, len(messages))
+	for i := 0; i < len(messages); i++ {
+		m := messages[i]
+		if m.Role == "tool" {
+			continue
+		}
+		if m.Role != "assistant" || len(m.ToolCalls) == 0 {
+			paired = append(paired, m)
+			continue
+		}
+
+		var results []Message
+		answered := make(map[string]bool)
+		for i+1 < len(messages) && messages[i+1].Role == "tool" {
+			i++
+			id := messages[i].ToolCallID
+			if !answered[id] && slices.ContainsFunc(m.ToolCalls, func(c ToolCall) bool { return c.ID == id }) {
+				answered[id] = true
+				results = append(results, messages[i])
+			}
+		}
+		m.ToolCalls = slices.DeleteFunc(slices.Clone(m.ToolCalls), func(c ToolCall) bool { return !answered[c.ID] })
+		if m.Content == "" && len(m.ToolCalls) == 0 {
+			continue
+		}
+		paired = append(paired, m)
+		paired = append(paired, results...)
+	}
+
+	// Merge adjacent assistant messages. After pairing, an assistant message
+	// followed by another has no tool calls.
+	out := make([]Message, 0, len(paired))
+	for _, m := range paired {
+		if n := len(out); n > 0 && m.Role == "assistant" && out[n-1].Role == "assistant" {
+			prev := &out[n-1]
+			if prev.Content != "" && m.Content != "" {
+				prev.Content += "\n\n"
+			}
+			prev.Content += m.Content
+			prev.ToolCalls = m.ToolCalls
+			continue
+		}
+		out = append(out, m)
+	}
+
+	if n := len(out); n > 0 && out[n-1].Role == "assistant" {
+		out = out[:n-1]
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCoherence(t *testing.T) {
+	user := Message{Role: "user", Content: "hi"}
+	reply := Message{Role: "assistant", Content: "hello"}
+	call := Message{Role: "assistant", ToolCalls: []ToolCall{{ID: "c1", Name: "f"}}}
+	result := Message{Role: "tool", ToolCallID: "c1", Content: "r"}
+	calls := Message{Role: "assistant", Content: "checking", ToolCalls: []ToolCall{{ID: "c1", Name: "f"}, {ID: "c2", Name: "g"}}}
+
+	tests := []struct {
+		name      string
+		messages  []Message
+		wantIndex int       // Index of the CoherenceError, or -1 if valid
+		want      []Message // The repaired conversation
+	}{
+		{name: "empty", wantIndex: -1},
+		{name: "tool round trip", messages: []Message{user, call, result}, wantIndex: -1},
+		{name: "reply then follow-up", messages: []Message{user, reply, user}, wantIndex: -1},
+		{
+			name:      "orphaned tool result",
+			messages:  []Message{user, result},
+			wantIndex: 1,
+			want:      []Message{user},
+		},
+		{
+			name:      "adjacent assistant messages",
+			messages:  []Message{user, reply, {Role: "assistant", Content: "again"}, user},
+			wantIndex: 2,
+			want:      []Message{user, {Role: "assistant", Content: "hello\n\nagain"}, user},
+		},
+		{
+			name:      "unanswered call",
+			messages:  []Message{user, calls, result, user},
+			wantIndex: 2,
+			want:      []Message{user, {Role: "assistant", Content: "checking", ToolCalls: calls.ToolCalls[:1]}, result, user},
+		},
+		{
+			name:      "call with no results",
+			messages:  []Message{user, call, user},
+			wantIndex: 1,
+			want:      []Message{user, user},
+		},
+		{
+			name:      "duplicate result",
+			messages:  []Message{user, call, result, result},
+			wantIndex: 3,
+			want:      []Message{user, call, result},
+		},
+		{
+			name:      "ends with assistant",
+			messages:  []Message{user, reply},
+			wantIndex: 1,
+			want:      []Message{user},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCoherence(tt.messages)
+			var cerr *CoherenceError
+			if tt.wantIndex < 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.As(err, &cerr) || !errors.Is(err, ErrIncoherentConversation) || cerr.Index != tt.wantIndex {
+				t.Fatalf("err = %v, want a CoherenceError at %d", err, tt.wantIndex)
+			}
+
+			got := repairCoherence(tt.messages)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("repaired = %+v, want %+v", got, tt.want)
+			}
+			if err := ValidateCoherence(got); err != nil {
+				t.Errorf("repaired conversation is still incoherent: %v", err)
+			}
+		})
+	}
+}
+
+func TestCoherenceProvider(t *testing.T) {
+	valid := userMessages("hi")
+	invalid := []Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}}
+	tests := []struct {
+		name         string
+		lenient      bool
+		messages     []Message
+		wantErr      error
+		wantRepaired bool
+	}{
+		{name: "valid", messages: valid},
+		{name: "strict rejects", messages: invalid, wantErr: ErrIncoherentConversation},
+		{name: "lenient repairs", lenient: true, messages: invalid, wantRepaired: true},
+		{name: "lenient leaves valid alone", lenient: true, messages: valid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			resp, err := NewCoherenceProvider(inner, tt.lenient).Chat(context.Background(), &ChatRequest{Messages: tt.messages})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(inner.requests()) != 0 {
+					t.Error("rejected request was forwarded")
+				}
+				return
+			}
+			if repaired, _ := resp.Metadata[MetadataCoherenceRepaired].(bool); repaired != tt.wantRepaired {
+				t.Errorf("repaired = %v, want %v", repaired, tt.wantRepaired)
+			}
+			if sent := inner.requests()[0].Messages; ValidateCoherence(sent) != nil {
+				t.Errorf("forwarded incoherent conversation %+v", sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MetadataCompressedTurns records how many turns a CompressingProvider
+// replaced with a summary.
+const MetadataCompressedTurns = "compressed_turns"
+
+// CompressionConfig configures a CompressingProvider.
+type CompressionConfig struct {
+	// Threshold is the prompt size, in tokens, above which older turns are
+	// summarized.
+	Threshold int
+
+	// KeepRecent is the number of most recent messages kept verbatim. Defaults to 4.
+	KeepRecent int
+
+	// Counter estimates prompt size. Defaults to HeuristicTokenCounter.
+	Counter TokenCounter
+
+	// Summarizer produces the summary. Defaults to the wrapped provider.
+	Summarizer Provider
+
+	// SummaryModel is the model asked for the summary. Defaults to the
+	// request's model.
+	SummaryModel string
+}
+
+// CompressingProvider wraps a Provider and, when a conversation grows past a
+// token threshold, replaces its older turns with a model-written summary.
+// Unlike truncation this keeps the gist of the earlier conversation. Leading
+// system messages and the most recent turns are always sent unchanged.
+type CompressingProvider struct {
+	Provider
+	cfg CompressionConfig
+}
+
+// NewCompressingProvider creates a provider that compresses long conversations.
+func NewCompressingProvider(inner Provider, cfg CompressionConfig) *CompressingProvider {
+	if cfg.KeepRecent <= 0 {
+		cfg.KeepRecent = 4
+	}
+	if cfg.Counter == nil {
+		cfg.Counter = HeuristicTokenCounter{}
+	}
+	if cfg.Summarizer == nil {
+		cfg.Summarizer = inner
+	}
+	return &CompressingProvider{Provider: inner, cfg: cfg}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *CompressingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat compresses the conversation if it is over the threshold, then forwards it.
+func (p *CompressingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	compressed, turns, err := p.Compress(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Provider.Chat(ctx, compressed)
+	if err != nil {
+		return nil, err
+	}
+	if turns > 0 {
+		resp.SetMetadata(MetadataCompressedTurns, turns)
+	}
+	return resp, nil
+}
+
+// Compress returns req with its older turns summarized, and the number of
+// turns replaced. Requests under the threshold are returned unchanged.
+func (p *CompressingProvider) Compress(ctx context.Context, req *ChatRequest) (*ChatRequest, int, error) {
+	if p.cfg.Counter.CountMessages(req.Model, req.Messages) <= p.cfg.Threshold {
+		return req, 0, nil
+	}
+
+	start := 0
+	for start < len(req.Messages) && req.Messages[start].Role == "system" {
+		start++
+	}
+	// Never split a tool result from the assistant message that requested it.
+	split := max(len(req.Messages)-p.cfg.KeepRecent, start)
+	for split > start && req.Messages[split].Role == "tool" {
+		split--
+	}
+	if split-start < 2 {
+		return req, 0, nil
+	}
+
+	model := req.Model
+	if p.cfg.SummaryModel != "" {
+		model = p.cfg.SummaryModel
+	}
+	summary, err := summarizeTurns(ctx, p.cfg.Summarizer, model, req.Messages[start:split])
+	if err != nil {
+		return nil, 0, fmt.Errorf("compress conversation: %w", err)
+	}
+
+	out := *req
+	out.Messages = make([]Message, 0, start+1+len(req.Messages)-split)
+	out.Messages = append(out.Messages, req.Messages[:start]...)
+	out.Messages = append(out.Messages, Message{Role: "system", Content: "Summary of the earlier conversation:\n" + summary})
+	out.Messages = append(out.Messages, req.Messages[split:]...)
+	return &out, split - start, nil
+}
+
+// summarizeTurns asks summarizer, on model, for a summary of turns.
+func summarizeTurns(ctx context.Context, summarizer Provider, model string, turns []Message) (string, error) {
+	var transcript strings.Builder
+	for _, m := range turns {
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+		for _, call := range m.ToolCalls {
+			fmt.Fprintf(&transcript, "%s called %s(%s)\n", m.Role, call.Name, call.Arguments)
+		}
+	}
+
+	resp, err := summarizer.Chat(ctx, &ChatRequest{
+		Model: model,
+		Messages: []Message{
+			{Role: "system", Content: "Summarize the conversation below. Keep every fact, decision, name and open question the rest of the conversation may rely on. Be concise."},
+			{Role: "user", Content: transcript.String()},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCompressingProvider(t *testing.T) {
+	sys := Message{Role: "system", Content: "be brief"}
+	turn := func(role, content string) Message { return Message{Role: role, Content: content} }
+	long := []Message{sys, turn("user", "aaaa"), turn("assistant", "bbbb"), turn("user", "cccc"), turn("assistant", "dddd"), turn("user", "eeee")}
+	withTool := []Message{sys, turn("user", "aaaa"), turn("assistant", "bbbb"), turn("user", "cccc"),
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "1", Name: "f"}}}, {Role: "tool", ToolCallID: "1", Content: "dddd"}, turn("user", "eeee")}
+
+	tests := []struct {
+		name       string
+		messages   []Message
+		threshold  int
+		keepRecent int
+		summaryErr error
+		wantRoles  []string // Roles sent to the inner provider
+		wantTurns  int
+		wantErr    bool
+	}{
+		{"under threshold", long, 100, 2, nil, []string{"system", "user", "assistant", "user", "assistant", "user"}, 0, false},
+		{"older turns summarized", long, 10, 2, nil, []string{"system", "system", "assistant", "user"}, 3, false},
+		{"tool result kept with its call", withTool, 10, 2, nil, []string{"system", "system", "assistant", "tool", "user"}, 3, false},
+		{"too few turns to compress", long, 10, 4, nil, []string{"system", "user", "assistant", "user", "assistant", "user"}, 0, false},
+		{"summarizer fails", long, 10, 2, ErrRateLimited, nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			summarizer := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+				if tt.summaryErr != nil {
+					return nil, tt.summaryErr
+				}
+				return &ChatResponse{Content: " the gist "}, nil
+			}}
+			p := NewCompressingProvider(inner, CompressionConfig{Threshold: tt.threshold, KeepRecent: tt.keepRecent, Counter: wordCounter{}, Summarizer: summarizer, SummaryModel: "cheap"})
+			resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m", Messages: tt.messages})
+			if tt.wantErr {
+				if !errors.Is(err, tt.summaryErr) || len(inner.requests()) != 0 {
+					t.Fatalf("err = %v, want %v before dispatch", err, tt.summaryErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			sent := inner.requests()[0].Messages
+			var roles []string
+			for _, m := range sent {
+				roles = append(roles, m.Role)
+			}
+			if !slices.Equal(roles, tt.wantRoles) {
+				t.Errorf("roles = %v, want %v", roles, tt.wantRoles)
+			}
+			if got, _ := resp.Metadata[MetadataCompressedTurns].(int); got != tt.wantTurns {
+				t.Errorf("compressed turns = %d, want %d", got, tt.wantTurns)
+			}
+			if tt.wantTurns == 0 {
+				return
+			}
+			if got := sent[1].Content; got != "Summary of the earlier conversation:\nthe gist" {
+				t.Errorf("summary message = %q", got)
+			}
+			if got := summarizer.requests()[0].Model; got != "cheap" {
+				t.Errorf("summary model = %q, want cheap", got)
+			}
+		})
+	}
+}
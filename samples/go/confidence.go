@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrLowConfidence is returned when a completion's confidence is below the required minimum.
+var ErrLowConfidence = errors.New("response confidence below threshold")
+
+// Confidence returns the aggregate confidence of a completion as the geometric
+// mean of its token probabilities, in the range [0, 1]. It returns 0 when no
+// logprobs are available.
+func Confidence(logprobs []TokenLogprob) float64 {
+	if len(logprobs) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, lp := range logprobs {
+		sum += lp.Logprob
+	}
+	return math.Exp(sum / float64(len(logprobs)))
+}
+
+// LowConfidenceTokens returns the positions of the tokens whose probability
+// is below minProbability, for highlighting uncertain output.
+func LowConfidenceTokens(logprobs []TokenLogprob, minProbability float64) []int {
+	var positions []int
+	for i, lp := range logprobs {
+		if math.Exp(lp.Logprob) < minProbability {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// ConfidenceProvider wraps a Provider and rejects completions whose aggregate
+// confidence is below a minimum. It requests logprobs on every call.
+type ConfidenceProvider struct {
+	Provider
+	minConfidence float64
+}
+
+// NewConfidenceProvider creates a provider that requires at least minConfidence (0 to 1).
+func NewConfidenceProvider(inner Provider, minConfidence float64) *ConfidenceProvider {
+	return &ConfidenceProvider{Provider: inner, minConfidence: minConfidence}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ConfidenceProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request with logprobs enabled and gates the response on its confidence.
+// Responses without logprobs are rejected, since their confidence cannot be established.
+func (p *ConfidenceProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	gated := *req
+	gated.Logprobs = true
+
+	resp, err := p.Provider.Chat(ctx, &gated)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Logprobs) == 0 {
+		return nil, fmt.Errorf("%w: provider returned no logprobs", ErrLowConfidence)
+	}
+	if c := Confidence(resp.Logprobs); c < p.minConfidence {
+		return nil, fmt.Errorf("%w: %.3f < %.3f", ErrLowConfidence, c, p.minConfidence)
+	}
+	return resp, nil
+}
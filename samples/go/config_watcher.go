@@ -0,0 +1,246 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrInvalidConfig is returned when a providers config file fails validation.
+var ErrInvalidConfig = errors.New("invalid providers config")
+
+// ProviderConfig describes a single provider in a providers config file.
+type ProviderConfig struct {
+	ID      string            `json:"id"`
+	Type    string            `json:"type"` // e.g. "openai", "azure", "ollama"
+	BaseURL string            `json:"base_url,omitempty"`
+	APIKey  string            `json:"api_key,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// ProvidersConfig is the top-level shape of a providers config file.
+type ProvidersConfig struct {
+	Default   string           `json:"default,omitempty"`
+	Providers []ProviderConfig `json:"providers"`
+}
+
+// Validate checks that every provider has a unique ID and a type, and that the
+// default, if set, names a configured provider.
+func (c *ProvidersConfig) Validate() error {
+	seen := make(map[string]bool, len(c.Providers))
+	for i, pc := range c.Providers {
+		if pc.ID == "" {
+			return fmt.Errorf("%w: provider %d has no id", ErrInvalidConfig, i)
+		}
+		if pc.Type == "" {
+			return fmt.Errorf("%w: provider %q has no type", ErrInvalidConfig, pc.ID)
+		}
+		if seen[pc.ID] {
+			return fmt.Errorf("%w: duplicate provider id %q", ErrInvalidConfig, pc.ID)
+		}
+		seen[pc.ID] = true
+	}
+	if c.Default != "" && !seen[c.Default] {
+		return fmt.Errorf("%w: default provider %q is not configured", ErrInvalidConfig, c.Default)
+	}
+	return nil
+}
+
+// ProviderFactory builds a Provider from its config entry.
+type ProviderFactory func(cfg ProviderConfig) (Provider, error)
+
+// ConfigWatcherOptions configures a ConfigWatcher.
+type ConfigWatcherOptions struct {
+	// Interval is how often the file is checked for changes. Defaults to 2s.
+	Interval time.Duration
+
+	// Decode parses the file contents. Defaults to JSON. The package has no YAML
+	// dependency, so YAML files need a decoder supplied here.
+	Decode func(data []byte) (*ProvidersConfig, error)
+
+	// OnError is called when a reload is rejected, in which case the running
+	// registry is left untouched, and for config entries skipped because their
+	// ID is registered by other code.
+	OnError func(err error)
+}
+
+// ConfigWatcher keeps a ProviderRegistry in sync with a providers config file.
+//
+// It only manages providers it registered itself; providers registered by
+// other code are never replaced or removed, and config entries with their IDs
+// are skipped. Providers it registers are pre-warmed like any other.
+type ConfigWatcher struct {
+	path     string
+	registry *ProviderRegistry
+	factory  ProviderFactory
+	opts     ConfigWatcherOptions
+
+	mu      sync.Mutex
+	applied map[string]ProviderConfig
+	modTime time.Time
+	size    int64
+}
+
+// NewConfigWatcher creates a watcher that reconciles registry with the config file at path.
+func NewConfigWatcher(path string, registry *ProviderRegistry, factory ProviderFactory, opts ConfigWatcherOptions) *ConfigWatcher {
+	if opts.Interval <= 0 {
+		opts.Interval = 2 * time.Second
+	}
+	if opts.Decode == nil {
+		opts.Decode = decodeJSONConfig
+	}
+	return &ConfigWatcher{
+		path:     path,
+		registry: registry,
+		factory:  factory,
+		opts:     opts,
+		applied:  make(map[string]ProviderConfig),
+	}
+}
+
+// Run loads the config and then polls it for changes until ctx is canceled.
+// The initial load error, if any, is returned; later errors go to OnError.
+func (w *ConfigWatcher) Run(ctx context.Context) error {
+	if err := w.Reload(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			changed, err := w.changed()
+			if err == nil && changed {
+				err = w.Reload()
+			}
+			if err != nil && w.opts.OnError != nil {
+				w.opts.OnError(err)
+			}
+		}
+	}
+}
+
+// Reload reads, validates and applies the config file. An invalid config, or
+// one whose providers fail to build, is rejected without changing the registry.
+func (w *ConfigWatcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	cfg, err := w.opts.Decode(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	desired := make(map[string]ProviderConfig, len(cfg.Providers))
+	var build []Provider
+	for _, pc := range cfg.Providers {
+		desired[pc.ID] = pc
+		if prev, ok := w.applied[pc.ID]; ok && reflect.DeepEqual(prev, pc) {
+			continue
+		}
+		provider, err := w.factory(pc)
+		if err != nil {
+			return fmt.Errorf("%w: provider %q: %v", ErrInvalidConfig, pc.ID, err)
+		}
+		if provider.ID() != pc.ID {
+			return fmt.Errorf("%w: provider %q built with id %q", ErrInvalidConfig, pc.ID, provider.ID())
+		}
+		build = append(build, provider)
+	}
+
+	var remove []string
+	for id := range w.applied {
+		if _, ok := desired[id]; !ok {
+			remove = append(remove, id)
+		}
+	}
+
+	skipped := w.registry.reconcile(build, remove, cfg.Default, w.applied)
+	for _, id := range skipped {
+		delete(desired, id)
+		if w.opts.OnError != nil {
+			w.opts.OnError(fmt.Errorf("%w: provider %q is registered by other code; skipped", ErrInvalidConfig, id))
+		}
+	}
+	w.applied = desired
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return nil
+}
+
+// changed reports whether the file has been modified since the last successful reload.
+func (w *ConfigWatcher) changed() (bool, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size, nil
+}
+
+// reconcile registers or replaces the given providers, removes the given IDs and
+// updates the default, all under a single lock so callers never observe a
+// partially applied config. An empty defaultID clears a default that points at
+// an owned provider. Providers whose ID is registered but not in owned are
+// left alone, and their IDs returned. Registered providers are pre-warmed as
+// by Register.
+func (r *ProviderRegistry) reconcile(register []Provider, remove []string, defaultID string, owned map[string]ProviderConfig) (skipped []string) {
+	var added []Provider
+
+	r.mu.Lock()
+	for _, id := range remove {
+		delete(r.providers, id)
+		if r.defaultID == id {
+			r.defaultID = ""
+		}
+	}
+	for _, p := range register {
+		if _, exists := r.providers[p.ID()]; exists {
+			if _, ok := owned[p.ID()]; !ok {
+				skipped = append(skipped, p.ID())
+				continue
+			}
+		}
+		r.providers[p.ID()] = p
+		added = append(added, p)
+	}
+	if _, ok := owned[r.defaultID]; ok || defaultID != "" {
+		r.defaultID = defaultID
+	}
+	r.mu.Unlock()
+
+	for _, p := range added {
+		r.prewarm(p)
+	}
+	return skipped
+}
+
+func decodeJSONConfig(data []byte) (*ProvidersConfig, error) {
+	var cfg ProvidersConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
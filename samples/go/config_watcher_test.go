@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeConfig writes data to the config file at path.
+func writeConfig(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// configFactory builds fake providers, remembering the config each was built from.
+func configFactory(built map[string]ProviderConfig) ProviderFactory {
+	return func(cfg ProviderConfig) (Provider, error) {
+		if cfg.Type == "broken" {
+			return nil, errors.New("cannot build")
+		}
+		built[cfg.ID] = cfg
+		return &fakeProvider{id: cfg.ID}, nil
+	}
+}
+
+func TestConfigWatcherReconciles(t *testing.T) {
+	steps := []struct {
+		name        string
+		config      string
+		wantErr     bool
+		wantIDs     []string
+		wantDefault string
+		wantRebuilt []string // Providers built by this step
+	}{
+		{"initial", `{"default":"a","providers":[{"id":"a","type":"openai"},{"id":"b","type":"ollama"}]}`,
+			false, []string{"a", "b"}, "a", []string{"a", "b"}},
+		{"change one", `{"default":"a","providers":[{"id":"a","type":"openai"},{"id":"b","type":"ollama","base_url":"http://h"}]}`,
+			false, []string{"a", "b"}, "a", []string{"b"}},
+		{"add and remove", `{"default":"c","providers":[{"id":"a","type":"openai"},{"id":"c","type":"azure"}]}`,
+			false, []string{"a", "c"}, "c", []string{"c"}},
+		{"malformed", `{"providers":[`, true, []string{"a", "c"}, "c", nil},
+		{"invalid", `{"providers":[{"id":"a"}]}`, true, []string{"a", "c"}, "c", nil},
+		{"unbuildable", `{"providers":[{"id":"a","type":"openai"},{"id":"d","type":"broken"}]}`, true, []string{"a", "c"}, "c", nil},
+		{"default dropped", `{"providers":[{"id":"a","type":"openai"},{"id":"c","type":"azure"}]}`, false, []string{"a", "c"}, "", nil},
+	}
+
+	path := filepath.Join(t.TempDir(), "providers.json")
+	registry := NewProviderRegistry()
+	built := make(map[string]ProviderConfig)
+	w := NewConfigWatcher(path, registry, configFactory(built), ConfigWatcherOptions{})
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			clear(built)
+			writeConfig(t, path, step.config)
+			err := w.Reload()
+			if (err != nil) != step.wantErr {
+				t.Fatalf("Reload() = %v, want error %v", err, step.wantErr)
+			}
+			if step.wantErr && !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Reload() = %v, want ErrInvalidConfig", err)
+			}
+
+			ids := registry.ListProviders()
+			slices.Sort(ids)
+			if !slices.Equal(ids, step.wantIDs) {
+				t.Errorf("providers = %v, want %v", ids, step.wantIDs)
+			}
+			def, err := registry.GetDefault()
+			if step.wantDefault == "" {
+				if !errors.Is(err, ErrProviderNotFound) {
+					t.Errorf("default = %v, %v; want none", def, err)
+				}
+			} else if err != nil || def.ID() != step.wantDefault {
+				t.Errorf("default = %v, %v; want %q", def, err, step.wantDefault)
+			}
+			var rebuilt []string
+			for id := range built {
+				rebuilt = append(rebuilt, id)
+			}
+			slices.Sort(rebuilt)
+			if !slices.Equal(rebuilt, step.wantRebuilt) {
+				t.Errorf("rebuilt %v, want %v", rebuilt, step.wantRebuilt)
+			}
+		})
+	}
+}
+
+func TestConfigWatcherLeavesForeignProviders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "providers.json")
+	registry := NewProviderRegistry()
+	foreign := &fakeProvider{id: "shared"}
+	registry.Register(foreign)
+	registry.SetDefault("shared")
+
+	var reported []error
+	w := NewConfigWatcher(path, registry, configFactory(map[string]ProviderConfig{}), ConfigWatcherOptions{
+		OnError: func(err error) { reported = append(reported, err) },
+	})
+
+	writeConfig(t, path, `{"providers":[{"id":"shared","type":"openai"},{"id":"own","type":"openai"}]}`)
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := registry.Get("shared"); got != foreign {
+		t.Error("watcher replaced a provider registered by other code")
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], ErrInvalidConfig) {
+		t.Errorf("reported %v, want one skipped-provider error", reported)
+	}
+
+	writeConfig(t, path, `{"providers":[{"id":"own","type":"openai"}]}`)
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Get("shared"); err != nil {
+		t.Error("watcher removed a provider registered by other code")
+	}
+	if def, err := registry.GetDefault(); err != nil || def != foreign {
+		t.Errorf("default = %v, %v; want the default set by other code", def, err)
+	}
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Vote is one provider's response in a consensus.
+type Vote struct {
+	Provider string
+	Response *ChatResponse
+}
+
+// ConsensusConfig configures WeightedConsensus.
+type ConsensusConfig struct {
+	// Trust weights each provider's votes. Providers not listed weigh 1.
+	Trust map[string]float64
+
+	// UseConfidence multiplies each vote by the Confidence of its logprobs,
+	// when the response has any.
+	UseConfidence bool
+
+	// Normalize maps content to the answer it votes for, so that equivalent
+	// answers pool their weight. Defaults to trimming space and lowercasing.
+	Normalize func(content string) string
+}
+
+// Consensus is the outcome of WeightedConsensus.
+type Consensus struct {
+	Answer    string             // The winning normalized answer
+	Response  *ChatResponse      // The heaviest response that voted for Answer
+	Agreement float64            // The winning share of the total weight, from 0 to 1
+	Weights   map[string]float64 // The total weight of every answer
+}
+
+// WeightedConsensus combines votes for categorical or numeric answers, each
+// weighted by provider trust and optionally by confidence, and returns the
+// answer with the most weight. Ties go to the answer voted for first.
+func WeightedConsensus(votes []Vote, cfg ConsensusConfig) (*Consensus, error) {
+	normalize := cfg.Normalize
+	if normalize == nil {
+		normalize = func(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+	}
+
+	weights := map[string]float64{}
+	heaviest := map[string]*ChatResponse{}
+	heaviestWeight := map[string]float64{}
+	var order []string
+	total := 0.0
+
+	for _, v := range votes {
+		weight := cfg.weight(v)
+		if weight <= 0 {
+			continue
+		}
+		answer := normalize(v.Response.Content)
+		if _, seen := weights[answer]; !seen {
+			order = append(order, answer)
+		}
+		weights[answer] += weight
+		total += weight
+		if weight > heaviestWeight[answer] {
+			heaviest[answer], heaviestWeight[answer] = v.Response, weight
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%w: no weighted votes", ErrInvalidRequest)
+	}
+
+	winner := order[0]
+	for _, answer := range order[1:] {
+		if weights[answer] > weights[winner] {
+			winner = answer
+		}
+	}
+	return &Consensus{
+		Answer:    winner,
+		Response:  heaviest[winner],
+		Agreement: weights[winner] / total,
+		Weights:   weights,
+	}, nil
+}
+
+// weight returns the weight of v's vote; nil responses do not vote.
+func (cfg ConsensusConfig) weight(v Vote) float64 {
+	if v.Response == nil {
+		return 0
+	}
+	weight := 1.0
+	if trust, ok := cfg.Trust[v.Provider]; ok {
+		weight = trust
+	}
+	if cfg.UseConfidence && len(v.Response.Logprobs) > 0 {
+		weight *= Confidence(v.Response.Logprobs)
+	}
+	return weight
+}
@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWeightedConsensus(t *testing.T) {
+	vote := func(provider, content string) Vote {
+		return Vote{Provider: provider, Response: &ChatResponse{Content: content}}
+	}
+	unsure := Vote{Provider: "c", Response: &ChatResponse{Content: "no", Logprobs: []TokenLogprob{{Token: "no", Logprob: math.Log(0.1)}}}}
+
+	tests := []struct {
+		name          string
+		votes         []Vote
+		cfg           ConsensusConfig
+		wantAnswer    string
+		wantAgreement float64
+		wantErr       bool
+	}{
+		{"majority", []Vote{vote("a", "Yes"), vote("b", " yes"), vote("c", "no")}, ConsensusConfig{}, "yes", 2.0 / 3, false},
+		{"trust outweighs count", []Vote{vote("a", "yes"), vote("b", "yes"), vote("c", "no")}, ConsensusConfig{Trust: map[string]float64{"c": 3}}, "no", 0.6, false},
+		{"tie goes to first answer", []Vote{vote("a", "no"), vote("b", "yes")}, ConsensusConfig{}, "no", 0.5, false},
+		{"confidence scales votes", []Vote{vote("a", "yes"), unsure, unsure}, ConsensusConfig{UseConfidence: true}, "yes", 1 / 1.2, false},
+		{"zero trust and nil responses abstain", []Vote{vote("a", "yes"), {Provider: "b"}, vote("c", "no")}, ConsensusConfig{Trust: map[string]float64{"c": 0}}, "yes", 1, false},
+		{"no weighted votes", []Vote{{Provider: "a"}}, ConsensusConfig{}, "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WeightedConsensus(tt.votes, tt.cfg)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRequest) {
+					t.Fatalf("err = %v, want ErrInvalidRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Answer != tt.wantAnswer || math.Abs(got.Agreement-tt.wantAgreement) > 1e-9 {
+				t.Errorf("answer %q with agreement %v, want %q with %v", got.Answer, got.Agreement, tt.wantAnswer, tt.wantAgreement)
+			}
+			if got.Response == nil || strings.ToLower(strings.TrimSpace(got.Response.Content)) != got.Answer {
+				t.Errorf("response %+v did not vote for %q", got.Response, got.Answer)
+			}
+		})
+	}
+}
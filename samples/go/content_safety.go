@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrContentFlagged is returned when a content-safety classifier flags a response.
+var ErrContentFlagged = errors.New("content flagged")
+
+// ContentFlaggedError reports why a stream was aborted and the content that was
+// delivered before the flagged text.
+type ContentFlaggedError struct {
+	Category   string
+	SafePrefix string
+}
+
+func (e *ContentFlaggedError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrContentFlagged, e.Category)
+}
+
+// Is reports whether target is ErrContentFlagged.
+func (e *ContentFlaggedError) Is(target error) bool {
+	return target == ErrContentFlagged
+}
+
+// ContentClassifier inspects text and reports whether it should be blocked.
+type ContentClassifier func(text string) (flagged bool, category string, err error)
+
+// ContentSafetyProvider wraps a StreamingProvider and aborts streams as soon as
+// a classifier flags the accumulated output.
+//
+// Content is held back until it has been classified, so consumers only ever
+// receive text that passed; this adds up to checkEvery characters of latency.
+type ContentSafetyProvider struct {
+	StreamingProvider
+	classify   ContentClassifier
+	checkEvery int
+}
+
+// NewContentSafetyProvider creates a provider that classifies the stream after
+// every checkEvery characters (default 200) and once more at the end.
+func NewContentSafetyProvider(inner StreamingProvider, classify ContentClassifier, checkEvery int) *ContentSafetyProvider {
+	if checkEvery <= 0 {
+		checkEvery = 200
+	}
+	return &ContentSafetyProvider{StreamingProvider: inner, classify: classify, checkEvery: checkEvery}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ContentSafetyProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream starts a stream on the wrapped provider and monitors it for flagged content.
+func (p *ContentSafetyProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		var content, safe strings.Builder
+		var pending []StreamChunk
+		pendingChars := 0
+
+		// check classifies everything received so far and releases the held-back
+		// chunks if it passes. It returns a terminal chunk if the stream must stop.
+		check := func() *StreamChunk {
+			flagged, category, err := p.classify(content.String())
+			if err != nil {
+				return &StreamChunk{Done: true, Reason: StreamError, Err: err}
+			}
+			if flagged {
+				return &StreamChunk{Done: true, Reason: StreamFlagged, Err: &ContentFlaggedError{Category: category, SafePrefix: safe.String()}}
+			}
+			for _, c := range pending {
+				emit(c)
+				safe.WriteString(c.Content)
+			}
+			pending, pendingChars = pending[:0], 0
+			return nil
+		}
+
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				if len(pending) > 0 {
+					if stop := check(); stop != nil {
+						return *stop
+					}
+				}
+				return chunk
+			}
+
+			content.WriteString(chunk.Content)
+			pending = append(pending, chunk)
+			pendingChars += utf8.RuneCountInString(chunk.Content)
+
+			if pendingChars >= p.checkEvery {
+				if stop := check(); stop != nil {
+					return *stop
+				}
+			}
+		}
+	}), nil
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// wordClassifier flags text containing word.
+func wordClassifier(word string) ContentClassifier {
+	return func(text string) (bool, string, error) {
+		return strings.Contains(text, word), "blocked-word", nil
+	}
+}
+
+func TestContentSafetyProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		parts       []string
+		checkEvery  int
+		wantContent string
+		wantReason  StreamEndReason
+		wantPrefix  string
+	}{
+		{"clean stream", []string{"hello ", "world"}, 5, "hello world", StreamCompleted, ""},
+		{"flagged mid-stream", []string{"safe ", "text ", "then BAD ", "more"}, 5, "safe text ", StreamFlagged, "safe text "},
+		{"flagged in the tail", []string{"ok ", "BAD"}, 100, "", StreamFlagged, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewContentSafetyProvider(streamingReply(tt.parts...), wordClassifier("BAD"), tt.checkEvery)
+			chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, final := collectStream(chunks)
+			if content != tt.wantContent {
+				t.Errorf("delivered %q, want %q", content, tt.wantContent)
+			}
+			if final.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", final.Reason, tt.wantReason)
+			}
+			if tt.wantReason != StreamFlagged {
+				return
+			}
+			var flagged *ContentFlaggedError
+			if !errors.As(final.Err, &flagged) || !errors.Is(final.Err, ErrContentFlagged) {
+				t.Fatalf("err = %v, want ContentFlaggedError", final.Err)
+			}
+			if flagged.SafePrefix != tt.wantPrefix {
+				t.Errorf("safe prefix = %q, want %q", flagged.SafePrefix, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestContentSafetyCountsCharacters(t *testing.T) {
+	// Four 2-byte runes are four characters: below a check interval of five,
+	// so nothing is released until the fifth arrives.
+	var checked []string
+	classify := func(text string) (bool, string, error) {
+		checked = append(checked, text)
+		return false, "", nil
+	}
+	p := NewContentSafetyProvider(streamingReply("éé", "éé", "é"), classify, 5)
+	chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectStream(chunks)
+	if len(checked) != 1 || checked[0] != "ééééé" {
+		t.Errorf("classified %q, want one check of all five characters", checked)
+	}
+}
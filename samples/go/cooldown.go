@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrProviderCoolingDown is returned for providers skipped because they failed recently.
+var ErrProviderCoolingDown = errors.New("provider is cooling down after an error")
+
+// WithCooldown makes ChatWithFallback skip a provider for d after it fails,
+// steering traffic away from providers that just errored without waiting for
+// a full circuit-breaker trip.
+func WithCooldown(d time.Duration) RegistryOption {
+	return func(r *ProviderRegistry) {
+		r.cooldown = d
+	}
+}
+
+// CoolingDown reports whether the provider with id is in its cool-down period.
+func (r *ProviderRegistry) CoolingDown(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return time.Now().Before(r.cooldownUntil[id])
+}
+
+// startCooldown puts provider id into cool-down after err, unless err is the
+// caller's fault rather than the provider's.
+func (r *ProviderRegistry) startCooldown(id string, err error) {
+	if r.cooldown <= 0 || errors.Is(err, ErrInvalidRequest) || errors.Is(err, ErrProviderNotAllowed) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cooldownUntil == nil {
+		r.cooldownUntil = make(map[string]time.Time)
+	}
+	r.cooldownUntil[id] = time.Now().Add(r.cooldown)
+}
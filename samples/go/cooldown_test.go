@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistryCooldown(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	tests := []struct {
+		name        string
+		err         error // Returned by the first provider
+		wantCooling bool
+	}{
+		{"transient error cools down", ErrRateLimited, true},
+		{"server error cools down", &ProviderError{StatusCode: 503}, true},
+		{"caller error does not", ErrInvalidRequest, false},
+		{"policy rejection does not", ErrProviderNotAllowed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failing := &fakeProvider{id: "a", chat: func(context.Context, *ChatRequest) (*ChatResponse, error) { return nil, tt.err }}
+			backup := &fakeProvider{id: "b"}
+			r := NewProviderRegistry(WithCooldown(cooldown))
+			r.Register(failing)
+			r.Register(backup)
+			req := &ChatRequest{Model: "m", Messages: userMessages("hi")}
+
+			for range 2 {
+				if _, err := r.ChatWithFallback(context.Background(), req, []string{"a", "b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if r.CoolingDown("a") != tt.wantCooling || r.CoolingDown("b") {
+				t.Errorf("cooling down: a %v, b %v; want a %v", r.CoolingDown("a"), r.CoolingDown("b"), tt.wantCooling)
+			}
+			wantCalls := 2
+			if tt.wantCooling {
+				wantCalls = 1 // Skipped on the second request
+			}
+			if got := len(failing.requests()); got != wantCalls {
+				t.Errorf("failing provider called %d times, want %d", got, wantCalls)
+			}
+
+			_, err := r.ChatWithFallback(context.Background(), req, []string{"a"})
+			if got := errors.Is(err, ErrProviderCoolingDown); got != tt.wantCooling {
+				t.Errorf("err = %v, want ErrProviderCoolingDown: %v", err, tt.wantCooling)
+			}
+
+			time.Sleep(cooldown)
+			if r.CoolingDown("a") {
+				t.Error("still cooling down after the cool-down period")
+			}
+		})
+	}
+}
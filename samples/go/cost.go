@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrPricingNotFound is returned when a model has no entry in a CostTable.
+var ErrPricingNotFound = errors.New("pricing not found for model")
+
+// ModelPricing is the price of a model in USD per million tokens.
+type ModelPricing struct {
+	PromptPerMillion     float64 `json:"prompt_per_million"`
+	CompletionPerMillion float64 `json:"completion_per_million"`
+}
+
+// CostTable maps model names to their pricing.
+type CostTable map[string]ModelPricing
+
+// Cost returns the USD cost of the given token counts on model.
+func (t CostTable) Cost(model string, promptTokens, completionTokens int) (float64, error) {
+	pricing, ok := t[model]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrPricingNotFound, model)
+	}
+	return (float64(promptTokens)*pricing.PromptPerMillion +
+		float64(completionTokens)*pricing.CompletionPerMillion) / 1_000_000, nil
+}
+
+// UsageCost returns the USD cost of a completed request's usage on model.
+func (t CostTable) UsageCost(model string, usage *UsageStats) (float64, error) {
+	if usage == nil {
+		return 0, nil
+	}
+	return t.Cost(model, usage.PromptTokens, usage.CompletionTokens)
+}
+
+// TokenCounter estimates token counts for a model.
+type TokenCounter interface {
+	// CountTokens returns the number of tokens in text.
+	CountTokens(model, text string) int
+
+	// CountMessages returns the number of prompt tokens the messages occupy,
+	// including per-message formatting overhead.
+	CountMessages(model string, messages []Message) int
+}
+
+// HeuristicTokenCounter approximates tokens as one per four characters, which
+// is close enough for budgeting when no real tokenizer is available.
+type HeuristicTokenCounter struct{}
+
+// perMessageOverhead approximates the role and delimiter tokens added to each message.
+const perMessageOverhead = 4
+
+// CountTokens estimates the number of tokens in text.
+func (HeuristicTokenCounter) CountTokens(_ string, text string) int {
+	n := utf8.RuneCountInString(text)
+	return (n + 3) / 4
+}
+
+// CountMessages estimates the prompt tokens of messages.
+func (c HeuristicTokenCounter) CountMessages(model string, messages []Message) int {
+	total := 0
+	for _, m := range messages {
+		total += perMessageOverhead + c.CountTokens(model, m.Content)
+	}
+	return total
+}
+
+// CostEstimate is an estimated token count and USD cost.
+type CostEstimate struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// EstimateConversationCost estimates what a conversation has cost so far. Each
+// assistant message is treated as a completion whose prompt was every message
+// before it.
+func EstimateConversationCost(table CostTable, counter TokenCounter, model string, messages []Message) (CostEstimate, error) {
+	var est CostEstimate
+	for i, m := range messages {
+		if m.Role != "assistant" {
+			continue
+		}
+		est.PromptTokens += counter.CountMessages(model, messages[:i])
+		est.CompletionTokens += counter.CountTokens(model, m.Content)
+	}
+
+	cost, err := table.Cost(model, est.PromptTokens, est.CompletionTokens)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	est.Cost = cost
+	return est, nil
+}
+
+// EstimateNextTurnCost projects the incremental cost of sending messages as the
+// next prompt and receiving up to completionBudget tokens in reply.
+func EstimateNextTurnCost(table CostTable, counter TokenCounter, model string, messages []Message, completionBudget int) (CostEstimate, error) {
+	est := CostEstimate{
+		PromptTokens:     counter.CountMessages(model, messages),
+		CompletionTokens: completionBudget,
+	}
+
+	cost, err := table.Cost(model, est.PromptTokens, est.CompletionTokens)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	est.Cost = cost
+	return est, nil
+}
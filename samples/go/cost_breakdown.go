@@ -0,0 +1,107 @@
+package llm
+
+import "errors"
+
+// MetadataCostBreakdown records the CostBreakdown of a ChatWithFallback chain
+// on its final response.
+const MetadataCostBreakdown = "cost_breakdown"
+
+// AttemptCost is the cost of one provider attempt in a fallback chain.
+type AttemptCost struct {
+	Provider string      `json:"provider"`
+	Model    string      `json:"model"`
+	Usage    *UsageStats `json:"usage,omitempty"`
+	Cost     float64     `json:"cost"`          // Zero when the model is not priced or no usage was reported
+	Err      string      `json:"err,omitempty"` // Why the attempt was rejected, if it was
+}
+
+// CostBreakdown is the per-attempt and total cost of a fallback chain.
+type CostBreakdown struct {
+	Attempts []AttemptCost `json:"attempts"`
+	Total    float64       `json:"total"`
+}
+
+// FallbackError is returned by ChatWithFallback, with WithCostBreakdown, when
+// the chain fails, so that callers can still account for the attempts it
+// paid for.
+type FallbackError struct {
+	Err   error // The error ChatWithFallback would otherwise return
+	Costs CostBreakdown
+}
+
+func (e *FallbackError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FallbackError) Unwrap() error {
+	return e.Err
+}
+
+// PartialUsageError wraps an error from a request that consumed tokens before
+// failing, such as a stream cut off midway, so that cost accounting can still
+// charge for them.
+type PartialUsageError struct {
+	Err   error
+	Usage *UsageStats
+}
+
+func (e *PartialUsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialUsageError) Unwrap() error {
+	return e.Err
+}
+
+// withPartialUsage returns err wrapped in a PartialUsageError if the failed
+// request reported usage, and err itself otherwise.
+func withPartialUsage(err error, usage *UsageStats) error {
+	if usage == nil {
+		return err
+	}
+	return &PartialUsageError{Err: err, Usage: usage}
+}
+
+// WithCostBreakdown makes ChatWithFallback price every attempt with table and
+// record the chain's CostBreakdown on the response it returns, or in a
+// FallbackError if every attempt fails. Each provider and fallback model tried
+// is an attempt; failed attempts are charged for the usage on a rejected
+// response or a PartialUsageError.
+func WithCostBreakdown(table CostTable) RegistryOption {
+	return func(r *ProviderRegistry) {
+		r.costTable = table
+	}
+}
+
+// add records an attempt on provider, charging for the usage of resp or, if
+// the attempt failed, of a PartialUsageError in err.
+func (b *CostBreakdown) add(table CostTable, provider, model string, resp *ChatResponse, err error) {
+	attempt := AttemptCost{Provider: provider, Model: model}
+	if resp != nil {
+		attempt.Usage = resp.Usage
+		if resp.Model != "" {
+			attempt.Model = resp.Model
+		}
+	}
+	if err != nil {
+		attempt.Err = err.Error()
+		var partial *PartialUsageError
+		if attempt.Usage == nil && errors.As(err, &partial) {
+			attempt.Usage = partial.Usage
+		}
+	}
+	if cost, costErr := table.UsageCost(attempt.Model, attempt.Usage); costErr == nil {
+		attempt.Cost = cost
+	}
+	b.Attempts = append(b.Attempts, attempt)
+	b.Total += attempt.Cost
+}
+
+// fallbackError returns err, wrapped in a FallbackError with costs if cost
+// breakdowns are enabled.
+func (r *ProviderRegistry) fallbackError(err error, costs CostBreakdown) error {
+	if r.costTable == nil {
+		return err
+	}
+	return &FallbackError{Err: err, Costs: costs}
+}
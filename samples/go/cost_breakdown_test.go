@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCostBreakdown(t *testing.T) {
+	// One dollar per token, so costs read as token counts.
+	table := CostTable{
+		"m":  {PromptPerMillion: 1e6, CompletionPerMillion: 1e6},
+		"m2": {PromptPerMillion: 1e6, CompletionPerMillion: 1e6},
+	}
+	usage := func(n int) *UsageStats { return &UsageStats{PromptTokens: n, TotalTokens: n} }
+	reply := func(n int) outcome { return outcome{resp: &ChatResponse{Content: "ok", Usage: usage(n)}} }
+	failure := outcome{err: &ProviderError{StatusCode: 503}}
+	partial := outcome{err: &PartialUsageError{Err: &ProviderError{StatusCode: 503}, Usage: usage(2)}}
+
+	tests := []struct {
+		name      string
+		a, b      []outcome // Outcomes of providers a and b, by attempt
+		fallbacks []string
+		quality   QualityCheck
+		want      []string // "provider/model cost" per attempt, "!" marking failures
+		wantTotal float64
+		wantErr   bool
+	}{
+		{name: "first succeeds", a: []outcome{reply(3)}, want: []string{"a/m 3"}, wantTotal: 3},
+		{
+			name:      "partial usage charged",
+			a:         []outcome{partial},
+			b:         []outcome{reply(3)},
+			want:      []string{"a/m 2!", "b/m 3"},
+			wantTotal: 5,
+		},
+		{
+			name:      "model fallbacks itemized",
+			a:         []outcome{partial, reply(3)},
+			fallbacks: []string{"m2"},
+			want:      []string{"a/m 2!", "a/m2 3"},
+			wantTotal: 5,
+		},
+		{
+			name: "rejected response charged",
+			a:    []outcome{reply(4)},
+			b:    []outcome{reply(1)},
+			quality: func(_ *ChatRequest, resp *ChatResponse) (bool, string) {
+				return resp.Usage.PromptTokens < 4, "too long"
+			},
+			want:      []string{"a/m 4!", "b/m 1"},
+			wantTotal: 5,
+		},
+		{
+			name:      "chain fails",
+			a:         []outcome{partial, failure},
+			b:         []outcome{failure},
+			fallbacks: []string{"m2"},
+			want:      []string{"a/m 2!", "a/m2 0!", "b/m 0!", "b/m2 0!"},
+			wantTotal: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []RegistryOption{WithCostBreakdown(table)}
+			if tt.quality != nil {
+				opts = append(opts, WithQualityCheck(tt.quality))
+			}
+			r := NewProviderRegistry(opts...)
+			r.Register(&fakeProvider{id: "a", chat: scripted(tt.a...)})
+			b := tt.b
+			if b == nil {
+				b = tt.a
+			}
+			r.Register(&fakeProvider{id: "b", chat: scripted(b...)})
+
+			req := &ChatRequest{Model: "m", Messages: userMessages("hi"), ModelFallbacks: tt.fallbacks}
+			resp, err := r.ChatWithFallback(context.Background(), req, []string{"a", "b"})
+
+			var costs CostBreakdown
+			if tt.wantErr {
+				var ferr *FallbackError
+				if !errors.As(err, &ferr) {
+					t.Fatalf("err = %v, want a FallbackError", err)
+				}
+				var perr *ProviderError
+				if !errors.As(err, &perr) {
+					t.Errorf("err = %v, want it to wrap the last failure", err)
+				}
+				costs = ferr.Costs
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				costs = resp.Metadata[MetadataCostBreakdown].(CostBreakdown)
+			}
+
+			var got []string
+			for _, a := range costs.Attempts {
+				s := fmt.Sprintf("%s/%s %g", a.Provider, a.Model, a.Cost)
+				if a.Err != "" {
+					s += "!"
+				}
+				got = append(got, s)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) || costs.Total != tt.wantTotal {
+				t.Errorf("attempts = %v totalling %g, want %v totalling %g", got, costs.Total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestFallbackErrorDisabled(t *testing.T) {
+	r := NewProviderRegistry()
+	r.Register(&fakeProvider{id: "a", chat: scripted(outcome{err: ErrRateLimited})})
+	_, err := r.ChatWithFallback(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")}, []string{"a"})
+	var ferr *FallbackError
+	if !errors.Is(err, ErrRateLimited) || errors.As(err, &ferr) {
+		t.Errorf("err = %v, want the plain error without WithCostBreakdown", err)
+	}
+}
+
+func TestCostBreakdownFailedStream(t *testing.T) {
+	table := CostTable{"m": {PromptPerMillion: 1e6, CompletionPerMillion: 1e6}}
+	failing := &fakeStreamer{fakeProvider: &fakeProvider{id: "a"}, stream: func(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+		return streamOf(
+			StreamChunk{Content: "par"},
+			StreamChunk{Done: true, Reason: StreamError, Err: &ProviderError{StatusCode: 503}, Usage: &UsageStats{PromptTokens: 2, TotalTokens: 2}},
+		), nil
+	}}
+	r := NewProviderRegistry(WithCostBreakdown(table))
+	r.Register(NewLatencyBoundProvider(failing, LatencyBudget{}))
+	r.Register(&fakeProvider{id: "b", chat: scripted(outcome{resp: &ChatResponse{Content: "ok", Usage: &UsageStats{PromptTokens: 3, TotalTokens: 3}}})})
+
+	resp, err := r.ChatWithFallback(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")}, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	costs := resp.Metadata[MetadataCostBreakdown].(CostBreakdown)
+	if len(costs.Attempts) != 2 || costs.Attempts[0].Cost != 2 || costs.Attempts[0].Err == "" || costs.Total != 5 {
+		t.Errorf("costs = %+v, want the failed stream's 2 tokens charged in a total of 5", costs)
+	}
+}
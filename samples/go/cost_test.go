@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// wordCounter counts one token per rune and no per-message overhead, to make
+// expected token counts easy to derive.
+type wordCounter struct{}
+
+func (wordCounter) CountTokens(_, text string) int { return len([]rune(text)) }
+
+func (c wordCounter) CountMessages(model string, messages []Message) int {
+	n := 0
+	for _, m := range messages {
+		n += c.CountTokens(model, m.Content)
+	}
+	return n
+}
+
+var testCosts = CostTable{"m": {PromptPerMillion: 1_000_000, CompletionPerMillion: 2_000_000}} // $1 and $2 per token
+
+func TestCostTable(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      string
+		prompt     int
+		completion int
+		want       float64
+		wantErr    error
+	}{
+		{"priced", "m", 3, 2, 7, nil},
+		{"zero tokens", "m", 0, 0, 0, nil},
+		{"unpriced", "other", 1, 1, 0, ErrPricingNotFound},
+	}
+	for _, tt := range tests {
+		got, err := testCosts.Cost(tt.model, tt.prompt, tt.completion)
+		if !errors.Is(err, tt.wantErr) || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: Cost = %v, %v; want %v, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestEstimateConversationCost(t *testing.T) {
+	conversation := []Message{
+		{Role: "user", Content: "ab"},
+		{Role: "assistant", Content: "cde"},
+		{Role: "user", Content: "f"},
+		{Role: "assistant", Content: "g"},
+	}
+	tests := []struct {
+		name     string
+		messages []Message
+		want     CostEstimate
+	}{
+		// Prompts: "ab" (2) then "ab"+"cde"+"f" (6); completions: 3 + 1.
+		{"two turns", conversation, CostEstimate{PromptTokens: 8, CompletionTokens: 4, Cost: 8 + 8}},
+		{"no replies yet", conversation[:1], CostEstimate{}},
+	}
+	for _, tt := range tests {
+		got, err := EstimateConversationCost(testCosts, wordCounter{}, "m", tt.messages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.PromptTokens != tt.want.PromptTokens || got.CompletionTokens != tt.want.CompletionTokens || math.Abs(got.Cost-tt.want.Cost) > 1e-9 {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateNextTurnCost(t *testing.T) {
+	got, err := EstimateNextTurnCost(testCosts, wordCounter{}, "m", userMessages("abcd"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CostEstimate{PromptTokens: 4, CompletionTokens: 10, Cost: 4 + 20}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := EstimateNextTurnCost(testCosts, wordCounter{}, "other", nil, 1); !errors.Is(err, ErrPricingNotFound) {
+		t.Errorf("unpriced model: err = %v, want ErrPricingNotFound", err)
+	}
+}
+
+func TestHeuristicTokenCounter(t *testing.T) {
+	var c HeuristicTokenCounter
+	tests := []struct {
+		text string
+		want int
+	}{{"", 0}, {"abcd", 1}, {"abcde", 2}, {"héllo wörld!", 3}}
+	for _, tt := range tests {
+		if got := c.CountTokens("m", tt.text); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+	if got := c.CountMessages("m", userMessages("abcd", "")); got != 2*perMessageOverhead+1 {
+		t.Errorf("CountMessages = %d, want %d", got, 2*perMessageOverhead+1)
+	}
+}
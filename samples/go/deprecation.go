@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrModelDeprecated is returned when the provider has retired the requested model.
+var ErrModelDeprecated = errors.New("model deprecated")
+
+// MetadataDeprecation holds a DeprecationWarning when the provider signals
+// that the requested model is deprecated, or a successor was used instead.
+const MetadataDeprecation = "deprecation"
+
+// DeprecationWarning describes a deprecated model.
+type DeprecationWarning struct {
+	Model     string    `json:"model"`
+	Since     time.Time `json:"since,omitzero"`      // When the model was deprecated, if known
+	Sunset    time.Time `json:"sunset,omitzero"`     // When the model stops being served, if known
+	Successor string    `json:"successor,omitempty"` // The model used instead, after auto-migration
+
+	// SuggestedSuccessor is the model to migrate to before the sunset, on a
+	// warning for a model that was still served.
+	SuggestedSuccessor string `json:"suggested_successor,omitempty"`
+}
+
+// ParseDeprecationHeaders reads the Deprecation (RFC 9745) and Sunset
+// (RFC 8594) headers a provider sends while a model is still served but
+// scheduled for removal. It reports false if neither is present.
+func ParseDeprecationHeaders(h http.Header, model string) (DeprecationWarning, bool) {
+	w := DeprecationWarning{Model: model}
+	dep := strings.TrimSpace(h.Get("Deprecation"))
+	sunset := strings.TrimSpace(h.Get("Sunset"))
+	if dep == "" && sunset == "" {
+		return w, false
+	}
+	if secs, err := strconv.ParseInt(strings.TrimPrefix(dep, "@"), 10, 64); err == nil {
+		w.Since = time.Unix(secs, 0).UTC()
+	}
+	if t, err := http.ParseTime(sunset); err == nil {
+		w.Sunset = t.UTC()
+	}
+	return w, true
+}
+
+// DeprecationProvider wraps a Provider and migrates requests for retired
+// models to their successors. A request failing with ErrModelDeprecated is
+// retried once on the mapped successor; the response records the original
+// model in RequestedModel and a DeprecationWarning in its metadata.
+// OnDeprecated, if set, is called for every deprecation seen, including
+// header warnings on responses that succeeded, so they can be logged.
+type DeprecationProvider struct {
+	Provider
+	successors   map[string]string
+	onDeprecated func(DeprecationWarning)
+}
+
+// NewDeprecationProvider creates a provider that replaces retired models
+// with successors[model]. onDeprecated may be nil.
+func NewDeprecationProvider(inner Provider, successors map[string]string, onDeprecated func(DeprecationWarning)) *DeprecationProvider {
+	return &DeprecationProvider{Provider: inner, successors: successors, onDeprecated: onDeprecated}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *DeprecationProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request, retrying on the successor model if the
+// requested one has been retired.
+func (p *DeprecationProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err == nil {
+		if w, ok := resp.Metadata[MetadataDeprecation].(DeprecationWarning); ok {
+			w.SuggestedSuccessor = p.successors[req.Model]
+			resp.SetMetadata(MetadataDeprecation, w)
+			p.notify(w)
+		}
+		return resp, nil
+	}
+
+	successor, ok := p.successors[req.Model]
+	if !errors.Is(err, ErrModelDeprecated) || !ok {
+		return nil, err
+	}
+	w := DeprecationWarning{Model: req.Model, Successor: successor}
+	p.notify(w)
+
+	migrated := *req
+	migrated.Model = successor
+	resp, err = p.Provider.Chat(ctx, &migrated)
+	if err != nil {
+		return nil, err
+	}
+	if resp.RequestedModel == "" {
+		resp.RequestedModel = req.Model
+	}
+	resp.SetMetadata(MetadataDeprecation, w)
+	return resp, nil
+}
+
+func (p *DeprecationProvider) notify(w DeprecationWarning) {
+	if p.onDeprecated != nil {
+		p.onDeprecated(w)
+	}
+}
@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseDeprecationHeaders(t *testing.T) {
+	sunset := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   DeprecationWarning
+		wantOK bool
+	}{
+		{name: "none", header: http.Header{}, want: DeprecationWarning{Model: "m"}},
+		{
+			name:   "both",
+			header: http.Header{"Deprecation": {"@1700000000"}, "Sunset": {sunset.Format(http.TimeFormat)}},
+			want:   DeprecationWarning{Model: "m", Since: time.Unix(1700000000, 0).UTC(), Sunset: sunset},
+			wantOK: true,
+		},
+		{name: "boolean deprecation", header: http.Header{"Deprecation": {"true"}}, want: DeprecationWarning{Model: "m"}, wantOK: true},
+		{name: "sunset only", header: http.Header{"Sunset": {sunset.Format(http.TimeFormat)}}, want: DeprecationWarning{Model: "m", Sunset: sunset}, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseDeprecationHeaders(tt.header, "m")
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("got %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDeprecationProvider(t *testing.T) {
+	warned := &ChatResponse{Content: "ok", Model: "old"}
+	warned.SetMetadata(MetadataDeprecation, DeprecationWarning{Model: "old"})
+	retired := outcome{err: &ProviderError{StatusCode: http.StatusNotFound, Code: "model_deprecated"}}
+	errDown := errors.New("down")
+	succeeded := outcome{resp: &ChatResponse{Content: "ok", Model: "new"}}
+
+	tests := []struct {
+		name      string
+		model     string
+		outcomes  []outcome
+		wantModel []string // Models requested from the inner provider
+		want      *DeprecationWarning
+		wantErr   error
+	}{
+		{name: "not deprecated", model: "old", outcomes: []outcome{succeeded}, wantModel: []string{"old"}},
+		{
+			name:      "header warning suggests the successor",
+			model:     "old",
+			outcomes:  []outcome{{resp: warned}},
+			wantModel: []string{"old"},
+			want:      &DeprecationWarning{Model: "old", SuggestedSuccessor: "new"},
+		},
+		{
+			name:      "retired model migrates",
+			model:     "old",
+			outcomes:  []outcome{retired, succeeded},
+			wantModel: []string{"old", "new"},
+			want:      &DeprecationWarning{Model: "old", Successor: "new"},
+		},
+		{name: "retired without successor", model: "other", outcomes: []outcome{retired}, wantModel: []string{"other"}, wantErr: ErrModelDeprecated},
+		{name: "other errors are not migrated", model: "old", outcomes: []outcome{{err: errDown}}, wantModel: []string{"old"}, wantErr: errDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(tt.outcomes...)}
+			var notified []DeprecationWarning
+			p := NewDeprecationProvider(inner, map[string]string{"old": "new"}, func(w DeprecationWarning) { notified = append(notified, w) })
+
+			resp, err := p.Chat(context.Background(), &ChatRequest{Model: tt.model})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			var models []string
+			for _, req := range inner.requests() {
+				models = append(models, req.Model)
+			}
+			if !slices.Equal(models, tt.wantModel) {
+				t.Errorf("requested models = %v, want %v", models, tt.wantModel)
+			}
+			if tt.want == nil {
+				if len(notified) != 0 {
+					t.Errorf("notified %+v, want nothing", notified)
+				}
+				return
+			}
+			if len(notified) != 1 || notified[0] != *tt.want {
+				t.Errorf("notified %+v, want %+v", notified, *tt.want)
+			}
+			if got, _ := resp.Metadata[MetadataDeprecation].(DeprecationWarning); got != *tt.want {
+				t.Errorf("metadata = %+v, want %+v", got, *tt.want)
+			}
+			if tt.want.Successor != "" && resp.RequestedModel != tt.model {
+				t.Errorf("RequestedModel = %q, want %q", resp.RequestedModel, tt.model)
+			}
+		})
+	}
+}
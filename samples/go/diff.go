@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOp identifies how a line differs between two responses.
+type DiffOp string
+
+// Line operations reported in a ResponseDiff.
+const (
+	DiffEqual  DiffOp = "="
+	DiffRemove DiffOp = "-"
+	DiffAdd    DiffOp = "+"
+)
+
+// DiffLine is a single line of a content diff.
+type DiffLine struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+// UsageDelta is the change in token usage from one response to another.
+type UsageDelta struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ResponseDiff is a structured, deterministic comparison of two responses,
+// suitable for golden-file regression tests.
+type ResponseDiff struct {
+	ContentChanged bool       `json:"content_changed"`
+	Content        []DiffLine `json:"content,omitempty"`
+
+	Usage UsageDelta `json:"usage"`
+
+	FinishReasonChanged bool   `json:"finish_reason_changed"`
+	FinishReasonBefore  string `json:"finish_reason_before,omitempty"`
+	FinishReasonAfter   string `json:"finish_reason_after,omitempty"`
+}
+
+// Equal reports whether the responses had the same content and finish reason.
+// Usage differences alone do not make responses unequal.
+func (d ResponseDiff) Equal() bool {
+	return !d.ContentChanged && !d.FinishReasonChanged
+}
+
+// String renders the diff in a compact, unified-diff-like form.
+func (d ResponseDiff) String() string {
+	var b strings.Builder
+	if d.FinishReasonChanged {
+		fmt.Fprintf(&b, "finish_reason: %q -> %q\n", d.FinishReasonBefore, d.FinishReasonAfter)
+	}
+	if d.Usage != (UsageDelta{}) {
+		fmt.Fprintf(&b, "usage: prompt %+d, completion %+d, total %+d\n",
+			d.Usage.PromptTokens, d.Usage.CompletionTokens, d.Usage.TotalTokens)
+	}
+	if d.ContentChanged {
+		for _, l := range d.Content {
+			fmt.Fprintf(&b, "%s %s\n", l.Op, l.Text)
+		}
+	}
+	return b.String()
+}
+
+// DiffResponses compares response a (before) with b (after). Content is
+// compared line by line; usage is reported as b minus a.
+func DiffResponses(a, b *ChatResponse) ResponseDiff {
+	var d ResponseDiff
+
+	if a.Content != b.Content {
+		d.ContentChanged = true
+		d.Content = diffLines(strings.Split(a.Content, "\n"), strings.Split(b.Content, "\n"))
+	}
+
+	var ua, ub UsageStats
+	if a.Usage != nil {
+		ua = *a.Usage
+	}
+	if b.Usage != nil {
+		ub = *b.Usage
+	}
+	d.Usage = UsageDelta{
+		PromptTokens:     ub.PromptTokens - ua.PromptTokens,
+		CompletionTokens: ub.CompletionTokens - ua.CompletionTokens,
+		TotalTokens:      ub.TotalTokens - ua.TotalTokens,
+	}
+
+	if a.FinishReason != b.FinishReason {
+		d.FinishReasonChanged = true
+		d.FinishReasonBefore = a.FinishReason
+		d.FinishReasonAfter = b.FinishReason
+	}
+	return d
+}
+
+// diffLines computes a line diff from the longest common subsequence of a and b.
+func diffLines(a, b []string) []DiffLine {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, DiffLine{Op: DiffRemove, Text: a[i]})
+			i++
+		default:
+			out = append(out, DiffLine{Op: DiffAdd, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, DiffLine{Op: DiffRemove, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, DiffLine{Op: DiffAdd, Text: b[j]})
+	}
+	return out
+}
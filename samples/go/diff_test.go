@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      *ChatResponse
+		wantEqual bool
+		wantLines []DiffLine
+		wantUsage UsageDelta
+	}{
+		{
+			name:      "identical",
+			a:         &ChatResponse{Content: "same", FinishReason: "stop"},
+			b:         &ChatResponse{Content: "same", FinishReason: "stop"},
+			wantEqual: true,
+		},
+		{
+			name: "changed line",
+			a:    &ChatResponse{Content: "one\ntwo\nthree"},
+			b:    &ChatResponse{Content: "one\n2\nthree"},
+			wantLines: []DiffLine{
+				{DiffEqual, "one"}, {DiffRemove, "two"}, {DiffAdd, "2"}, {DiffEqual, "three"},
+			},
+		},
+		{
+			name:      "appended line",
+			a:         &ChatResponse{Content: "one"},
+			b:         &ChatResponse{Content: "one\ntwo"},
+			wantLines: []DiffLine{{DiffEqual, "one"}, {DiffAdd, "two"}},
+		},
+		{
+			name:      "usage only",
+			a:         &ChatResponse{Content: "x", Usage: &UsageStats{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+			b:         &ChatResponse{Content: "x", Usage: &UsageStats{PromptTokens: 10, CompletionTokens: 8, TotalTokens: 18}},
+			wantEqual: true,
+			wantUsage: UsageDelta{CompletionTokens: 3, TotalTokens: 3},
+		},
+		{
+			name: "finish reason",
+			a:    &ChatResponse{Content: "x", FinishReason: "stop"},
+			b:    &ChatResponse{Content: "x", FinishReason: "length"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DiffResponses(tt.a, tt.b)
+			if d.Equal() != tt.wantEqual {
+				t.Errorf("Equal() = %v, want %v\n%s", d.Equal(), tt.wantEqual, d)
+			}
+			if !reflect.DeepEqual(d.Content, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", d.Content, tt.wantLines)
+			}
+			if d.Usage != tt.wantUsage {
+				t.Errorf("usage = %+v, want %+v", d.Usage, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestResponseDiffString(t *testing.T) {
+	d := DiffResponses(
+		&ChatResponse{Content: "a", FinishReason: "stop", Usage: &UsageStats{TotalTokens: 4}},
+		&ChatResponse{Content: "b", FinishReason: "length", Usage: &UsageStats{TotalTokens: 2}},
+	)
+	want := "finish_reason: \"stop\" -> \"length\"\nusage: prompt +0, completion +0, total -2\n- a\n+ b\n"
+	if got := d.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
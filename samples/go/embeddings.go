@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// DefaultEmbeddingBatch is the batch size assumed for embedders that do not
+// declare a maximum. It is deliberately small so that unknown backends are not overrun.
+const DefaultEmbeddingBatch = 16
+
+// Embedder computes vector embeddings for text.
+type Embedder interface {
+	// ID returns the unique identifier for this embedder.
+	ID() string
+
+	// Embed returns one embedding per input, in input order. Callers should use
+	// EmbedAll, which splits inputs into batches the embedder accepts.
+	Embed(ctx context.Context, model string, inputs []string) ([][]float32, error)
+}
+
+// EmbeddingBatchSize returns the maximum batch size e declares through its
+// capabilities, or DefaultEmbeddingBatch if it declares none.
+func EmbeddingBatchSize(e Embedder) int {
+	if cp, ok := e.(CapabilityProvider); ok {
+		if n := cp.Capabilities().MaxEmbeddingBatch; n > 0 {
+			return n
+		}
+	}
+	return DefaultEmbeddingBatch
+}
+
+// EmbedAll embeds any number of inputs, splitting them into batches no larger
+// than the embedder's declared maximum.
+func EmbedAll(ctx context.Context, e Embedder, model string, inputs []string) ([][]float32, error) {
+	size := EmbeddingBatchSize(e)
+	out := make([][]float32, 0, len(inputs))
+
+	for start := 0; start < len(inputs); start += size {
+		batch := inputs[start:min(start+size, len(inputs))]
+		vectors, err := e.Embed(ctx, model, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(batch) {
+			return nil, fmt.Errorf("%w: %d embeddings for %d inputs", ErrInvalidResponse, len(vectors), len(batch))
+		}
+		out = append(out, vectors...)
+	}
+	return out, nil
+}
+
+// Embed calls the embeddings API for a single batch of inputs.
+func (p *OpenAIProvider) Embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": model, "input": inputs})
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := p.do(ctx, http.MethodPost, "/embeddings", model, body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var wire struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&wire); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	vectors := make([][]float32, len(inputs))
+	filled := make([]bool, len(inputs))
+	for _, d := range wire.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("%w: embedding index %d out of range", ErrInvalidResponse, d.Index)
+		}
+		if filled[d.Index] {
+			return nil, fmt.Errorf("%w: duplicate embedding for index %d", ErrInvalidResponse, d.Index)
+		}
+		vectors[d.Index], filled[d.Index] = d.Embedding, true
+	}
+	for i, ok := range filled {
+		if !ok {
+			return nil, fmt.Errorf("%w: no embedding for index %d", ErrInvalidResponse, i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAIEmbed(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    [][]float32
+		wantErr bool
+	}{
+		{"in order", `[{"index":0,"embedding":[1]},{"index":1,"embedding":[2]}]`, [][]float32{{1}, {2}}, false},
+		{"out of order", `[{"index":1,"embedding":[2]},{"index":0,"embedding":[1]}]`, [][]float32{{1}, {2}}, false},
+		{"missing index", `[{"index":0,"embedding":[1]}]`, nil, true},
+		{"duplicate index", `[{"index":0,"embedding":[1]},{"index":0,"embedding":[1]}]`, nil, true},
+		{"index out of range", `[{"index":0,"embedding":[1]},{"index":2,"embedding":[2]}]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+				writeJSON(w, `{"data":`+tt.data+`}`)
+			})
+			got, err := srv.provider(OpenAIConfig{}).Embed(context.Background(), "embed", []string{"a", "b"})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidResponse) {
+					t.Fatalf("err = %v, want ErrInvalidResponse", err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("embeddings = %v (%v), want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// batchEmbedder records its batch sizes and returns each input's length as its embedding.
+type batchEmbedder struct {
+	max     int
+	batches []int
+}
+
+func (e *batchEmbedder) ID() string { return "batches" }
+
+func (e *batchEmbedder) Capabilities() Capabilities { return Capabilities{MaxEmbeddingBatch: e.max} }
+
+func (e *batchEmbedder) Embed(_ context.Context, _ string, inputs []string) ([][]float32, error) {
+	e.batches = append(e.batches, len(inputs))
+	out := make([][]float32, len(inputs))
+	for i, in := range inputs {
+		out[i] = []float32{float32(len(in))}
+	}
+	return out, nil
+}
+
+func TestEmbedAll(t *testing.T) {
+	inputs := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	tests := []struct {
+		max         int
+		wantBatches []int
+	}{
+		{2, []int{2, 2, 1}},
+		{10, []int{5}},
+		{0, []int{5}}, // DefaultEmbeddingBatch
+	}
+	for _, tt := range tests {
+		e := &batchEmbedder{max: tt.max}
+		got, err := EmbedAll(context.Background(), e, "m", inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(e.batches, tt.wantBatches) {
+			t.Errorf("max %d: batches %v, want %v", tt.max, e.batches, tt.wantBatches)
+		}
+		if len(got) != 5 || got[4][0] != 5 {
+			t.Errorf("max %d: embeddings %v not in input order", tt.max, got)
+		}
+	}
+}
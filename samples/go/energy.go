@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// MetadataEnergy holds the EnergyEstimate of a response.
+const MetadataEnergy = "energy"
+
+// EnergyFactor is a model's estimated energy use in watt-hours per thousand tokens.
+type EnergyFactor struct {
+	PromptWhPerThousand     float64 `json:"prompt_wh_per_thousand"`
+	CompletionWhPerThousand float64 `json:"completion_wh_per_thousand"`
+}
+
+// EnergyTable maps model names to their energy factors.
+type EnergyTable map[string]EnergyFactor
+
+// EnergyEstimate is the estimated energy and emissions of one or more requests.
+type EnergyEstimate struct {
+	WattHours float64 `json:"watt_hours"`
+	GramsCO2  float64 `json:"grams_co2"`
+	Requests  int     `json:"requests"`
+}
+
+// Estimate returns the estimated energy of usage on model, converted to
+// emissions at gramsPerKWh. It reports false if model has no factor.
+func (t EnergyTable) Estimate(model string, usage *UsageStats, gramsPerKWh float64) (EnergyEstimate, bool) {
+	factor, ok := t[model]
+	if !ok || usage == nil {
+		return EnergyEstimate{}, false
+	}
+	wh := (float64(usage.PromptTokens)*factor.PromptWhPerThousand +
+		float64(usage.CompletionTokens)*factor.CompletionWhPerThousand) / 1000
+	return EnergyEstimate{WattHours: wh, GramsCO2: wh / 1000 * gramsPerKWh, Requests: 1}, true
+}
+
+// EnergyProvider wraps a Provider and estimates the energy and carbon cost of
+// each response from its usage, for sustainability reporting. Estimates are
+// recorded on the response's metadata and accumulated in Totals. Responses
+// for models missing from the table are not counted.
+type EnergyProvider struct {
+	Provider
+	table       EnergyTable
+	gramsPerKWh float64
+
+	mu     sync.Mutex
+	totals EnergyEstimate
+}
+
+// NewEnergyProvider creates a provider that estimates energy with table and
+// converts it to emissions at the grid's carbon intensity, gramsPerKWh.
+func NewEnergyProvider(inner Provider, table EnergyTable, gramsPerKWh float64) *EnergyProvider {
+	return &EnergyProvider{Provider: inner, table: table, gramsPerKWh: gramsPerKWh}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *EnergyProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and records its energy estimate.
+func (p *EnergyProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	est, ok := p.table.Estimate(model, resp.Usage, p.gramsPerKWh)
+	if !ok && model != req.Model {
+		// The served snapshot may be missing from the table while its alias is not.
+		est, ok = p.table.Estimate(req.Model, resp.Usage, p.gramsPerKWh)
+	}
+	if ok {
+		resp.SetMetadata(MetadataEnergy, est)
+
+		p.mu.Lock()
+		p.totals.WattHours += est.WattHours
+		p.totals.GramsCO2 += est.GramsCO2
+		p.totals.Requests++
+		p.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// Totals returns the accumulated estimate of every counted response.
+func (p *EnergyProvider) Totals() EnergyEstimate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.totals
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestEnergyProvider(t *testing.T) {
+	table := EnergyTable{"m": {PromptWhPerThousand: 1, CompletionWhPerThousand: 3}}
+	usage := &UsageStats{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+	tests := []struct {
+		name        string
+		served      string // Model reported on the response
+		usage       *UsageStats
+		wantWh      float64
+		wantCounted bool
+	}{
+		{"served model priced", "m", usage, 2.5, true},
+		{"unreported model uses the request's", "", usage, 2.5, true},
+		{"unknown snapshot uses the request's", "m-2024", usage, 2.5, true},
+		{"no usage", "m", nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+				return &ChatResponse{Model: tt.served, Usage: tt.usage}, nil
+			}}
+			p := NewEnergyProvider(inner, table, 400)
+			resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			est, ok := resp.Metadata[MetadataEnergy].(EnergyEstimate)
+			if ok != tt.wantCounted {
+				t.Fatalf("estimate recorded: %v, want %v", ok, tt.wantCounted)
+			}
+			if !ok {
+				if p.Totals() != (EnergyEstimate{}) {
+					t.Errorf("totals = %+v, want none", p.Totals())
+				}
+				return
+			}
+			if math.Abs(est.WattHours-tt.wantWh) > 1e-9 || math.Abs(est.GramsCO2-tt.wantWh*0.4) > 1e-9 {
+				t.Errorf("estimate = %+v, want %v Wh", est, tt.wantWh)
+			}
+			if p.Totals() != est {
+				t.Errorf("totals = %+v, want %+v", p.Totals(), est)
+			}
+		})
+	}
+}
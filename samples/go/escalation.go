@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidStructuredOutput is returned when no model produced output that passed validation.
+var ErrInvalidStructuredOutput = errors.New("invalid structured output")
+
+// Metadata keys recorded by EscalatingProvider.
+const (
+	MetadataEscalated      = "escalated"       // true when the stronger model produced the response
+	MetadataRepairAttempts = "repair_attempts" // number of repair attempts made on the primary model
+)
+
+// OutputValidator checks a response's content, returning a descriptive error if it is invalid.
+type OutputValidator func(content string) error
+
+// EscalationConfig configures an EscalatingProvider.
+type EscalationConfig struct {
+	Validate OutputValidator
+
+	// MaxRepairs is how many times the primary model is asked to fix its output
+	// before escalating.
+	MaxRepairs int
+
+	// EscalationModel is the stronger model tried once when repairs are exhausted.
+	// If empty, the provider fails without escalating.
+	EscalationModel string
+}
+
+// EscalatingProvider wraps a Provider and validates structured output, asking
+// the model to repair invalid output and escalating to a stronger model when
+// the primary keeps failing.
+type EscalatingProvider struct {
+	Provider
+	config EscalationConfig
+}
+
+// NewEscalatingProvider creates a provider that repairs and escalates invalid output.
+func NewEscalatingProvider(inner Provider, config EscalationConfig) *EscalatingProvider {
+	return &EscalatingProvider{Provider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *EscalatingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request, repairing and escalating until output validates.
+func (p *EscalatingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	attempt := req
+	var lastErr error
+
+	for repairs := 0; repairs <= p.config.MaxRepairs; repairs++ {
+		resp, err := p.Provider.Chat(ctx, attempt)
+		if err != nil {
+			return nil, err
+		}
+		if lastErr = p.config.Validate(resp.Content); lastErr == nil {
+			resp.SetMetadata(MetadataRepairAttempts, repairs)
+			return resp, nil
+		}
+		attempt = repairRequest(attempt, resp.Content, lastErr)
+	}
+
+	if p.config.EscalationModel == "" {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStructuredOutput, lastErr)
+	}
+
+	escalated := *req
+	escalated.Model = p.config.EscalationModel
+	resp, err := p.Provider.Chat(ctx, &escalated)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.config.Validate(resp.Content); err != nil {
+		return nil, fmt.Errorf("%w: escalation to %s failed: %v", ErrInvalidStructuredOutput, p.config.EscalationModel, err)
+	}
+
+	resp.SetMetadata(MetadataEscalated, true)
+	resp.SetMetadata(MetadataRepairAttempts, p.config.MaxRepairs)
+	return resp, nil
+}
+
+// repairRequest returns a copy of req that feeds back the rejected content and
+// the reason it was rejected, asking the model to try again.
+func repairRequest(req *ChatRequest, content string, reason error) *ChatRequest {
+	out := *req
+	out.Messages = append(append([]Message(nil), req.Messages...),
+		Message{Role: "assistant", Content: content},
+		Message{Role: "user", Content: fmt.Sprintf("Your previous response was invalid: %v. Reply again with only the corrected output.", reason)},
+	)
+	return &out
+}
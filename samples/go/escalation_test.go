@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// validJSON is an OutputValidator accepting any JSON value.
+func validJSON(content string) error {
+	if !json.Valid([]byte(content)) {
+		return errors.New("not JSON")
+	}
+	return nil
+}
+
+// modelReplies answers each model with its own sequence of replies, repeating
+// the last one once a model runs out.
+func modelReplies(replies map[string][]string) func(context.Context, *ChatRequest) (*ChatResponse, error) {
+	calls := map[string]int{}
+	return func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		rs := replies[req.Model]
+		reply := rs[min(calls[req.Model], len(rs)-1)]
+		calls[req.Model]++
+		return &ChatResponse{Content: reply, Model: req.Model}, nil
+	}
+}
+
+func TestEscalatingProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		replies       map[string][]string
+		escalateTo    string
+		wantErr       bool
+		wantModel     string
+		wantEscalated bool
+		wantRepairs   int
+		wantCalls     int
+	}{
+		{"valid first time", map[string][]string{"small": {`{}`}}, "big", false, "small", false, 0, 1},
+		{"repaired on primary", map[string][]string{"small": {"oops", `{}`}}, "big", false, "small", false, 1, 2},
+		{"escalated after repairs", map[string][]string{"small": {"oops"}, "big": {`{"ok":true}`}}, "big", false, "big", true, 2, 4},
+		{"escalation also fails", map[string][]string{"small": {"oops"}, "big": {"nope"}}, "big", true, "", false, 0, 4},
+		{"no escalation model", map[string][]string{"small": {"oops"}}, "", true, "", false, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: modelReplies(tt.replies)}
+			p := NewEscalatingProvider(inner, EscalationConfig{Validate: validJSON, MaxRepairs: 2, EscalationModel: tt.escalateTo})
+			resp, err := p.Chat(context.Background(), &ChatRequest{Model: "small", Messages: userMessages("give me JSON")})
+			if got := len(inner.requests()); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStructuredOutput) {
+					t.Fatalf("err = %v, want ErrInvalidStructuredOutput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Model != tt.wantModel {
+				t.Errorf("model = %q, want %q", resp.Model, tt.wantModel)
+			}
+			if got, _ := resp.Metadata[MetadataEscalated].(bool); got != tt.wantEscalated {
+				t.Errorf("escalated = %v, want %v", got, tt.wantEscalated)
+			}
+			if got := resp.Metadata[MetadataRepairAttempts]; got != tt.wantRepairs {
+				t.Errorf("repairs = %v, want %d", got, tt.wantRepairs)
+			}
+		})
+	}
+}
+
+func TestRepairRequestFeedsBackTheError(t *testing.T) {
+	req := &ChatRequest{Messages: userMessages("q")}
+	got := repairRequest(req, "bad", errors.New("missing brace"))
+	if len(req.Messages) != 1 {
+		t.Error("original request modified")
+	}
+	if len(got.Messages) != 3 || got.Messages[1].Content != "bad" || got.Messages[2].Role != "user" {
+		t.Errorf("messages = %+v", got.Messages)
+	}
+}
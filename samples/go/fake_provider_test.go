@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// fakeProvider is a Provider whose Chat behavior is supplied by the test. It
+// records every request it receives.
+type fakeProvider struct {
+	id     string
+	models []string // Nil means every model is available
+	chat   func(ctx context.Context, req *ChatRequest) (*ChatResponse, error)
+
+	mu    sync.Mutex
+	calls []*ChatRequest
+}
+
+func (p *fakeProvider) ID() string {
+	if p.id == "" {
+		return "fake"
+	}
+	return p.id
+}
+
+func (p *fakeProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	p.mu.Lock()
+	p.calls = append(p.calls, req)
+	p.mu.Unlock()
+	if p.chat == nil {
+		return &ChatResponse{Content: "ok", Model: req.Model, FinishReason: "stop"}, nil
+	}
+	return p.chat(ctx, req)
+}
+
+func (p *fakeProvider) IsModelAvailable(_ context.Context, model string) (bool, error) {
+	return p.models == nil || slices.Contains(p.models, model), nil
+}
+
+func (p *fakeProvider) ListModels(context.Context) ([]string, error) {
+	return p.models, nil
+}
+
+// requests returns the requests received so far.
+func (p *fakeProvider) requests() []*ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.calls)
+}
+
+// fakeStreamer is a StreamingProvider whose streams are supplied by the test.
+type fakeStreamer struct {
+	*fakeProvider
+	stream func(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error)
+}
+
+func (p *fakeStreamer) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	p.mu.Lock()
+	p.calls = append(p.calls, req)
+	p.mu.Unlock()
+	return p.stream(ctx, req)
+}
+
+// replyWith returns a Chat function that answers every request with content.
+func replyWith(content string) func(context.Context, *ChatRequest) (*ChatResponse, error) {
+	return func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		return &ChatResponse{Content: content, Model: req.Model, FinishReason: "stop"}, nil
+	}
+}
+
+// outcome is one scripted result of a Chat call.
+type outcome struct {
+	resp *ChatResponse
+	err  error
+}
+
+// scripted returns a Chat function that returns outcomes in order, repeating
+// the last one once they run out.
+func scripted(outcomes ...outcome) func(context.Context, *ChatRequest) (*ChatResponse, error) {
+	var mu sync.Mutex
+	next := 0
+	return func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		o := outcomes[min(next, len(outcomes)-1)]
+		next++
+		return o.resp, o.err
+	}
+}
+
+// streamOf returns a closed channel holding chunks, followed by a terminal
+// chunk if the last one is not already terminal.
+func streamOf(chunks ...StreamChunk) <-chan StreamChunk {
+	if len(chunks) == 0 || !chunks[len(chunks)-1].Done {
+		chunks = append(chunks, StreamChunk{Done: true, Reason: StreamCompleted})
+	}
+	out := make(chan StreamChunk, len(chunks))
+	for _, c := range chunks {
+		out <- c
+	}
+	close(out)
+	return out
+}
+
+// pacedStreamer returns a fakeStreamer that sends chunks with delay before
+// each one, stopping early if the stream's context ends.
+func pacedStreamer(delay time.Duration, chunks ...StreamChunk) *fakeStreamer {
+	return &fakeStreamer{
+		fakeProvider: &fakeProvider{},
+		stream: func(ctx context.Context, _ *ChatRequest) (<-chan StreamChunk, error) {
+			out := make(chan StreamChunk)
+			go func() {
+				defer close(out)
+				for _, c := range chunks {
+					select {
+					case <-time.After(delay):
+					case <-ctx.Done():
+						return
+					}
+					select {
+					case out <- c:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return out, nil
+		},
+	}
+}
+
+// textChunks returns one content chunk per part.
+func textChunks(parts ...string) []StreamChunk {
+	chunks := make([]StreamChunk, len(parts))
+	for i, p := range parts {
+		chunks[i] = StreamChunk{Content: p}
+	}
+	return chunks
+}
+
+// streamingReply returns a fakeStreamer whose streams carry parts.
+func streamingReply(parts ...string) *fakeStreamer {
+	return &fakeStreamer{
+		fakeProvider: &fakeProvider{},
+		stream: func(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+			return streamOf(textChunks(parts...)...), nil
+		},
+	}
+}
+
+// userMessages returns one user message per content string.
+func userMessages(contents ...string) []Message {
+	msgs := make([]Message, len(contents))
+	for i, c := range contents {
+		msgs[i] = Message{Role: "user", Content: c}
+	}
+	return msgs
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Feature is a request feature counted by FeatureUsageProvider. The set is
+// fixed, so the counters have bounded cardinality.
+type Feature string
+
+const (
+	FeatureStreaming        Feature = "streaming"
+	FeatureTools            Feature = "tools"
+	FeatureToolResults      Feature = "tool_results" // The conversation carries tool results
+	FeatureJSONMode         Feature = "json_mode"
+	FeatureStructuredOutput Feature = "structured_output"
+	FeatureBuiltinTools     Feature = "builtin_tools"
+	FeatureGrammar          Feature = "grammar"
+	FeatureSeed             Feature = "seed"
+	FeatureLogprobs         Feature = "logprobs"
+	FeatureMultipleChoices  Feature = "multiple_choices"
+	FeatureStoredPrompt     Feature = "stored_prompt"
+)
+
+// FeatureUsage is a snapshot of FeatureUsageProvider's counters.
+type FeatureUsage struct {
+	Requests int64             `json:"requests"`
+	Features map[Feature]int64 `json:"features"` // Requests using each feature
+}
+
+// FeatureUsageProvider wraps a Provider and counts which features requests
+// use, for aggregate product reporting. Requests are counted when made,
+// whether or not they succeed.
+type FeatureUsageProvider struct {
+	Provider
+
+	mu       sync.Mutex
+	requests int64
+	features map[Feature]int64
+}
+
+// NewFeatureUsageProvider creates a provider that counts feature usage.
+func NewFeatureUsageProvider(inner Provider) *FeatureUsageProvider {
+	return &FeatureUsageProvider{Provider: inner, features: make(map[Feature]int64)}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *FeatureUsageProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat counts the request's features and forwards it.
+func (p *FeatureUsageProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	p.count(req, false)
+	return p.Provider.Chat(ctx, req)
+}
+
+// ChatStream counts the request's features and starts a stream.
+func (p *FeatureUsageProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	inner, err := streamingInner(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	p.count(req, true)
+	return inner.ChatStream(ctx, req)
+}
+
+// Usage returns the counters so far.
+func (p *FeatureUsageProvider) Usage() FeatureUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return FeatureUsage{Requests: p.requests, Features: maps.Clone(p.features)}
+}
+
+func (p *FeatureUsageProvider) count(req *ChatRequest, streaming bool) {
+	used := requestFeatures(req)
+	if streaming {
+		used = append(used, FeatureStreaming)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests++
+	for _, f := range used {
+		p.features[f]++
+	}
+}
+
+// requestFeatures lists the features req uses, other than streaming.
+func requestFeatures(req *ChatRequest) []Feature {
+	var used []Feature
+	add := func(f Feature, ok bool) {
+		if ok {
+			used = append(used, f)
+		}
+	}
+	add(FeatureTools, len(req.Tools) > 0)
+	add(FeatureToolResults, slices.ContainsFunc(req.Messages, func(m Message) bool { return m.Role == "tool" }))
+	if req.ResponseFormat != nil {
+		add(FeatureJSONMode, req.ResponseFormat.Type == ResponseFormatJSONObject)
+		add(FeatureStructuredOutput, req.ResponseFormat.Type == ResponseFormatJSONSchema)
+	}
+	add(FeatureBuiltinTools, len(req.BuiltinTools) > 0)
+	add(FeatureGrammar, req.Grammar != "")
+	add(FeatureSeed, req.Seed != nil)
+	add(FeatureLogprobs, req.Logprobs)
+	add(FeatureMultipleChoices, req.N > 1)
+	add(FeatureStoredPrompt, req.PromptID != "")
+	return used
+}
@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+	"maps"
+	"testing"
+)
+
+func TestFeatureUsageProvider(t *testing.T) {
+	seed := int64(1)
+	tests := []struct {
+		name   string
+		req    *ChatRequest
+		stream bool
+		want   map[Feature]int64
+	}{
+		{name: "plain", req: &ChatRequest{Messages: userMessages("hi")}, want: map[Feature]int64{}},
+		{name: "streaming", req: &ChatRequest{}, stream: true, want: map[Feature]int64{FeatureStreaming: 1}},
+		{
+			name: "tools and results",
+			req: &ChatRequest{
+				Tools:    []ToolDefinition{{Name: "f"}},
+				Messages: []Message{{Role: "tool", ToolCallID: "c1", Content: "x"}},
+			},
+			want: map[Feature]int64{FeatureTools: 1, FeatureToolResults: 1},
+		},
+		{
+			name: "json mode",
+			req:  &ChatRequest{ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject}},
+			want: map[Feature]int64{FeatureJSONMode: 1},
+		},
+		{
+			name: "structured output",
+			req:  &ChatRequest{ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONSchema}},
+			want: map[Feature]int64{FeatureStructuredOutput: 1},
+		},
+		{
+			name: "text format",
+			req:  &ChatRequest{ResponseFormat: &ResponseFormat{Type: ResponseFormatText}},
+			want: map[Feature]int64{},
+		},
+		{
+			name: "sampling options",
+			req:  &ChatRequest{Seed: &seed, Logprobs: true, N: 2, Grammar: "root ::= x"},
+			want: map[Feature]int64{FeatureSeed: 1, FeatureLogprobs: 1, FeatureMultipleChoices: 1, FeatureGrammar: 1},
+		},
+		{name: "single choice", req: &ChatRequest{N: 1}, want: map[Feature]int64{}},
+		{
+			name: "hosted",
+			req:  &ChatRequest{PromptID: "p", BuiltinTools: []BuiltinTool{BuiltinWebSearch}},
+			want: map[Feature]int64{FeatureStoredPrompt: 1, FeatureBuiltinTools: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewFeatureUsageProvider(streamingReply("ok"))
+			if tt.stream {
+				chunks, err := p.ChatStream(context.Background(), tt.req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				collectStream(chunks)
+			} else if _, err := p.Chat(context.Background(), tt.req); err != nil {
+				t.Fatal(err)
+			}
+
+			got := p.Usage()
+			if got.Requests != 1 || !maps.Equal(got.Features, tt.want) {
+				t.Errorf("usage = %+v, want 1 request using %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeatureUsageSnapshot(t *testing.T) {
+	p := NewFeatureUsageProvider(&fakeProvider{})
+	req := &ChatRequest{Tools: []ToolDefinition{{Name: "f"}}}
+	p.Chat(context.Background(), req)
+	snapshot := p.Usage()
+	p.Chat(context.Background(), req)
+	if snapshot.Requests != 1 || snapshot.Features[FeatureTools] != 1 {
+		t.Errorf("snapshot = %+v changed after a later request", snapshot)
+	}
+	if got := p.Usage(); got.Requests != 2 || got.Features[FeatureTools] != 2 {
+		t.Errorf("usage = %+v, want 2 requests with tools", got)
+	}
+}
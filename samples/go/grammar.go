@@ -0,0 +1,18 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrGrammarNotSupported is returned when a request carries a grammar for a
+// provider that cannot apply one.
+var ErrGrammarNotSupported = errors.New("provider does not support grammar-constrained decoding")
+
+// checkGrammar fails requests that carry a grammar unless p declares support.
+func checkGrammar(p Provider, req *ChatRequest) error {
+	if req.Grammar != "" && !CapabilitiesOf(p).Grammar {
+		return fmt.Errorf("%w: %s", ErrGrammarNotSupported, p.ID())
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// reservedHeaders carry credentials and cannot be set per request.
+var reservedHeaders = []string{"Authorization", "Api-Key", "Proxy-Authorization"}
+
+type headersKey struct{}
+
+// WithHeaders returns a context adding h to the HTTP requests made on its
+// behalf. They override the provider's default headers, except for the
+// reserved authentication headers, which are ignored.
+func WithHeaders(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, headersKey{}, h)
+}
+
+// HeadersFromContext returns the headers set by WithHeaders.
+func HeadersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(headersKey{}).(http.Header)
+	return h
+}
+
+// applyHeaders sets the provider's defaults on dst, then the request's
+// headers from ctx, skipping reserved ones.
+func applyHeaders(ctx context.Context, dst, defaults http.Header) {
+	for k, v := range defaults {
+		dst[http.CanonicalHeaderKey(k)] = slices.Clone(v)
+	}
+	for k, v := range HeadersFromContext(ctx) {
+		k = http.CanonicalHeaderKey(k)
+		if !slices.Contains(reservedHeaders, k) {
+			dst[k] = slices.Clone(v)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplyHeaders(t *testing.T) {
+	defaults := http.Header{"x-gateway": {"east"}, "Api-Version": {"1"}}
+	tests := []struct {
+		name    string
+		request http.Header
+		want    http.Header
+	}{
+		{
+			name: "defaults only",
+			want: http.Header{"X-Gateway": {"east"}, "Api-Version": {"1"}},
+		},
+		{
+			name:    "request overrides defaults",
+			request: http.Header{"x-gateway": {"west"}, "X-Trace": {"t1"}},
+			want:    http.Header{"X-Gateway": {"west"}, "Api-Version": {"1"}, "X-Trace": {"t1"}},
+		},
+		{
+			name:    "reserved headers are ignored",
+			request: http.Header{"authorization": {"Bearer stolen"}, "Api-Key": {"k"}, "Proxy-Authorization": {"p"}},
+			want:    http.Header{"X-Gateway": {"east"}, "Api-Version": {"1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.request != nil {
+				ctx = WithHeaders(ctx, tt.request)
+			}
+			got := http.Header{}
+			applyHeaders(ctx, got, defaults)
+			if len(got) != len(tt.want) {
+				t.Fatalf("headers = %v, want %v", got, tt.want)
+			}
+			for k := range tt.want {
+				if got.Get(k) != tt.want.Get(k) {
+					t.Errorf("%s = %q, want %q", k, got.Get(k), tt.want.Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAIHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		writeJSON(w, `{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`)
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider(OpenAIConfig{BaseURL: srv.URL, HTTPClient: srv.Client(), APIKey: "key", Headers: http.Header{"X-Gateway": {"east"}}})
+	ctx := WithHeaders(context.Background(), http.Header{"X-Trace": {"t1"}, "Authorization": {"Bearer stolen"}})
+	if _, err := p.Chat(ctx, &ChatRequest{Model: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("X-Gateway") != "east" || got.Get("X-Trace") != "t1" || got.Get("Authorization") != "Bearer key" {
+		t.Errorf("headers = %v", got)
+	}
+}
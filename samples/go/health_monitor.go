@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ProviderHealth is the health of a provider as last observed by a HealthMonitor.
+type ProviderHealth struct {
+	Healthy     bool
+	LastError   error
+	LastChecked time.Time
+
+	// Latency percentiles over the most recent Chat calls, and the number of
+	// samples they were computed from.
+	P50     time.Duration
+	P95     time.Duration
+	Samples int
+}
+
+// providerHealthState is the mutable state behind a ProviderHealth.
+type providerHealthState struct {
+	healthy     bool
+	lastError   error
+	lastChecked time.Time
+	latencies   []time.Duration // ring buffer of recent samples
+	next        int
+}
+
+// HealthMonitor periodically probes the providers in a registry and tracks
+// their Chat latency. Probes decide whether a provider is healthy; latency
+// samples come from Chat calls made through providers returned by Track.
+type HealthMonitor struct {
+	registry *ProviderRegistry
+	interval time.Duration
+	window   int
+
+	mu    sync.RWMutex
+	state map[string]*providerHealthState
+}
+
+// NewHealthMonitor creates a monitor that probes registry every interval
+// (default 30s) and keeps the last window latency samples per provider (default 100).
+func NewHealthMonitor(registry *ProviderRegistry, interval time.Duration, window int) *HealthMonitor {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if window <= 0 {
+		window = 100
+	}
+	return &HealthMonitor{
+		registry: registry,
+		interval: interval,
+		window:   window,
+		state:    make(map[string]*providerHealthState),
+	}
+}
+
+// Run probes immediately and then every interval until ctx is canceled.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	m.Probe(ctx)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Probe(ctx)
+		}
+	}
+}
+
+// Probe checks every registered provider now and records the results.
+func (m *HealthMonitor) Probe(ctx context.Context) {
+	results := m.registry.HealthCheck(ctx)
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, err := range results {
+		s := m.stateLocked(id)
+		s.healthy = err == nil
+		s.lastError = err
+		s.lastChecked = now
+	}
+}
+
+// RecordLatency adds a Chat latency sample for a provider.
+func (m *HealthMonitor) RecordLatency(providerID string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.stateLocked(providerID)
+	if len(s.latencies) < m.window {
+		s.latencies = append(s.latencies, d)
+		return
+	}
+	s.latencies[s.next] = d
+	s.next = (s.next + 1) % m.window
+}
+
+// Status returns the health of a provider, or false if it has never been
+// probed or called.
+func (m *HealthMonitor) Status(providerID string) (ProviderHealth, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.state[providerID]
+	if !ok {
+		return ProviderHealth{}, false
+	}
+	return s.snapshot(), true
+}
+
+// Snapshot returns the health of every known provider.
+func (m *HealthMonitor) Snapshot() map[string]ProviderHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make(map[string]ProviderHealth, len(m.state))
+	for id, s := range m.state {
+		out[id] = s.snapshot()
+	}
+	return out
+}
+
+// Track wraps p so that the latency of every successful Chat call is recorded.
+func (m *HealthMonitor) Track(p Provider) Provider {
+	return &latencyTrackingProvider{Provider: p, monitor: m}
+}
+
+func (m *HealthMonitor) stateLocked(id string) *providerHealthState {
+	s, ok := m.state[id]
+	if !ok {
+		s = &providerHealthState{}
+		m.state[id] = s
+	}
+	return s
+}
+
+func (s *providerHealthState) snapshot() ProviderHealth {
+	h := ProviderHealth{
+		Healthy:     s.healthy,
+		LastError:   s.lastError,
+		LastChecked: s.lastChecked,
+		Samples:     len(s.latencies),
+	}
+	if len(s.latencies) > 0 {
+		sorted := slices.Clone(s.latencies)
+		slices.Sort(sorted)
+		h.P50 = percentile(sorted, 50)
+		h.P95 = percentile(sorted, 95)
+	}
+	return h
+}
+
+// percentile returns the nearest-rank percentile of sorted, which must be non-empty.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// latencyTrackingProvider reports Chat latencies to a HealthMonitor.
+type latencyTrackingProvider struct {
+	Provider
+	monitor *HealthMonitor
+}
+
+// Unwrap returns the wrapped provider.
+func (p *latencyTrackingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+func (p *latencyTrackingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+	resp, err := p.Provider.Chat(ctx, req)
+	if err == nil {
+		p.monitor.RecordLatency(p.ID(), time.Since(start))
+	}
+	return resp, err
+}
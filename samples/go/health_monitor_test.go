@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// unreachableProvider fails every model listing, as a provider that is down would.
+type unreachableProvider struct{ *fakeProvider }
+
+func (p unreachableProvider) ListModels(context.Context) ([]string, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestHealthMonitorProbe(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(&fakeProvider{id: "up", models: []string{"m"}})
+	registry.Register(unreachableProvider{&fakeProvider{id: "down"}})
+
+	m := NewHealthMonitor(registry, 0, 0)
+	if _, ok := m.Status("up"); ok {
+		t.Fatal("status known before the first probe")
+	}
+	m.Probe(context.Background())
+
+	tests := []struct {
+		id          string
+		wantHealthy bool
+	}{
+		{"up", true},
+		{"down", false},
+	}
+	for _, tt := range tests {
+		h, ok := m.Status(tt.id)
+		if !ok || h.Healthy != tt.wantHealthy || (h.LastError == nil) == !tt.wantHealthy || h.LastChecked.IsZero() {
+			t.Errorf("%s: status %+v (known %v), want healthy %v", tt.id, h, ok, tt.wantHealthy)
+		}
+	}
+	if len(m.Snapshot()) != 2 {
+		t.Errorf("snapshot has %d providers, want 2", len(m.Snapshot()))
+	}
+}
+
+func TestHealthMonitorPercentiles(t *testing.T) {
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	tests := []struct {
+		name        string
+		window      int
+		samples     []int
+		wantP50     time.Duration
+		wantP95     time.Duration
+		wantSamples int
+	}{
+		{"single sample", 10, []int{7}, ms(7), ms(7), 1},
+		{"one to twenty", 100, seq(1, 20), ms(10), ms(19), 20},
+		{"window keeps the newest", 5, []int{100, 100, 1, 2, 3, 4, 5}, ms(3), ms(5), 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewHealthMonitor(NewProviderRegistry(), 0, tt.window)
+			for _, n := range tt.samples {
+				m.RecordLatency("p", ms(n))
+			}
+			h, _ := m.Status("p")
+			if h.P50 != tt.wantP50 || h.P95 != tt.wantP95 || h.Samples != tt.wantSamples {
+				t.Errorf("p50 %v, p95 %v, samples %d; want %v, %v, %d", h.P50, h.P95, h.Samples, tt.wantP50, tt.wantP95, tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestHealthMonitorTrack(t *testing.T) {
+	m := NewHealthMonitor(NewProviderRegistry(), 0, 0)
+	failing := &fakeProvider{id: "p", chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		return nil, ErrRateLimited
+	}}
+	m.Track(failing).Chat(context.Background(), &ChatRequest{})
+	if _, ok := m.Status("p"); ok {
+		t.Error("failed call was recorded")
+	}
+	m.Track(&fakeProvider{id: "p"}).Chat(context.Background(), &ChatRequest{})
+	if h, _ := m.Status("p"); h.Samples != 1 {
+		t.Errorf("samples = %d, want 1", h.Samples)
+	}
+}
+
+// seq returns the integers from lo to hi inclusive.
+func seq(lo, hi int) []int {
+	var out []int
+	for i := lo; i <= hi; i++ {
+		out = append(out, i)
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package llm
+
+import "context"
+
+// WithMaxInFlight caps the number of concurrent Chat and ChatWithFallback
+// calls across the whole registry at n, protecting shared resources such as
+// memory and connections. Calls over the cap wait for a slot or for their
+// context to end.
+func WithMaxInFlight(n int) RegistryOption {
+	return func(r *ProviderRegistry) {
+		if n > 0 {
+			r.inFlight = make(chan struct{}, n)
+		}
+	}
+}
+
+// acquireSlot waits for an in-flight slot and returns the function that
+// releases it.
+func (r *ProviderRegistry) acquireSlot(ctx context.Context) (release func(), err error) {
+	if r.inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.inFlight <- struct{}{}:
+		return func() { <-r.inFlight }, nil
+	case <-ctx.Done():
+		return nil, ErrContextCanceled
+	}
+}
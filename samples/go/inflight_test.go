@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyProbe is a Chat function that records the peak number of
+// concurrent calls, holding each call until release is closed.
+type concurrencyProbe struct {
+	active, peak atomic.Int32
+	release      chan struct{}
+}
+
+func (c *concurrencyProbe) chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	n := c.active.Add(1)
+	defer c.active.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	<-c.release
+	return &ChatResponse{Content: "ok"}, nil
+}
+
+func TestRegistryMaxInFlight(t *testing.T) {
+	const callers = 6
+	tests := []struct {
+		name     string
+		limit    int
+		fallback bool
+		wantPeak int32
+	}{
+		{"unlimited", 0, false, callers},
+		{"chat capped", 2, false, 2},
+		{"fallback capped", 2, true, 2},
+		{"serialized", 1, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := &concurrencyProbe{release: make(chan struct{})}
+			r := NewProviderRegistry(WithMaxInFlight(tt.limit))
+			r.Register(&fakeProvider{id: "p", chat: probe.chat})
+			if err := r.SetDefault("p"); err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			for range callers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := &ChatRequest{Model: "m", Messages: userMessages("hi")}
+					var err error
+					if tt.fallback {
+						_, err = r.ChatWithFallback(context.Background(), req, []string{"p"})
+					} else {
+						_, err = r.Chat(context.Background(), req)
+					}
+					if err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			// Give every caller the chance to enter before releasing them.
+			deadline := time.Now().Add(time.Second)
+			for probe.active.Load() < tt.wantPeak && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(probe.release)
+			wg.Wait()
+
+			if got := probe.peak.Load(); got != tt.wantPeak {
+				t.Errorf("peak concurrency = %d, want %d", got, tt.wantPeak)
+			}
+		})
+	}
+}
+
+func TestRegistryMaxInFlightWaitCanceled(t *testing.T) {
+	probe := &concurrencyProbe{release: make(chan struct{})}
+	defer close(probe.release)
+	r := NewProviderRegistry(WithMaxInFlight(1))
+	r.Register(&fakeProvider{id: "p", chat: probe.chat})
+	if err := r.SetDefault("p"); err != nil {
+		t.Fatal(err)
+	}
+	req := &ChatRequest{Model: "m", Messages: userMessages("hi")}
+	go r.Chat(context.Background(), req) // Holds the only slot
+
+	for probe.active.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := r.Chat(ctx, req); !errors.Is(err, ErrContextCanceled) {
+		t.Errorf("err = %v, want ErrContextCanceled while waiting for a slot", err)
+	}
+	if got := probe.peak.Load(); got != 1 {
+		t.Errorf("peak concurrency = %d, want 1", got)
+	}
+}
@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema used by tool parameter declarations:
+// type, properties, required, additionalProperties, items and enum.
+type jsonSchema struct {
+	Type                 any                    `json:"type"` // A string or a list of strings
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+}
+
+// validateJSONSchema checks that data conforms to schema. Empty schemas accept anything.
+func validateJSONSchema(schema json.RawMessage, data []byte) error {
+	if len(bytes.TrimSpace(schema)) == 0 {
+		if !json.Valid(data) {
+			return fmt.Errorf("not valid JSON")
+		}
+		return nil
+	}
+
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid schema: %v", err)
+	}
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("not valid JSON: %v", err)
+	}
+	return s.validate("$", value)
+}
+
+func (s *jsonSchema) validate(path string, value any) error {
+	if s == nil {
+		return nil
+	}
+	if types := s.types(); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return jsonTypeMatches(t, value) }) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeOf(value))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, value) }) {
+		return fmt.Errorf("%s: value not in enum", path)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			field := v[name]
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := prop.validate(path+"."+name, field); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func jsonTypeMatches(t string, value any) bool {
+	if t == "integer" {
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	if t == "number" {
+		_, ok := value.(json.Number)
+		return ok
+	}
+	return jsonTypeOf(value) == t
+}
+
+func jsonTypeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+// jsonEqual compares a schema enum value with a decoded value.
+func jsonEqual(enum, value any) bool {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		e, isFloat := enum.(float64)
+		return err == nil && isFloat && f == e
+	}
+	return enum == value
+}
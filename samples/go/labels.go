@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrNoLabelMatch is returned when a response is not one of the allowed labels.
+var ErrNoLabelMatch = errors.New("response is not an allowed label")
+
+// MetadataLabelFuzzy is set when a response was mapped to a label by fuzzy
+// matching; the value is the original content.
+const MetadataLabelFuzzy = "label_fuzzy_match"
+
+// LabelConfig configures a LabelProvider.
+type LabelConfig struct {
+	Labels []string
+
+	// Fuzzy maps near misses, such as different case, surrounding punctuation
+	// or a small typo, to the closest label.
+	Fuzzy bool
+
+	// MaxRetries is how many times the model is asked again after an
+	// unmatched response before ErrNoLabelMatch is returned.
+	MaxRetries int
+}
+
+// LabelProvider wraps a Provider for strict classification: the response
+// content is guaranteed to be exactly one of the configured labels.
+type LabelProvider struct {
+	Provider
+	config LabelConfig
+}
+
+// NewLabelProvider creates a provider that constrains responses to config.Labels.
+func NewLabelProvider(inner Provider, config LabelConfig) *LabelProvider {
+	return &LabelProvider{Provider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *LabelProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and replaces the content with the matched label.
+func (p *LabelProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	attempt := req
+	for retries := 0; ; retries++ {
+		resp, err := p.Provider.Chat(ctx, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		label, fuzzy, ok := p.Match(resp.Content)
+		if ok {
+			if fuzzy {
+				resp.SetMetadata(MetadataLabelFuzzy, resp.Content)
+			}
+			resp.Content = label
+			return resp, nil
+		}
+
+		reason := fmt.Errorf("%w: %q", ErrNoLabelMatch, strings.TrimSpace(resp.Content))
+		if retries >= p.config.MaxRetries {
+			return nil, reason
+		}
+		attempt = repairRequest(attempt, resp.Content, fmt.Errorf("it must be exactly one of %s", strings.Join(p.config.Labels, ", ")))
+	}
+}
+
+// Match returns the label content corresponds to and whether fuzzy matching
+// was needed.
+func (p *LabelProvider) Match(content string) (label string, fuzzy bool, ok bool) {
+	trimmed := strings.TrimSpace(content)
+	for _, l := range p.config.Labels {
+		if trimmed == l {
+			return l, false, true
+		}
+	}
+	if !p.config.Fuzzy {
+		return "", false, false
+	}
+
+	squashed := squashLabel(trimmed)
+	best, bestDist, tie := "", -1, false
+	for _, l := range p.config.Labels {
+		d := editDistance(squashed, squashLabel(l))
+		switch {
+		case bestDist < 0 || d < bestDist:
+			best, bestDist, tie = l, d, false
+		case d == bestDist:
+			tie = true
+		}
+	}
+	if tie || bestDist < 0 || bestDist > max(1, len(squashed)/5) {
+		return "", false, false
+	}
+	return best, true, true
+}
+
+// squashLabel lowercases s and drops everything but letters and digits.
+func squashLabel(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// editDistance is the Levenshtein distance between a and b, in runes.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
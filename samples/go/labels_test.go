@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLabelProviderMatch(t *testing.T) {
+	labels := []string{"positive", "negative", "neutral"}
+	tests := []struct {
+		content   string
+		fuzzy     bool
+		want      string
+		wantFuzzy bool
+		wantOK    bool
+	}{
+		{"positive", false, "positive", false, true},
+		{"  neutral\n", false, "neutral", false, true},
+		{"Positive.", false, "", false, false},
+		{"Positive.", true, "positive", true, true},
+		{"negtive", true, "negative", true, true},
+		{"mixed", true, "", false, false},
+		{"The sentiment is positive", true, "", false, false},
+	}
+	for _, tt := range tests {
+		p := NewLabelProvider(&fakeProvider{}, LabelConfig{Labels: labels, Fuzzy: tt.fuzzy})
+		label, fuzzy, ok := p.Match(tt.content)
+		if label != tt.want || fuzzy != tt.wantFuzzy || ok != tt.wantOK {
+			t.Errorf("Match(%q) fuzzy=%v = %q, %v, %v; want %q, %v, %v", tt.content, tt.fuzzy, label, fuzzy, ok, tt.want, tt.wantFuzzy, tt.wantOK)
+		}
+	}
+}
+
+func TestLabelProviderRetries(t *testing.T) {
+	reply := func(s string) outcome { return outcome{resp: &ChatResponse{Content: s}} }
+	tests := []struct {
+		name      string
+		outcomes  []outcome
+		wantLabel string
+		wantCalls int
+	}{
+		{"exact label", []outcome{reply("yes")}, "yes", 1},
+		{"retried until matched", []outcome{reply("maybe"), reply("no")}, "no", 2},
+		{"retries exhausted", []outcome{reply("maybe")}, "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(tt.outcomes...)}
+			p := NewLabelProvider(inner, LabelConfig{Labels: []string{"yes", "no"}, MaxRetries: 1})
+			resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("agree?")})
+			if got := len(inner.requests()); got != tt.wantCalls {
+				t.Errorf("inner called %d times, want %d", got, tt.wantCalls)
+			}
+			if tt.wantLabel == "" {
+				if !errors.Is(err, ErrNoLabelMatch) {
+					t.Errorf("err = %v, want ErrNoLabelMatch", err)
+				}
+				return
+			}
+			if err != nil || resp.Content != tt.wantLabel {
+				t.Errorf("Chat = %+v, %v; want %q", resp, err, tt.wantLabel)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrLanguageMismatch is returned when a response is not in the expected language.
+var ErrLanguageMismatch = errors.New("response language mismatch")
+
+// LanguageMismatchError reports the expected and detected languages of a rejected response.
+type LanguageMismatchError struct {
+	Expected string
+	Detected string
+}
+
+func (e *LanguageMismatchError) Error() string {
+	return fmt.Sprintf("%v: expected %q, detected %q", ErrLanguageMismatch, e.Expected, e.Detected)
+}
+
+// Is reports whether target is ErrLanguageMismatch.
+func (e *LanguageMismatchError) Is(target error) bool {
+	return target == ErrLanguageMismatch
+}
+
+// LanguageDetector returns the language code (e.g. "en", "fr") of text.
+type LanguageDetector func(text string) (string, error)
+
+type expectedLanguageKey struct{}
+
+// WithExpectedLanguage returns a context requiring responses in lang.
+func WithExpectedLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, expectedLanguageKey{}, lang)
+}
+
+// ExpectedLanguageFromContext returns the language set by WithExpectedLanguage.
+func ExpectedLanguageFromContext(ctx context.Context) (string, bool) {
+	lang, ok := ctx.Value(expectedLanguageKey{}).(string)
+	return lang, ok && lang != ""
+}
+
+// LanguageProvider wraps a Provider and ensures responses are in the expected
+// language, retrying once with an explicit instruction on mismatch.
+type LanguageProvider struct {
+	Provider
+	detect      LanguageDetector
+	defaultLang string
+}
+
+// NewLanguageProvider creates a provider that checks response language with detect.
+// defaultLang is used when the context does not carry an expected language; if
+// both are empty the response is passed through unchecked.
+func NewLanguageProvider(inner Provider, detect LanguageDetector, defaultLang string) *LanguageProvider {
+	return &LanguageProvider{Provider: inner, detect: detect, defaultLang: defaultLang}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *LanguageProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and verifies the response language.
+func (p *LanguageProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	expected, ok := ExpectedLanguageFromContext(ctx)
+	if !ok {
+		expected = p.defaultLang
+	}
+
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil || expected == "" {
+		return resp, err
+	}
+
+	detected, err := p.detect(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+	if sameLanguage(detected, expected) {
+		return resp, nil
+	}
+
+	retry := withSystemInstruction(req, fmt.Sprintf("Respond only in the language with code %q.", expected))
+	resp, err = p.Provider.Chat(ctx, retry)
+	if err != nil {
+		return nil, err
+	}
+
+	detected, err = p.detect(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+	if !sameLanguage(detected, expected) {
+		return nil, &LanguageMismatchError{Expected: expected, Detected: detected}
+	}
+	return resp, nil
+}
+
+// sameLanguage compares language codes by their primary subtag, so "en-US" matches "en".
+func sameLanguage(a, b string) bool {
+	primary := func(code string) string {
+		code = strings.ToLower(code)
+		if i := strings.IndexAny(code, "-_"); i >= 0 {
+			code = code[:i]
+		}
+		return code
+	}
+	return primary(a) == primary(b)
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// prefixDetector treats a response's leading "xx:" as its language code.
+func prefixDetector(text string) (string, error) {
+	code, _, ok := strings.Cut(text, ":")
+	if !ok {
+		return "", errors.New("no language prefix")
+	}
+	return code, nil
+}
+
+func TestLanguageProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		expected  string
+		replies   []string
+		wantCalls int
+		wantErr   error
+		want      string
+	}{
+		{"match passes through", "fr", []string{"fr: bonjour"}, 1, nil, "fr: bonjour"},
+		{"regional variant matches", "en", []string{"en-US: hello"}, 1, nil, "en-US: hello"},
+		{"mismatch then retry", "fr", []string{"en: hello", "fr: bonjour"}, 2, nil, "fr: bonjour"},
+		{"still wrong after retry", "fr", []string{"en: hello", "de: hallo"}, 2, ErrLanguageMismatch, ""},
+		{"no expectation", "", []string{"en: hello"}, 1, nil, "en: hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			inner := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+				reply := tt.replies[min(calls, len(tt.replies)-1)]
+				calls++
+				return &ChatResponse{Content: reply}, nil
+			}}
+			ctx := context.Background()
+			if tt.expected != "" {
+				ctx = WithExpectedLanguage(ctx, tt.expected)
+			}
+
+			resp, err := NewLanguageProvider(inner, prefixDetector, "").Chat(ctx, &ChatRequest{Messages: userMessages("hi")})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if err == nil && resp.Content != tt.want {
+				t.Errorf("content = %q, want %q", resp.Content, tt.want)
+			}
+			var mismatch *LanguageMismatchError
+			if tt.wantErr != nil && (!errors.As(err, &mismatch) || mismatch.Expected != tt.expected) {
+				t.Errorf("err = %v, want LanguageMismatchError expecting %q", err, tt.expected)
+			}
+			if calls == 2 {
+				retry := inner.requests()[1]
+				if retry.Messages[0].Role != "system" || !strings.Contains(retry.Messages[0].Content, `"fr"`) {
+					t.Errorf("retry did not instruct the language: %+v", retry.Messages)
+				}
+			}
+		})
+	}
+}
+
+func TestLanguageProviderDefault(t *testing.T) {
+	inner := &fakeProvider{chat: replyWith("en: hello")}
+	_, err := NewLanguageProvider(inner, prefixDetector, "fr").Chat(context.Background(), &ChatRequest{})
+	if !errors.Is(err, ErrLanguageMismatch) {
+		t.Errorf("err = %v, want ErrLanguageMismatch from the default language", err)
+	}
+}
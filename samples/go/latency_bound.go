@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// MetadataLatencyTruncated records which latency budget truncated a response:
+// "first_token" or "total".
+const MetadataLatencyTruncated = "latency_truncated"
+
+// LatencyBudget bounds how long a streamed response may take. Zero values disable a bound.
+type LatencyBudget struct {
+	FirstToken time.Duration // Maximum time until the first content chunk
+	Total      time.Duration // Maximum time for the whole response
+}
+
+// LatencyBoundProvider wraps a StreamingProvider and cuts off responses that
+// exceed a LatencyBudget, keeping whatever content arrived in time.
+//
+// Chat is served by streaming, so blocking callers also get the partial
+// response rather than an error.
+type LatencyBoundProvider struct {
+	StreamingProvider
+	budget LatencyBudget
+}
+
+// NewLatencyBoundProvider creates a provider that enforces budget on every response.
+func NewLatencyBoundProvider(inner StreamingProvider, budget LatencyBudget) *LatencyBoundProvider {
+	return &LatencyBoundProvider{StreamingProvider: inner, budget: budget}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *LatencyBoundProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// Chat streams the response and returns it, marked as truncated in metadata if
+// a latency budget was exceeded. A stream that fails after reporting usage
+// returns a PartialUsageError.
+func (p *LatencyBoundProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+
+	stream, err := p.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	content, final := collectStream(stream)
+	if final.Err != nil {
+		return nil, withPartialUsage(final.Err, final.Usage)
+	}
+
+	resp := &ChatResponse{
+		Content:      content,
+		Model:        final.Model,
+		FinishReason: final.FinishReason,
+		Usage:        final.Usage,
+		Latency:      time.Since(start),
+	}
+	if resp.Model == "" {
+		resp.Model = req.Model
+	}
+	if resp.FinishReason == "" {
+		resp.FinishReason = "stop"
+	}
+	if final.Reason == StreamLatency {
+		resp.FinishReason = string(StreamLatency)
+	}
+	for k, v := range final.Metadata {
+		resp.SetMetadata(k, v)
+	}
+	return resp, nil
+}
+
+// ChatStream starts a stream that ends with reason StreamLatency if a budget
+// is exceeded. The terminal chunk then records the exceeded budget under
+// MetadataLatencyTruncated and the model that was serving the stream.
+func (p *LatencyBoundProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		firstToken := timerChan(p.budget.FirstToken)
+		total := timerChan(p.budget.Total)
+		var model string
+		truncated := func(phase string) StreamChunk {
+			final := StreamChunk{Done: true, Reason: StreamLatency, Model: model}
+			final.SetMetadata(MetadataLatencyTruncated, phase)
+			return final
+		}
+
+		for {
+			select {
+			case <-firstToken:
+				return truncated("first_token")
+			case <-total:
+				return truncated("total")
+			case chunk, ok := <-in:
+				if chunk = normalizeChunk(streamCtx, chunk, ok); chunk.Done {
+					return chunk
+				}
+				if chunk.Model != "" {
+					model = chunk.Model
+				}
+				firstToken = nil
+				emit(chunk)
+			case <-streamCtx.Done():
+				return StreamChunk{Done: true, Reason: ctxEndReason(streamCtx), Err: streamCtx.Err()}
+			}
+		}
+	}), nil
+}
+
+// timerChan returns a channel that fires after d, or nil (never fires) if d is not positive.
+func timerChan(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return time.After(d)
+}
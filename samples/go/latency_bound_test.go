@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatencyBoundProvider(t *testing.T) {
+	chunk := func(content string) StreamChunk { return StreamChunk{Content: content, Model: "m-2024"} }
+	terminal := StreamChunk{Done: true, Reason: StreamCompleted, Model: "m-2024", FinishReason: "length"}
+
+	tests := []struct {
+		name       string
+		delay      time.Duration
+		budget     LatencyBudget
+		wantReason string
+		wantPhase  any
+		wantModel  string
+	}{
+		{"within budget", time.Millisecond, LatencyBudget{FirstToken: time.Second, Total: time.Second}, "length", nil, "m-2024"},
+		{"slow first token", 200 * time.Millisecond, LatencyBudget{FirstToken: 20 * time.Millisecond}, string(StreamLatency), "first_token", "requested"},
+		{"slow overall", 30 * time.Millisecond, LatencyBudget{Total: 50 * time.Millisecond}, string(StreamLatency), "total", "m-2024"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := pacedStreamer(tt.delay, chunk("a"), chunk("b"), chunk("c"), terminal)
+			p := NewLatencyBoundProvider(inner, tt.budget)
+			resp, err := p.Chat(context.Background(), &ChatRequest{Model: "requested"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.FinishReason != tt.wantReason || resp.Model != tt.wantModel {
+				t.Errorf("finish reason %q, model %q; want %q, %q", resp.FinishReason, resp.Model, tt.wantReason, tt.wantModel)
+			}
+			if got := resp.Metadata[MetadataLatencyTruncated]; got != tt.wantPhase {
+				t.Errorf("truncated phase = %v, want %v", got, tt.wantPhase)
+			}
+		})
+	}
+}
+
+func TestLatencyBoundStreamReportsPhase(t *testing.T) {
+	inner := pacedStreamer(200*time.Millisecond, StreamChunk{Content: "late"})
+	p := NewLatencyBoundProvider(inner, LatencyBudget{FirstToken: 10 * time.Millisecond})
+	chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, final := collectStream(chunks)
+	if content != "" || final.Reason != StreamLatency || final.Metadata[MetadataLatencyTruncated] != "first_token" {
+		t.Errorf("content %q, final %+v; want a first_token truncation", content, final)
+	}
+}
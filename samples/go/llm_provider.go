@@ -7,6 +7,7 @@ package llm
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -18,29 +19,117 @@ var (
 	ErrRateLimited       = errors.New("rate limited")
 	ErrContextCanceled   = errors.New("context canceled")
 	ErrInvalidResponse   = errors.New("invalid response from provider")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrNoModels          = errors.New("provider exposes no models")
 )
 
 // Message represents a single message in a chat conversation.
 type Message struct {
-	Role    string `json:"role"`    // "system", "user", or "assistant"
-	Content string `json:"content"` // The message content
+	Role       string     `json:"role"`                   // "system", "user", "assistant", or "tool"
+	Content    string     `json:"content"`                // The message content
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // Tool calls requested by an assistant message
+	ToolCallID string     `json:"tool_call_id,omitempty"` // The call a "tool" message is the result of
 }
 
 // ChatRequest contains parameters for a chat completion request.
 type ChatRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float64   `json:"temperature,omitempty"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Model            string           `json:"model"`
+	Messages         []Message        `json:"messages"`
+	Temperature      float64          `json:"temperature,omitempty"`
+	MaxTokens        int              `json:"max_tokens,omitempty"`
+	TopP             float64          `json:"top_p,omitempty"`
+	FrequencyPenalty float64          `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64          `json:"presence_penalty,omitempty"`
+	N                int              `json:"n,omitempty"`            // Number of completions to generate; see DemuxChoices for streaming
+	Seed             *int64           `json:"seed,omitempty"`         // Requests reproducible sampling on providers that support it
+	Logprobs         bool             `json:"logprobs,omitempty"`     // Request per-token log probabilities
+	TopLogprobs      int              `json:"top_logprobs,omitempty"` // Number of alternatives to return per token
+	Tools            []ToolDefinition `json:"tools,omitempty"`
+	ResponseFormat   *ResponseFormat  `json:"response_format,omitempty"` // Constrains the output to JSON; see FormatDowngradeProvider
+
+	// PromptID references a prompt stored on the provider, used instead of
+	// inline Messages. PromptVariables fill the stored prompt's placeholders.
+	// Stored prompts need the /responses API; OpenAIProvider rejects them.
+	PromptID        string            `json:"-"`
+	PromptVariables map[string]string `json:"-"`
+
+	// ReasoningSummary asks reasoning models for a summary of their reasoning:
+	// ReasoningSummaryNone (the default), ReasoningSummaryConcise or
+	// ReasoningSummaryDetailed. Only the /responses API supports it;
+	// OpenAIProvider rejects it with ErrInvalidRequest.
+	ReasoningSummary string `json:"-"`
+
+	// StreamUsage asks a streaming request to report usage on its final chunk.
+	// OpenAIProvider requests usage on every stream unless configured with
+	// OmitStreamUsage, in which case only requests setting StreamUsage do.
+	StreamUsage bool `json:"-"`
+
+	// ModelFallbacks are alternative models, tried in order on the same
+	// provider by the registry when Model is unavailable or fails transiently.
+	ModelFallbacks []string `json:"-"`
+
+	// Grammar is a GBNF grammar the output must conform to. It is only sent to
+	// providers that declare Capabilities.Grammar; others reject the request
+	// with ErrGrammarNotSupported.
+	Grammar string `json:"-"`
+
+	// BuiltinTools enables tools the provider runs itself, such as web search.
+	// Providers that do not declare them in Capabilities.BuiltinTools reject
+	// the request with ErrBuiltinToolNotSupported.
+	BuiltinTools []BuiltinTool `json:"-"`
 }
 
 // ChatResponse contains the result of a chat completion.
 type ChatResponse struct {
-	Content      string        `json:"content"`
-	Model        string        `json:"model"`
-	FinishReason string        `json:"finish_reason"`
-	Usage        *UsageStats   `json:"usage,omitempty"`
-	Latency      time.Duration `json:"-"`
+	Content          string         `json:"content"`
+	Model            string         `json:"model"`                        // The model that served the request, as reported by the provider
+	RequestedModel   string         `json:"requested_model,omitempty"`    // The model named in the request, if it differs from Model
+	ModelVersion     string         `json:"model_version,omitempty"`      // The snapshot/version suffix parsed from Model
+	Fingerprint      string         `json:"system_fingerprint,omitempty"` // The backend configuration that served the request
+	FinishReason     string         `json:"finish_reason"`
+	ToolCalls        []ToolCall     `json:"tool_calls,omitempty"`
+	Citations        []Citation     `json:"citations,omitempty"`
+	ReasoningSummary string         `json:"reasoning_summary,omitempty"` // The model's summary of its reasoning, if requested
+	Usage            *UsageStats    `json:"usage,omitempty"`
+	Logprobs         []TokenLogprob `json:"logprobs,omitempty"`
+	Metadata         map[string]any `json:"metadata,omitempty"` // Annotations added by decorators
+	Latency          time.Duration  `json:"-"`
+}
+
+// SetMetadata records an annotation on the response, allocating Metadata if needed.
+func (r *ChatResponse) SetMetadata(key string, value any) {
+	if r.Metadata == nil {
+		r.Metadata = make(map[string]any)
+	}
+	r.Metadata[key] = value
+}
+
+// TokenLogprob is the log probability the model assigned to a generated token.
+// TopLogprobs holds the most likely alternatives at that position, most likely
+// first, when ChatRequest.TopLogprobs is set.
+type TokenLogprob struct {
+	Token       string             `json:"token"`
+	Logprob     float64            `json:"logprob"`
+	TopLogprobs []TokenAlternative `json:"top_logprobs,omitempty"`
+}
+
+// TokenAlternative is a candidate token the model considered at a position.
+type TokenAlternative struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+}
+
+// Citation is a source referenced by a response, such as a web page found by
+// a search-enabled model. StartIndex and EndIndex delimit, in runes, the part of
+// the content that cites it.
+type Citation struct {
+	Source     string `json:"source"` // The kind of source, e.g. "url_citation" or "file_citation"
+	Title      string `json:"title,omitempty"`
+	URL        string `json:"url,omitempty"`     // Set for web citations
+	FileID     string `json:"file_id,omitempty"` // Set for file citations
+	Text       string `json:"text,omitempty"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
 }
 
 // UsageStats tracks token usage for a request.
@@ -70,20 +159,69 @@ type ProviderRegistry struct {
 	mu        sync.RWMutex
 	providers map[string]Provider
 	defaultID string
+
+	prewarmTimeout time.Duration // Zero disables warming providers on Register
+
+	cooldown      time.Duration // Zero disables cool-downs
+	cooldownUntil map[string]time.Time
+
+	inFlight chan struct{} // Semaphore for WithMaxInFlight; nil means unlimited
+
+	qualityCheck QualityCheck // Nil accepts every response
+
+	requireModels bool // HealthCheck reports ErrNoModels for empty model lists
+
+	costTable CostTable // Prices fallback attempts for WithCostBreakdown; nil disables it
 }
 
+// RegistryOption configures a ProviderRegistry.
+type RegistryOption func(*ProviderRegistry)
+
 // NewProviderRegistry creates a new provider registry.
-func NewProviderRegistry() *ProviderRegistry {
-	return &ProviderRegistry{
+func NewProviderRegistry(opts ...RegistryOption) *ProviderRegistry {
+	r := &ProviderRegistry{
 		providers: make(map[string]Provider),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Register adds a provider to the registry.
 func (r *ProviderRegistry) Register(provider Provider) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	r.providers[provider.ID()] = provider
+	r.mu.Unlock()
+
+	r.prewarm(provider)
+}
+
+// prewarm warms a newly registered provider in the background, if WithPrewarm is set.
+func (r *ProviderRegistry) prewarm(provider Provider) {
+	if r.prewarmTimeout > 0 {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), r.prewarmTimeout)
+			defer cancel()
+			warm(ctx, provider)
+		}()
+	}
+}
+
+// Deregister removes a provider from the registry. Removing the default
+// provider clears the default.
+func (r *ProviderRegistry) Deregister(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[id]; !ok {
+		return ErrProviderNotFound
+	}
+	delete(r.providers, id)
+	if r.defaultID == id {
+		r.defaultID = ""
+	}
+	return nil
 }
 
 // SetDefault sets the default provider by ID.
@@ -123,16 +261,39 @@ func (r *ProviderRegistry) GetDefault() (Provider, error) {
 
 // Chat sends a request to the default provider.
 func (r *ProviderRegistry) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
 	provider, err := r.GetDefault()
 	if err != nil {
 		return nil, err
 	}
-	return provider.Chat(ctx, req)
+
+	release, err := r.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, _, err := chatWithModelFallbacks(ctx, provider, req, nil)
+	return resp, err
 }
 
 // ChatWithFallback tries multiple providers in order until one succeeds.
 func (r *ProviderRegistry) ChatWithFallback(ctx context.Context, req *ChatRequest, providerIDs []string) (*ChatResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// One slot covers the whole chain: its providers are called one at a time.
+	release, err := r.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	var lastErr error
+	var costs CostBreakdown
 
 	for _, id := range providerIDs {
 		provider, err := r.Get(id)
@@ -140,23 +301,77 @@ func (r *ProviderRegistry) ChatWithFallback(ctx context.Context, req *ChatReques
 			lastErr = err
 			continue
 		}
+		if r.CoolingDown(id) {
+			lastErr = fmt.Errorf("%w: %s", ErrProviderCoolingDown, id)
+			continue
+		}
 
-		resp, err := provider.Chat(ctx, req)
+		var failed func(model string, err error)
+		if r.costTable != nil {
+			failed = func(model string, err error) { costs.add(r.costTable, id, model, nil, err) }
+		}
+		resp, model, err := chatWithModelFallbacks(ctx, provider, req, failed)
 		if err == nil {
-			return resp, nil
+			ok, reason := true, ""
+			if r.qualityCheck != nil {
+				ok, reason = r.qualityCheck(req, resp)
+			}
+			if ok {
+				if r.costTable != nil {
+					costs.add(r.costTable, id, model, resp, nil)
+					resp.SetMetadata(MetadataCostBreakdown, costs)
+				}
+				return resp, nil
+			}
+			lastErr = &LowQualityError{Provider: id, Reason: reason}
+			if r.costTable != nil {
+				costs.add(r.costTable, id, model, resp, lastErr)
+			}
+			continue
 		}
 		lastErr = err
+		r.startCooldown(id, err)
 
 		// Don't try other providers if context was canceled
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-			return nil, ErrContextCanceled
+			return nil, r.fallbackError(ErrContextCanceled, costs)
 		}
 	}
 
 	if lastErr != nil {
-		return nil, lastErr
+		return nil, r.fallbackError(lastErr, costs)
 	}
-	return nil, ErrProviderNotFound
+	return nil, r.fallbackError(ErrProviderNotFound, costs)
+}
+
+// chatWithModelFallbacks sends req to provider, retrying with each of
+// req.ModelFallbacks in turn while the model is unavailable or the failure is
+// transient. The response's RequestedModel records the original model. It
+// returns the model of the last attempt, and calls failed, if not nil, with
+// the model and error of each failed attempt.
+func chatWithModelFallbacks(ctx context.Context, provider Provider, req *ChatRequest, failed func(model string, err error)) (*ChatResponse, string, error) {
+	model := req.Model
+	resp, err := provider.Chat(ctx, req)
+	for _, fallback := range req.ModelFallbacks {
+		if err == nil || !(errors.Is(err, ErrModelNotAvailable) || IsTransient(err)) {
+			break
+		}
+		if failed != nil {
+			failed(model, err)
+		}
+		next := *req
+		next.Model = fallback
+		next.ModelFallbacks = nil
+		model = fallback
+		resp, err = provider.Chat(ctx, &next)
+		if err == nil && resp.RequestedModel == "" {
+			resp.RequestedModel = req.Model
+		}
+	}
+	if err != nil && failed != nil {
+		failed(model, err)
+	}
+	return resp, model, err
 }
 
 // ListProviders returns IDs of all registered providers.
@@ -171,7 +386,18 @@ func (r *ProviderRegistry) ListProviders() []string {
 	return ids
 }
 
-// HealthCheck verifies all providers are operational.
+// WithRequireModels makes HealthCheck report ErrNoModels for providers whose
+// model list is empty, catching providers that authenticate but have no
+// usable models, such as a misconfigured deployment.
+func WithRequireModels() RegistryOption {
+	return func(r *ProviderRegistry) {
+		r.requireModels = true
+	}
+}
+
+// HealthCheck verifies all providers are operational. A provider is healthy
+// if it lists its models without error and, with WithRequireModels, lists at
+// least one.
 func (r *ProviderRegistry) HealthCheck(ctx context.Context) map[string]error {
 	r.mu.RLock()
 	providers := make(map[string]Provider, len(r.providers))
@@ -189,7 +415,10 @@ func (r *ProviderRegistry) HealthCheck(ctx context.Context) map[string]error {
 		go func(id string, p Provider) {
 			defer wg.Done()
 
-			_, err := p.ListModels(ctx)
+			models, err := p.ListModels(ctx)
+			if err == nil && len(models) == 0 && r.requireModels {
+				err = fmt.Errorf("%w: %s", ErrNoModels, id)
+			}
 
 			mu.Lock()
 			results[id] = err
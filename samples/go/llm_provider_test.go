@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRegistryModelFallbacks(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      map[string]error // Error returned per model
+		wantModels    []string         // Models tried, in order
+		wantErr       error
+		wantRequested string
+	}{
+		{"primary succeeds", nil, []string{"a"}, nil, ""},
+		{"unavailable model falls back", map[string]error{"a": ErrModelNotAvailable}, []string{"a", "b"}, nil, "a"},
+		{"transient failure falls back", map[string]error{"a": ErrRateLimited, "b": ErrRateLimited}, []string{"a", "b", "c"}, nil, "a"},
+		{"permanent failure stops", map[string]error{"a": ErrInvalidRequest}, []string{"a"}, ErrInvalidRequest, ""},
+		{"every model fails", map[string]error{"a": ErrRateLimited, "b": ErrModelNotAvailable, "c": ErrRateLimited}, []string{"a", "b", "c"}, ErrRateLimited, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &fakeProvider{id: "p", chat: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+				if err := tt.failures[req.Model]; err != nil {
+					return nil, err
+				}
+				return &ChatResponse{Content: "ok", Model: req.Model}, nil
+			}}
+			r := NewProviderRegistry()
+			r.Register(p)
+			if err := r.SetDefault("p"); err != nil {
+				t.Fatal(err)
+			}
+			resp, err := r.Chat(context.Background(), &ChatRequest{Model: "a", Messages: userMessages("hi"), ModelFallbacks: []string{"b", "c"}})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			var tried []string
+			for _, req := range p.requests() {
+				tried = append(tried, req.Model)
+				if req.Model != "a" && req.ModelFallbacks != nil {
+					t.Errorf("fallback request for %s still carries fallbacks %v", req.Model, req.ModelFallbacks)
+				}
+			}
+			if !slices.Equal(tried, tt.wantModels) {
+				t.Errorf("tried %v, want %v", tried, tt.wantModels)
+			}
+			if err == nil && resp.RequestedModel != tt.wantRequested {
+				t.Errorf("RequestedModel = %q, want %q", resp.RequestedModel, tt.wantRequested)
+			}
+		})
+	}
+}
+
+func TestRegistryHealthCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		requireModels bool
+		want          map[string]bool // Provider ID to whether it is healthy
+	}{
+		{name: "empty lists allowed", want: map[string]bool{"full": true, "empty": true, "down": false}},
+		{name: "empty lists required", requireModels: true, want: map[string]bool{"full": true, "empty": false, "down": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []RegistryOption
+			if tt.requireModels {
+				opts = append(opts, WithRequireModels())
+			}
+			r := NewProviderRegistry(opts...)
+			r.Register(&fakeProvider{id: "full", models: []string{"m"}})
+			r.Register(&fakeProvider{id: "empty", models: []string{}})
+			r.Register(unreachableProvider{&fakeProvider{id: "down"}})
+
+			results := r.HealthCheck(context.Background())
+			if len(results) != len(tt.want) {
+				t.Fatalf("results = %v, want %d providers", results, len(tt.want))
+			}
+			for id, healthy := range tt.want {
+				if err := results[id]; (err == nil) != healthy {
+					t.Errorf("%s: err = %v, want healthy %v", id, err, healthy)
+				}
+			}
+			if tt.requireModels && !errors.Is(results["empty"], ErrNoModels) {
+				t.Errorf("empty: err = %v, want %v", results["empty"], ErrNoModels)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package llm
+
+// openAILogprobs is the logprobs object of an OpenAI chat completion choice.
+// Its content entries decode directly into TokenLogprob, whose JSON tags follow
+// the OpenAI format, as do ChatRequest's Logprobs and TopLogprobs.
+type openAILogprobs struct {
+	Content []TokenLogprob `json:"content"`
+}
@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// logprobProvider returns a fixed set of logprobs and records the request it was sent.
+type logprobProvider struct {
+	logprobs []TokenLogprob
+	got      *ChatRequest
+}
+
+func (p *logprobProvider) ID() string { return "logprobs" }
+
+func (p *logprobProvider) Chat(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+	p.got = req
+	return &ChatResponse{Content: "ok", Logprobs: p.logprobs}, nil
+}
+
+func (p *logprobProvider) IsModelAvailable(context.Context, string) (bool, error) { return true, nil }
+
+func (p *logprobProvider) ListModels(context.Context) ([]string, error) { return nil, nil }
+
+func TestLogprobsRequestSerialization(t *testing.T) {
+	tests := []struct {
+		name string
+		req  ChatRequest
+		want []string
+		omit []string
+	}{
+		{"enabled", ChatRequest{Model: "m", Logprobs: true, TopLogprobs: 3}, []string{`"logprobs":true`, `"top_logprobs":3`}, nil},
+		{"disabled", ChatRequest{Model: "m"}, nil, []string{`"logprobs"`, `"top_logprobs"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("%s missing %s", data, s)
+				}
+			}
+			for _, s := range tt.omit {
+				if strings.Contains(string(data), s) {
+					t.Errorf("%s should not contain %s", data, s)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOpenAILogprobs(t *testing.T) {
+	raw := `{"content":[{"token":"Yes","logprob":-0.01,"bytes":[89,101,115]},{"token":".","logprob":-1.5,"bytes":[46]}]}`
+	var wire openAILogprobs
+	if err := json.Unmarshal([]byte(raw), &wire); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		token   string
+		logprob float64
+	}{{"Yes", -0.01}, {".", -1.5}}
+	if len(wire.Content) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(wire.Content), len(want))
+	}
+	for i, w := range want {
+		if wire.Content[i].Token != w.token || wire.Content[i].Logprob != w.logprob {
+			t.Errorf("token %d = %+v, want %s %v", i, wire.Content[i], w.token, w.logprob)
+		}
+	}
+}
+
+func TestConfidenceProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		logprobs []TokenLogprob
+		min      float64
+		wantErr  bool
+	}{
+		{"confident", []TokenLogprob{{Token: "a", Logprob: math.Log(0.9)}, {Token: "b", Logprob: math.Log(0.9)}}, 0.8, false},
+		{"below threshold", []TokenLogprob{{Token: "a", Logprob: math.Log(0.9)}, {Token: "b", Logprob: math.Log(0.1)}}, 0.5, true},
+		{"at threshold", []TokenLogprob{{Token: "a", Logprob: math.Log(0.5)}}, 0.5, false},
+		{"no logprobs", nil, 0.1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &logprobProvider{logprobs: tt.logprobs}
+			_, err := NewConfidenceProvider(inner, tt.min).Chat(context.Background(), &ChatRequest{Model: "m"})
+			if got := errors.Is(err, ErrLowConfidence); got != tt.wantErr {
+				t.Fatalf("err = %v, want low confidence %v", err, tt.wantErr)
+			}
+			if !inner.got.Logprobs {
+				t.Error("logprobs were not requested")
+			}
+		})
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		probs []float64
+		want  float64
+	}{
+		{nil, 0},
+		{[]float64{0.5, 0.5}, 0.5},
+		{[]float64{1, 1, 1}, 1},
+		{[]float64{0.9, 0.1}, 0.3}, // Geometric, not arithmetic, mean
+		{[]float64{0.8}, 0.8},
+	}
+	for _, tt := range tests {
+		logprobs := make([]TokenLogprob, len(tt.probs))
+		for i, p := range tt.probs {
+			logprobs[i] = TokenLogprob{Logprob: math.Log(p)}
+		}
+		if got := Confidence(logprobs); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Confidence(%v) = %v, want %v", tt.probs, got, tt.want)
+		}
+	}
+}
+
+func TestParseOpenAITopLogprobs(t *testing.T) {
+	raw := `{"content":[{"token":"Yes","logprob":-0.1,"top_logprobs":[{"token":"Yes","logprob":-0.1},{"token":"No","logprob":-2.4}]}]}`
+	var wire openAILogprobs
+	if err := json.Unmarshal([]byte(raw), &wire); err != nil {
+		t.Fatal(err)
+	}
+	want := []TokenAlternative{{Token: "Yes", Logprob: -0.1}, {Token: "No", Logprob: -2.4}}
+	if len(wire.Content) != 1 || !slices.Equal(wire.Content[0].TopLogprobs, want) {
+		t.Errorf("top logprobs = %+v, want %+v", wire.Content, want)
+	}
+}
+
+func TestLowConfidenceTokens(t *testing.T) {
+	logprobs := []TokenLogprob{{Logprob: math.Log(0.9)}, {Logprob: math.Log(0.2)}, {Logprob: math.Log(0.5)}, {Logprob: math.Log(0.05)}}
+	tests := []struct {
+		min  float64
+		want []int
+	}{
+		{0.01, nil},
+		{0.3, []int{1, 3}},
+		{0.6, []int{1, 2, 3}},
+		{1, []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		if got := LowConfidenceTokens(logprobs, tt.min); !slices.Equal(got, tt.want) {
+			t.Errorf("LowConfidenceTokens(%v) = %v, want %v", tt.min, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package llm
+
+import "strings"
+
+// maxMarkdownHold bounds how much of an unterminated link or code span is held
+// back waiting for its end before it is emitted as literal text.
+const maxMarkdownHold = 512
+
+// StripMarkdownStream converts the streamed markdown content of in to
+// plaintext, for channels such as SMS or logs that cannot render it. Headers,
+// emphasis, blockquote markers and code fences are removed, links are
+// replaced by their text, and bullets are normalized to "- ". Text that could
+// still turn out to be markup is held until it resolves. The terminal chunk is
+// forwarded last. Consumers must drain the channel.
+func StripMarkdownStream(in <-chan StreamChunk) <-chan StreamChunk {
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+
+		var stripper MarkdownStripper
+		for chunk := range in {
+			if chunk.Done {
+				if text := stripper.Flush(); text != "" {
+					out <- StreamChunk{Content: text}
+				}
+				out <- chunk
+				continue
+			}
+			if text := stripper.Feed(chunk.Content); text != "" {
+				out <- StreamChunk{Index: chunk.Index, Content: text}
+			}
+		}
+	}()
+	return out
+}
+
+// MarkdownStripper is the state machine behind StripMarkdownStream, for
+// consumers that receive content some other way. The zero value is ready to use.
+type MarkdownStripper struct {
+	fences  FenceTracker
+	pending string // Text outside code blocks not yet converted
+	midLine bool   // The current line's prefix has been handled
+	last    byte   // The last byte written, for word-boundary checks
+}
+
+// Feed consumes the next piece of markdown and returns the plaintext that can
+// be emitted so far.
+func (m *MarkdownStripper) Feed(content string) string {
+	var out strings.Builder
+	for _, seg := range m.fences.Feed(content) {
+		m.segment(&out, seg)
+	}
+	m.convert(&out, false)
+	return out.String()
+}
+
+// Flush returns any held text at the end of the stream.
+func (m *MarkdownStripper) Flush() string {
+	var out strings.Builder
+	for _, seg := range m.fences.Flush() {
+		m.segment(&out, seg)
+	}
+	m.convert(&out, true)
+	m.midLine = false
+	return out.String()
+}
+
+// segment handles one fence-annotated segment. Code is passed through as is,
+// without its fence lines.
+func (m *MarkdownStripper) segment(out *strings.Builder, seg FencedChunk) {
+	if !seg.InCode {
+		m.pending += seg.Content
+		m.convert(out, false)
+		return
+	}
+	m.convert(out, true)
+	switch seg.Boundary {
+	case FenceNone:
+		m.write(out, seg.Content)
+	case FenceClose:
+		m.midLine = false
+	}
+}
+
+// convert writes the plaintext of pending up to the first construct that may
+// still be incomplete, or all of it if final.
+func (m *MarkdownStripper) convert(out *strings.Builder, final bool) {
+	for m.pending != "" {
+		if !m.midLine {
+			line, _, complete := cutLine(m.pending)
+			if !complete && !final && !lineDecided(line) {
+				return
+			}
+			if isRuleLine(line) {
+				m.pending = m.pending[len(line):]
+				if complete {
+					m.write(out, "\n")
+				}
+				continue
+			}
+			n, prefix := linePrefix(line)
+			m.write(out, prefix)
+			m.pending = m.pending[n:]
+			m.midLine = true
+		}
+
+		line, _, complete := cutLine(m.pending)
+		text := strings.TrimSuffix(line, "\n")
+		n := m.inline(out, text, complete || final)
+		m.pending = m.pending[n:]
+		if n < len(text) || !complete {
+			return
+		}
+		m.write(out, "\n")
+		m.pending = m.pending[1:]
+		m.midLine = false
+	}
+}
+
+// inline writes the plaintext of s, which holds no newline, and returns how
+// many bytes were consumed. Unless complete, it stops before markup that the
+// rest of the line could still change.
+func (m *MarkdownStripper) inline(out *strings.Builder, s string, complete bool) int {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch c {
+		case '\\':
+			if i+1 == len(s) && !complete {
+				return i
+			}
+			if i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				m.write(out, s[i+1:i+2])
+				i += 2
+				continue
+			}
+		case '*', '_', '~':
+			j := i
+			for j < len(s) && s[j] == c {
+				j++
+			}
+			if j == len(s) && !complete {
+				return i
+			}
+			if emphasisMarker(c, j-i, m.last, s[j:]) {
+				i = j
+				continue
+			}
+			m.write(out, s[i:j])
+			i = j
+			continue
+		case '`':
+			j := i
+			for j < len(s) && s[j] == '`' {
+				j++
+			}
+			fence := s[i:j]
+			if end := strings.Index(s[j:], fence); end >= 0 {
+				m.write(out, s[j:j+end])
+				i = j + end + len(fence)
+				continue
+			}
+			if !complete && len(s)-i < maxMarkdownHold {
+				return i
+			}
+		case '!', '[':
+			start := i
+			if c == '!' {
+				if i+1 == len(s) && !complete {
+					return i
+				}
+				if i+1 == len(s) || s[i+1] != '[' {
+					break
+				}
+				start++
+			}
+			text, end, ok := parseLink(s, start, complete)
+			if !ok && end < 0 {
+				return i
+			}
+			if ok {
+				sub := MarkdownStripper{last: m.last}
+				sub.inline(out, text, true)
+				m.last = sub.last
+				i = end
+				continue
+			}
+		}
+		m.write(out, s[i:i+1])
+		i++
+	}
+	return i
+}
+
+func (m *MarkdownStripper) write(out *strings.Builder, s string) {
+	if s != "" {
+		out.WriteString(s)
+		m.last = s[len(s)-1]
+	}
+}
+
+// parseLink parses an inline link "[text](url)" starting at s[i]. It returns
+// the link text and the index after it; ok is false for a literal bracket,
+// and end is negative if the link may still be completed by more content.
+func parseLink(s string, i int, complete bool) (text string, end int, ok bool) {
+	more := !complete && len(s)-i < maxMarkdownHold
+	close := strings.IndexByte(s[i+1:], ']')
+	if close < 0 {
+		return "", holdOr(more), false
+	}
+	j := i + 1 + close
+	if j+1 == len(s) {
+		return "", holdOr(more), false
+	}
+	if s[j+1] != '(' {
+		return "", 0, false
+	}
+	paren := strings.IndexByte(s[j+2:], ')')
+	if paren < 0 {
+		return "", holdOr(more), false
+	}
+	return s[i+1 : j], j + 2 + paren + 1, true
+}
+
+func holdOr(more bool) int {
+	if more {
+		return -1
+	}
+	return 0
+}
+
+// emphasisMarker reports whether a run of n emphasis characters c, preceded
+// by prev and followed by rest, is markup rather than text. Asterisks between
+// spaces, underscores inside words and single tildes are text.
+func emphasisMarker(c byte, n int, prev byte, rest string) bool {
+	var next byte = ' '
+	if rest != "" {
+		next = rest[0]
+	}
+	switch c {
+	case '*':
+		return !(isSpaceOrStart(prev) && next == ' ')
+	case '_':
+		return !(isWordByte(prev) && isWordByte(next))
+	default:
+		return n >= 2
+	}
+}
+
+// lineDecided reports whether enough of a line has arrived to tell which
+// block markup, if any, it starts with.
+func lineDecided(line string) bool {
+	return strings.TrimLeft(line, " \t#>*+-_=") != ""
+}
+
+// isRuleLine reports whether line is a thematic break such as "---", or a
+// setext heading underline.
+func isRuleLine(line string) bool {
+	t := strings.TrimSpace(line)
+	if len(t) < 3 || strings.Count(t, t[:1]) < 3 || !strings.Contains("-*_=", t[:1]) {
+		return false
+	}
+	return strings.Trim(t, t[:1]+" ") == ""
+}
+
+// linePrefix returns the length of the block markup at the start of line and
+// its plaintext replacement: indentation is kept, blockquote and header
+// markers are dropped and bullets become "- ".
+func linePrefix(line string) (int, string) {
+	i := len(line) - len(strings.TrimLeft(line, " \t"))
+	indent := line[:i]
+
+	for i < len(line) && line[i] == '>' {
+		i++
+		if i < len(line) && line[i] == ' ' {
+			i++
+		}
+	}
+	if h := len(line[i:]) - len(strings.TrimLeft(line[i:], "#")); h > 0 && h <= 6 &&
+		(i+h == len(line) || line[i+h] == ' ' || line[i+h] == '\n') {
+		i += h
+		i += len(line[i:]) - len(strings.TrimLeft(line[i:], " "))
+		return i, indent
+	}
+	if i+1 < len(line) && strings.IndexByte("-*+", line[i]) >= 0 && line[i+1] == ' ' {
+		return i + 2, indent + "- "
+	}
+	return i, indent
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < 0x80 && strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isSpaceOrStart(c byte) bool {
+	return c == 0 || c == ' ' || c == '\t' || c == '\n'
+}
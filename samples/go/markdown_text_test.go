@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownStripper(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"headers and emphasis", "# Title\nSome **bold** and _it_ text.\n", "Title\nSome bold and it text.\n"},
+		{"bullets", "* one\n+ two\n- three\n", "- one\n- two\n- three\n"},
+		{"blockquote and code span", "> quoted `code` here\n", "quoted code here\n"},
+		{"links and images", "See [the docs](http://x) and ![img](y).\n", "See the docs and img.\n"},
+		{"code fence", "```go\nx := *p\n```\nafter\n", "x := *p\nafter\n"},
+		{"literal markers", "snake_case_name and 2 * 3 * 4\n", "snake_case_name and 2 * 3 * 4\n"},
+		{"thematic break", "a\n---\nb", "a\n\nb"},
+		{"escapes", `\*not emphasis\*`, "*not emphasis*"},
+		{"strikethrough", "~~gone~~ ~single~", "gone ~single~"},
+		{"unterminated link", "[open bracket", "[open bracket"},
+		{"unterminated code span", "a `b", "a `b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var whole MarkdownStripper
+			if got := whole.Feed(tt.in) + whole.Flush(); got != tt.want {
+				t.Errorf("whole = %q, want %q", got, tt.want)
+			}
+
+			var bytewise MarkdownStripper
+			var b strings.Builder
+			for i := range len(tt.in) {
+				b.WriteString(bytewise.Feed(tt.in[i : i+1]))
+			}
+			b.WriteString(bytewise.Flush())
+			if got := b.String(); got != tt.want {
+				t.Errorf("byte by byte = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripMarkdownStream(t *testing.T) {
+	in := streamOf(StreamChunk{Content: "## He"}, StreamChunk{Content: "llo **wor"}, StreamChunk{Content: "ld**"}, StreamChunk{Done: true, Reason: StreamTokenCap})
+	content, final := collectStream(StripMarkdownStream(in))
+	if content != "Hello world" || final.Reason != StreamTokenCap {
+		t.Errorf("got %q ending %q, want %q ending %q", content, final.Reason, "Hello world", StreamTokenCap)
+	}
+}
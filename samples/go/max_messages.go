@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyMessages is returned for requests with more messages than allowed.
+var ErrTooManyMessages = errors.New("request exceeds maximum message count")
+
+// MaxMessagesProvider wraps a Provider and rejects requests with more than a
+// maximum number of messages of any role. Unlike TurnLimitProvider it only
+// checks the slice length, so it is cheap enough to run before token counting
+// or any other per-message work, and should be placed outermost.
+type MaxMessagesProvider struct {
+	Provider
+	max int
+}
+
+// NewMaxMessagesProvider creates a provider that allows at most max messages per request.
+func NewMaxMessagesProvider(inner Provider, max int) *MaxMessagesProvider {
+	return &MaxMessagesProvider{Provider: inner, max: max}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *MaxMessagesProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat rejects the request if it has too many messages, otherwise forwards it.
+func (p *MaxMessagesProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := p.check(req); err != nil {
+		return nil, err
+	}
+	return p.Provider.Chat(ctx, req)
+}
+
+// ChatStream rejects the request if it has too many messages, otherwise starts a stream.
+func (p *MaxMessagesProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	inner, err := streamingInner(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.check(req); err != nil {
+		return nil, err
+	}
+	return inner.ChatStream(ctx, req)
+}
+
+func (p *MaxMessagesProvider) check(req *ChatRequest) error {
+	if n := len(req.Messages); n > p.max {
+		return fmt.Errorf("%w: %d > %d", ErrTooManyMessages, n, p.max)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMaxMessagesProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages int
+		wantErr  error
+	}{
+		{name: "empty", messages: 0},
+		{name: "at limit", messages: 3},
+		{name: "over limit", messages: 4, wantErr: ErrTooManyMessages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ChatRequest{Messages: make([]Message, tt.messages)}
+			for i := range req.Messages {
+				req.Messages[i] = Message{Role: "user", Content: "hi"}
+			}
+
+			inner := streamingReply("ok")
+			p := NewMaxMessagesProvider(inner, 3)
+			if _, err := p.Chat(context.Background(), req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Chat err = %v, want %v", err, tt.wantErr)
+			}
+			chunks, err := p.ChatStream(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChatStream err = %v, want %v", err, tt.wantErr)
+			}
+			if chunks != nil {
+				collectStream(chunks)
+			}
+			want := 2 // One Chat and one ChatStream
+			if tt.wantErr != nil {
+				want = 0
+			}
+			if n := len(inner.requests()); n != want {
+				t.Errorf("inner saw %d requests, want %d", n, want)
+			}
+		})
+	}
+
+	t.Run("not streaming", func(t *testing.T) {
+		_, err := NewMaxMessagesProvider(&fakeProvider{}, 3).ChatStream(context.Background(), &ChatRequest{})
+		if !errors.Is(err, ErrStreamingNotSupported) {
+			t.Errorf("err = %v, want %v", err, ErrStreamingNotSupported)
+		}
+	})
+}
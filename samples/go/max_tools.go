@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"unicode"
+)
+
+// ErrTooManyTools is returned in strict mode for requests declaring more
+// tools than the configured maximum.
+var ErrTooManyTools = errors.New("too many tools in request")
+
+// MetadataPrunedTools lists the names of the tools a MaxToolsProvider removed.
+const MetadataPrunedTools = "pruned_tools"
+
+// ToolSelector picks the n tools of req to keep, most relevant first.
+type ToolSelector func(req *ChatRequest, n int) []ToolDefinition
+
+// MaxToolsProvider wraps a Provider and limits the number of tools per
+// request, since some backends degrade or fail with large tool sets. In strict
+// mode requests over the limit are rejected with ErrTooManyTools; otherwise
+// the selector prunes them to the most relevant tools.
+type MaxToolsProvider struct {
+	Provider
+	max      int
+	strict   bool
+	selector ToolSelector
+}
+
+// NewMaxToolsProvider creates a provider that allows at most max tools per
+// request. selector defaults to RelevantTools.
+func NewMaxToolsProvider(inner Provider, max int, strict bool, selector ToolSelector) *MaxToolsProvider {
+	if selector == nil {
+		selector = RelevantTools
+	}
+	return &MaxToolsProvider{Provider: inner, max: max, strict: strict, selector: selector}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *MaxToolsProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat enforces the tool limit and forwards the request.
+func (p *MaxToolsProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if len(req.Tools) <= p.max {
+		return p.Provider.Chat(ctx, req)
+	}
+	if p.strict {
+		return nil, fmt.Errorf("%w: %d tools, limit %d", ErrTooManyTools, len(req.Tools), p.max)
+	}
+
+	pruned := *req
+	pruned.Tools = p.selector(req, p.max)
+	if len(pruned.Tools) > p.max {
+		pruned.Tools = pruned.Tools[:p.max]
+	}
+	resp, err := p.Provider.Chat(ctx, &pruned)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, t := range req.Tools {
+		if indexOfTool(pruned.Tools, t.Name) < 0 {
+			removed = append(removed, t.Name)
+		}
+	}
+	resp.SetMetadata(MetadataPrunedTools, removed)
+	return resp, nil
+}
+
+// RelevantTools is the default ToolSelector. Tools the conversation has
+// already called come first; the rest are ranked by how many words of their
+// name and description appear in the latest user message, ties keeping
+// declaration order.
+func RelevantTools(req *ChatRequest, n int) []ToolDefinition {
+	called := make(map[string]bool)
+	query := ""
+	for _, m := range req.Messages {
+		for _, call := range m.ToolCalls {
+			called[call.Name] = true
+		}
+		if m.Role == "user" {
+			query = m.Content
+		}
+	}
+	words := make(map[string]bool)
+	for _, w := range toolWords(query) {
+		words[w] = true
+	}
+
+	score := func(t ToolDefinition) int {
+		s := 0
+		for _, w := range toolWords(t.Name + " " + t.Description) {
+			if words[w] {
+				s++
+			}
+		}
+		return s
+	}
+
+	ranked := slices.Clone(req.Tools)
+	slices.SortStableFunc(ranked, func(a, b ToolDefinition) int {
+		if called[a.Name] != called[b.Name] {
+			if called[a.Name] {
+				return -1
+			}
+			return 1
+		}
+		return score(b) - score(a)
+	})
+	return ranked[:min(n, len(ranked))]
+}
+
+// toolWords splits s into lowercase words, treating underscores and case
+// changes in identifiers such as get_weather or getWeather as breaks.
+func toolWords(s string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 2 {
+			words = append(words, string(word))
+		}
+		word = word[:0]
+	}
+	prev := ' '
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			flush()
+			word = append(word, unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, unicode.ToLower(r))
+		default:
+			flush()
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
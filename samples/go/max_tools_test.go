@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestToolWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"get_weather", []string{"get", "weather"}},
+		{"getWeather", []string{"get", "weather"}},
+		{"Send an e-mail to Bob", []string{"send", "mail", "bob"}},
+		{"HTTPRequest v2", []string{"httprequest"}},
+	}
+	for _, tt := range tests {
+		if got := toolWords(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("toolWords(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRelevantTools(t *testing.T) {
+	tools := []ToolDefinition{
+		{Name: "send_email", Description: "Send an email"},
+		{Name: "get_weather", Description: "Current weather for a city"},
+		{Name: "search_flights", Description: "Find flights between cities"},
+	}
+	tests := []struct {
+		name     string
+		messages []Message
+		n        int
+		want     []string
+	}{
+		{name: "ranked by the latest user message", messages: userMessages("book flights", "what is the weather in Paris"), n: 2, want: []string{"get_weather", "send_email"}},
+		{name: "ties keep declaration order", messages: userMessages("hello"), n: 2, want: []string{"send_email", "get_weather"}},
+		{
+			name:     "called tools first",
+			messages: []Message{{Role: "assistant", ToolCalls: []ToolCall{{Name: "search_flights"}}}, {Role: "user", Content: "weather?"}},
+			n:        2,
+			want:     []string{"search_flights", "get_weather"},
+		},
+		{name: "n beyond the tool count", messages: userMessages("x"), n: 5, want: []string{"send_email", "get_weather", "search_flights"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tool := range RelevantTools(&ChatRequest{Tools: tools, Messages: tt.messages}, tt.n) {
+				got = append(got, tool.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("tools = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxToolsProvider(t *testing.T) {
+	tools := []ToolDefinition{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	first := func(req *ChatRequest, n int) []ToolDefinition { return req.Tools[:n] }
+	greedy := func(req *ChatRequest, _ int) []ToolDefinition { return req.Tools }
+
+	tests := []struct {
+		name       string
+		max        int
+		strict     bool
+		selector   ToolSelector
+		wantSent   []string
+		wantPruned any
+		wantErr    error
+	}{
+		{name: "within the limit", max: 3, selector: first, wantSent: []string{"a", "b", "c"}},
+		{name: "rejected in strict mode", max: 2, strict: true, wantErr: ErrTooManyTools},
+		{name: "pruned", max: 2, selector: first, wantSent: []string{"a", "b"}, wantPruned: []string{"c"}},
+		{name: "selector over the limit is cut", max: 1, selector: greedy, wantSent: []string{"a"}, wantPruned: []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			p := NewMaxToolsProvider(inner, tt.max, tt.strict, tt.selector)
+			resp, err := p.Chat(context.Background(), &ChatRequest{Tools: tools})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			var sent []string
+			for _, tool := range inner.requests()[0].Tools {
+				sent = append(sent, tool.Name)
+			}
+			if !slices.Equal(sent, tt.wantSent) {
+				t.Errorf("sent tools = %v, want %v", sent, tt.wantSent)
+			}
+			if got := resp.Metadata[MetadataPrunedTools]; !reflect.DeepEqual(got, tt.wantPruned) {
+				t.Errorf("pruned = %v, want %v", got, tt.wantPruned)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MergeStrategy selects how MergeResponses combines responses.
+type MergeStrategy int
+
+const (
+	// MergeConcatenate joins the responses in order, for continuations of a
+	// truncated completion. The finish reason is that of the last response.
+	MergeConcatenate MergeStrategy = iota
+	// MergeFirst keeps the first response's content, for ensembles that race providers.
+	MergeFirst
+	// MergeLongest keeps the response with the most content.
+	MergeLongest
+)
+
+// MergeResponses combines responses according to strategy. Usage is always
+// summed across every response, since all of them were paid for. Nil
+// responses are ignored.
+func MergeResponses(responses []*ChatResponse, strategy MergeStrategy) (*ChatResponse, error) {
+	var rs []*ChatResponse
+	for _, r := range responses {
+		if r != nil {
+			rs = append(rs, r)
+		}
+	}
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("%w: no responses to merge", ErrInvalidRequest)
+	}
+
+	var merged *ChatResponse
+	switch strategy {
+	case MergeConcatenate:
+		merged = concatenateResponses(rs)
+	case MergeFirst:
+		merged = cloneResponse(rs[0])
+	case MergeLongest:
+		longest := rs[0]
+		for _, r := range rs[1:] {
+			if len(r.Content) > len(longest.Content) {
+				longest = r
+			}
+		}
+		merged = cloneResponse(longest)
+	default:
+		return nil, fmt.Errorf("%w: unknown merge strategy %d", ErrInvalidRequest, strategy)
+	}
+
+	merged.Usage = sumUsage(rs)
+	if strategy != MergeConcatenate {
+		// Ensembles run concurrently, so the merged latency is the slowest call.
+		for _, r := range rs {
+			merged.Latency = max(merged.Latency, r.Latency)
+		}
+	}
+	return merged, nil
+}
+
+// concatenateResponses joins rs in order, shifting citation spans to match the
+// combined content.
+func concatenateResponses(rs []*ChatResponse) *ChatResponse {
+	last := rs[len(rs)-1]
+	merged := cloneResponse(last)
+	merged.ToolCalls, merged.Citations, merged.Logprobs = nil, nil, nil
+	merged.Latency = 0
+
+	var content strings.Builder
+	for _, r := range rs {
+		offset := utf8.RuneCountInString(content.String())
+		for _, c := range r.Citations {
+			c.StartIndex += offset
+			c.EndIndex += offset
+			merged.Citations = append(merged.Citations, c)
+		}
+		content.WriteString(r.Content)
+		merged.ToolCalls = append(merged.ToolCalls, r.ToolCalls...)
+		merged.Logprobs = append(merged.Logprobs, r.Logprobs...)
+		merged.Latency += r.Latency
+	}
+	merged.Content = content.String()
+	return merged
+}
+
+// sumUsage adds up the usage of rs, returning nil if none reported usage.
+func sumUsage(rs []*ChatResponse) *UsageStats {
+	var total *UsageStats
+	for _, r := range rs {
+		if r.Usage == nil {
+			continue
+		}
+		if total == nil {
+			total = &UsageStats{}
+		}
+		total.PromptTokens += r.Usage.PromptTokens
+		total.CompletionTokens += r.Usage.CompletionTokens
+		total.TotalTokens += r.Usage.TotalTokens
+	}
+	return total
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMergeResponses(t *testing.T) {
+	first := &ChatResponse{
+		Content: "Go é ", FinishReason: "length", Latency: time.Second,
+		Usage:     &UsageStats{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		Citations: []Citation{{URL: "a", StartIndex: 0, EndIndex: 2}},
+	}
+	second := &ChatResponse{
+		Content: "fast.", FinishReason: "stop", Latency: 3 * time.Second,
+		Usage:     &UsageStats{PromptTokens: 20, CompletionTokens: 2, TotalTokens: 22},
+		Citations: []Citation{{URL: "b", StartIndex: 0, EndIndex: 4}},
+	}
+	tests := []struct {
+		name        string
+		strategy    MergeStrategy
+		wantContent string
+		wantFinish  string
+		wantLatency time.Duration
+	}{
+		{"concatenate", MergeConcatenate, "Go é fast.", "stop", 4 * time.Second},
+		{"first", MergeFirst, "Go é ", "length", 3 * time.Second},
+		{"longest", MergeLongest, "Go é ", "length", 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeResponses([]*ChatResponse{first, nil, second}, tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if merged.Content != tt.wantContent || merged.FinishReason != tt.wantFinish || merged.Latency != tt.wantLatency {
+				t.Errorf("merged = %+v", merged)
+			}
+			want := UsageStats{PromptTokens: 30, CompletionTokens: 7, TotalTokens: 37}
+			if merged.Usage == nil || *merged.Usage != want {
+				t.Errorf("usage = %+v, want %+v", merged.Usage, want)
+			}
+		})
+	}
+
+	merged, _ := MergeResponses([]*ChatResponse{first, second}, MergeConcatenate)
+	if len(merged.Citations) != 2 || merged.Citations[1].StartIndex != 5 || merged.Citations[1].EndIndex != 9 {
+		t.Errorf("citations = %+v, want the second shifted by five runes", merged.Citations)
+	}
+	if first.Usage.TotalTokens != 15 || len(first.Citations) != 1 {
+		t.Error("inputs were modified")
+	}
+}
+
+func TestMergeResponsesInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []*ChatResponse
+		strategy  MergeStrategy
+	}{
+		{"no responses", nil, MergeFirst},
+		{"only nil responses", []*ChatResponse{nil}, MergeFirst},
+		{"unknown strategy", []*ChatResponse{{}}, MergeStrategy(99)},
+	}
+	for _, tt := range tests {
+		if _, err := MergeResponses(tt.responses, tt.strategy); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s: err = %v, want ErrInvalidRequest", tt.name, err)
+		}
+	}
+}
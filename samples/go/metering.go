@@ -0,0 +1,256 @@
+package llm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a context attributing requests to tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set by WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// MeteringEvent is a usage record for billing. Delivery is at-least-once, so
+// consumers should deduplicate on ID.
+type MeteringEvent struct {
+	ID               string            `json:"id"`
+	Tenant           string            `json:"tenant,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	Provider         string            `json:"provider"`
+	Model            string            `json:"model"`
+	PromptTokens     int               `json:"prompt_tokens"`
+	CompletionTokens int               `json:"completion_tokens"`
+	TotalTokens      int               `json:"total_tokens"`
+	Cost             float64           `json:"cost"`
+	Timestamp        time.Time         `json:"timestamp"`
+}
+
+// EventSink publishes metering events to an external system such as Kafka,
+// NATS or an HTTP collector. Publish must either accept the whole batch or
+// return an error, in which case the batch is retried.
+type EventSink interface {
+	Publish(ctx context.Context, events []MeteringEvent) error
+}
+
+// MeteringConfig configures a MeteringProvider.
+type MeteringConfig struct {
+	Sink  EventSink
+	Costs CostTable // Optional; events for unpriced models report zero cost
+
+	// Pricing, if set, supplies current prices and takes precedence over Costs.
+	Pricing *PricingCache
+
+	// TagLimits bounds the context tags copied onto events; see WithTags.
+	TagLimits TagLimits
+
+	BufferSize    int           // Maximum events held awaiting delivery (default 10000)
+	BatchSize     int           // Maximum events per Publish call (default 100)
+	FlushInterval time.Duration // How often pending events are published (default 1s)
+}
+
+// MeteringProvider wraps a Provider and emits a MeteringEvent for every
+// successful call. Events are buffered and published in the background, so a
+// slow sink never blocks Chat; failed batches are retried until delivered. If
+// the buffer fills, the oldest events are dropped and counted.
+type MeteringProvider struct {
+	Provider
+	config  MeteringConfig
+	bounder *tagBounder
+
+	mu      sync.Mutex
+	pending []MeteringEvent
+	dropped int
+
+	wake      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMeteringProvider creates a metering provider and starts its publisher.
+// Call Close to flush pending events on shutdown.
+func NewMeteringProvider(inner Provider, config MeteringConfig) *MeteringProvider {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 10000
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	p := &MeteringProvider{
+		Provider: inner,
+		config:   config,
+		bounder:  newTagBounder(config.TagLimits),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Unwrap returns the wrapped provider.
+func (p *MeteringProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and records a metering event for the response.
+func (p *MeteringProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	p.enqueue(p.event(ctx, req, resp))
+	return resp, nil
+}
+
+// Dropped returns the number of events discarded because the buffer was full.
+func (p *MeteringProvider) Dropped() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dropped
+}
+
+// Close stops the background publisher and makes a final attempt to deliver
+// pending events before ctx is done. It is safe to call more than once; later
+// calls retry delivery of whatever is still pending.
+func (p *MeteringProvider) Close(ctx context.Context) error {
+	p.closeOnce.Do(func() { close(p.done) })
+	<-p.stopped
+
+	for {
+		if ok, err := p.flush(ctx); err != nil || !ok {
+			return err
+		}
+	}
+}
+
+func (p *MeteringProvider) event(ctx context.Context, req *ChatRequest, resp *ChatResponse) MeteringEvent {
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+
+	ev := MeteringEvent{
+		ID:        newEventID(),
+		Provider:  p.ID(),
+		Model:     model,
+		Timestamp: time.Now().UTC(),
+	}
+	ev.Tenant, _ = TenantFromContext(ctx)
+	ev.Tags = p.bounder.bound(TagsFromContext(ctx))
+	if resp.Usage != nil {
+		ev.PromptTokens = resp.Usage.PromptTokens
+		ev.CompletionTokens = resp.Usage.CompletionTokens
+		ev.TotalTokens = resp.Usage.TotalTokens
+		costs := p.config.Costs
+		if p.config.Pricing != nil {
+			costs = p.config.Pricing.Table(ctx)
+		}
+		// Price the model that served the request, falling back to the
+		// requested alias when the table has no entry for the exact snapshot.
+		cost, err := costs.UsageCost(model, resp.Usage)
+		if err != nil && model != req.Model {
+			cost, err = costs.UsageCost(req.Model, resp.Usage)
+		}
+		if err == nil {
+			ev.Cost = cost
+		}
+	}
+	return ev
+}
+
+func (p *MeteringProvider) enqueue(ev MeteringEvent) {
+	p.mu.Lock()
+	if len(p.pending) >= p.config.BufferSize {
+		p.pending = p.pending[1:]
+		p.dropped++
+	}
+	p.pending = append(p.pending, ev)
+	full := len(p.pending) >= p.config.BatchSize
+	p.mu.Unlock()
+
+	if full {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *MeteringProvider) run() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(p.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.config.FlushInterval)
+		for {
+			// Stop at the first failure and retry on the next tick.
+			if ok, err := p.flush(ctx); err != nil || !ok {
+				break
+			}
+		}
+		cancel()
+	}
+}
+
+// flush publishes the oldest pending batch. It reports whether a batch was
+// delivered; events are only removed from the buffer once the sink accepts them.
+func (p *MeteringProvider) flush(ctx context.Context) (bool, error) {
+	p.mu.Lock()
+	n := min(len(p.pending), p.config.BatchSize)
+	batch := append([]MeteringEvent(nil), p.pending[:n]...)
+	p.mu.Unlock()
+
+	if n == 0 {
+		return false, nil
+	}
+	if err := p.config.Sink.Publish(ctx, batch); err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Events may have been dropped from the front while publishing, so remove
+	// whatever remains of the delivered batch rather than a fixed count.
+	delivered := make(map[string]bool, n)
+	for _, ev := range batch {
+		delivered[ev.ID] = true
+	}
+	for len(p.pending) > 0 && delivered[p.pending[0].ID] {
+		p.pending = p.pending[1:]
+	}
+	return true, nil
+}
+
+// newEventID returns a random 128-bit hex identifier.
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
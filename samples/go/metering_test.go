@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink collects published events, failing the first failures calls.
+type recordingSink struct {
+	mu       sync.Mutex
+	failures int
+	events   []MeteringEvent
+}
+
+func (s *recordingSink) Publish(_ context.Context, events []MeteringEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *recordingSink) published() []MeteringEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]MeteringEvent(nil), s.events...)
+}
+
+func TestMeteringProviderPricesServedModel(t *testing.T) {
+	costs := CostTable{
+		"alias":          {PromptPerMillion: 1_000_000, CompletionPerMillion: 1_000_000},
+		"alias-20240101": {PromptPerMillion: 2_000_000, CompletionPerMillion: 2_000_000},
+	}
+	usage := &UsageStats{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}
+	tests := []struct {
+		name      string
+		served    string
+		wantModel string
+		wantCost  float64
+	}{
+		{"priced snapshot", "alias-20240101", "alias-20240101", 4},
+		{"unpriced snapshot falls back to the alias", "alias-20250101", "alias-20250101", 2},
+		{"no served model", "", "alias", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			inner := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+				return &ChatResponse{Model: tt.served, Usage: usage}, nil
+			}}
+			p := NewMeteringProvider(inner, MeteringConfig{Sink: sink, Costs: costs, FlushInterval: time.Hour})
+			ctx := WithTenant(context.Background(), "acme")
+			if _, err := p.Chat(ctx, &ChatRequest{Model: "alias"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			events := sink.published()
+			if len(events) != 1 {
+				t.Fatalf("published %d events, want 1", len(events))
+			}
+			ev := events[0]
+			if ev.Model != tt.wantModel || ev.Cost != tt.wantCost || ev.Tenant != "acme" || ev.TotalTokens != 2 || ev.ID == "" {
+				t.Errorf("event = %+v, want model %s costing %g", ev, tt.wantModel, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestMeteringProviderCloseIsIdempotent(t *testing.T) {
+	sink := &recordingSink{failures: 1}
+	p := NewMeteringProvider(&fakeProvider{}, MeteringConfig{Sink: sink, FlushInterval: time.Hour})
+	p.Chat(context.Background(), &ChatRequest{})
+
+	if err := p.Close(context.Background()); err == nil {
+		t.Fatal("first Close succeeded despite a failing sink")
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if n := len(sink.published()); n != 1 {
+		t.Errorf("published %d events, want 1", n)
+	}
+}
+
+func TestMeteringProviderDropsOldestWhenFull(t *testing.T) {
+	sink := &recordingSink{}
+	p := NewMeteringProvider(&fakeProvider{}, MeteringConfig{Sink: sink, BufferSize: 2, BatchSize: 10, FlushInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		p.Chat(context.Background(), &ChatRequest{})
+	}
+	if p.Dropped() != 3 {
+		t.Errorf("dropped %d, want 3", p.Dropped())
+	}
+	p.Close(context.Background())
+	if n := len(sink.published()); n != 2 {
+		t.Errorf("published %d events, want 2", n)
+	}
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrOutputTooShort is returned when a response stays below the minimum
+// length after being asked to elaborate.
+var ErrOutputTooShort = errors.New("response shorter than required")
+
+// MinLengthConfig configures a MinLengthProvider. Zero fields are not checked.
+type MinLengthConfig struct {
+	MinChars  int
+	MinTokens int
+
+	// Counter counts MinTokens. Defaults to HeuristicTokenCounter.
+	Counter TokenCounter
+}
+
+// MinLengthProvider wraps a Provider and treats overly terse responses, such
+// as a one-line refusal, as failures: the model is asked once to elaborate,
+// and ErrOutputTooShort is returned if the answer is still too short.
+// Responses that call tools are not checked.
+type MinLengthProvider struct {
+	Provider
+	config MinLengthConfig
+}
+
+// NewMinLengthProvider creates a provider that enforces config's minimums.
+func NewMinLengthProvider(inner Provider, config MinLengthConfig) *MinLengthProvider {
+	if config.Counter == nil {
+		config.Counter = HeuristicTokenCounter{}
+	}
+	return &MinLengthProvider{Provider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *MinLengthProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request, retrying once if the response is too short.
+func (p *MinLengthProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if p.shortfall(req.Model, resp) == "" {
+		return resp, nil
+	}
+
+	retry := *req
+	retry.Messages = append(append([]Message(nil), req.Messages...),
+		Message{Role: "assistant", Content: resp.Content},
+		Message{Role: "user", Content: "That answer is too brief. Please answer again in full, with more detail."},
+	)
+	resp, err = p.Provider.Chat(ctx, &retry)
+	if err != nil {
+		return nil, err
+	}
+	if short := p.shortfall(req.Model, resp); short != "" {
+		return nil, fmt.Errorf("%w: %s", ErrOutputTooShort, short)
+	}
+	return resp, nil
+}
+
+// shortfall describes how resp falls short of the minimums, or returns "".
+func (p *MinLengthProvider) shortfall(model string, resp *ChatResponse) string {
+	if len(resp.ToolCalls) > 0 {
+		return ""
+	}
+	content := strings.TrimSpace(resp.Content)
+	if n := utf8.RuneCountInString(content); n < p.config.MinChars {
+		return fmt.Sprintf("%d characters < %d", n, p.config.MinChars)
+	}
+	if p.config.MinTokens > 0 {
+		if n := p.config.Counter.CountTokens(model, content); n < p.config.MinTokens {
+			return fmt.Sprintf("%d tokens < %d", n, p.config.MinTokens)
+		}
+	}
+	return ""
+}
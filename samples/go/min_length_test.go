@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMinLengthProvider(t *testing.T) {
+	short := outcome{resp: &ChatResponse{Content: " no "}}
+	long := outcome{resp: &ChatResponse{Content: "a complete answer"}}
+	tool := outcome{resp: &ChatResponse{ToolCalls: []ToolCall{{ID: "1", Name: "search"}}}}
+	errDown := errors.New("down")
+
+	tests := []struct {
+		name      string
+		config    MinLengthConfig
+		outcomes  []outcome
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "long enough", config: MinLengthConfig{MinChars: 5}, outcomes: []outcome{long}, wantCalls: 1},
+		{name: "elaborates on retry", config: MinLengthConfig{MinChars: 5}, outcomes: []outcome{short, long}, wantCalls: 2},
+		{name: "still too short", config: MinLengthConfig{MinChars: 5}, outcomes: []outcome{short, short}, wantCalls: 2, wantErr: ErrOutputTooShort},
+		{name: "token minimum", config: MinLengthConfig{MinTokens: 10, Counter: wordCounter{}}, outcomes: []outcome{short, long}, wantCalls: 2},
+		{name: "tool calls are not checked", config: MinLengthConfig{MinChars: 5}, outcomes: []outcome{tool}, wantCalls: 1},
+		{name: "retry error", config: MinLengthConfig{MinChars: 5}, outcomes: []outcome{short, {err: errDown}}, wantCalls: 2, wantErr: errDown},
+		{name: "no minimums", outcomes: []outcome{short}, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(tt.outcomes...)}
+			req := &ChatRequest{Model: "m", Messages: userMessages("why?")}
+			_, err := NewMinLengthProvider(inner, tt.config).Chat(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			calls := inner.requests()
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantCalls == 2 {
+				retry := calls[1].Messages
+				if len(retry) != 3 || retry[1].Role != "assistant" || retry[2].Role != "user" {
+					t.Errorf("retry messages = %+v, want the short answer and a follow-up", retry)
+				}
+				if len(req.Messages) != 1 {
+					t.Error("retry modified the caller's messages")
+				}
+			}
+		})
+	}
+}
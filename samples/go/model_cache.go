@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ModelInfo is the metadata a provider reports for one of its models.
+type ModelInfo struct {
+	ID      string
+	OwnedBy string    // Organization that owns the model, if reported
+	Created time.Time // When the model was published, if reported
+}
+
+// ModelDescriber is implemented by providers that report metadata for their
+// models, not just their IDs.
+type ModelDescriber interface {
+	DescribeModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// ModelListCache wraps a Provider and caches its model list and model
+// metadata, so availability checks do not call ListModels every time. If a
+// refresh fails, the previous list keeps being served and is flagged as stale,
+// and refreshing backs off: starting at a tenth of the TTL and doubling up to
+// the TTL, so a failing provider is not called on every lookup.
+type ModelListCache struct {
+	Provider
+	ttl time.Duration
+
+	mu        sync.Mutex
+	models    []ModelInfo
+	fetchedAt time.Time
+	stale     bool
+	lastErr   error
+	backoff   time.Duration // Delay after the latest failed refresh; zero when healthy
+	retryAt   time.Time     // No refresh is attempted before this while stale
+}
+
+// NewModelListCache creates a cache that refreshes the model list after ttl (default 5m).
+func NewModelListCache(inner Provider, ttl time.Duration) *ModelListCache {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &ModelListCache{Provider: inner, ttl: ttl}
+}
+
+// Unwrap returns the wrapped provider.
+func (c *ModelListCache) Unwrap() Provider {
+	return c.Provider
+}
+
+// Run refreshes the model list in the background every ttl until ctx is canceled,
+// so foreground calls rarely wait on the provider.
+func (c *ModelListCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListModels returns the cached model list, refreshing it first if it has expired.
+func (c *ModelListCache) ListModels(ctx context.Context) ([]string, error) {
+	infos, err := c.DescribeModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]string, len(infos))
+	for i, m := range infos {
+		models[i] = m.ID
+	}
+	return models, nil
+}
+
+// DescribeModels returns the cached model metadata, refreshing it first if it
+// has expired. For providers that are not a ModelDescriber, only the IDs are set.
+func (c *ModelListCache) DescribeModels(ctx context.Context) ([]ModelInfo, error) {
+	c.mu.Lock()
+	now := time.Now()
+	cached := c.models != nil && (now.Sub(c.fetchedAt) < c.ttl || c.stale && now.Before(c.retryAt))
+	models := c.models
+	c.mu.Unlock()
+
+	if cached {
+		return slices.Clone(models), nil
+	}
+	return c.refresh(ctx)
+}
+
+// IsModelAvailable checks model against the cached model list.
+func (c *ModelListCache) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	_, ok, err := c.Model(ctx, model)
+	return ok, err
+}
+
+// Model returns the cached metadata for model, or false if it is not listed.
+func (c *ModelListCache) Model(ctx context.Context, model string) (ModelInfo, bool, error) {
+	models, err := c.DescribeModels(ctx)
+	if err != nil {
+		return ModelInfo{}, false, err
+	}
+	i := slices.IndexFunc(models, func(m ModelInfo) bool { return m.ID == model })
+	if i < 0 {
+		return ModelInfo{}, false, nil
+	}
+	return models[i], true, nil
+}
+
+// Stale reports whether the cached list is being served after a failed refresh,
+// along with that failure.
+func (c *ModelListCache) Stale() (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stale, c.lastErr
+}
+
+// refresh fetches the model metadata. On failure it falls back to the
+// previous list, if there is one, marks it stale and backs off.
+func (c *ModelListCache) refresh(ctx context.Context) ([]ModelInfo, error) {
+	models, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.lastErr = err
+		if c.models == nil {
+			return nil, err
+		}
+		c.stale = true
+		c.backoff = min(max(c.backoff*2, c.ttl/10), c.ttl)
+		c.retryAt = time.Now().Add(c.backoff)
+		return slices.Clone(c.models), nil
+	}
+
+	c.models = slices.Clone(models)
+	c.fetchedAt = time.Now()
+	c.stale, c.lastErr, c.backoff = false, nil, 0
+	return models, nil
+}
+
+// fetch asks the provider for its model metadata, falling back to bare IDs
+// from ListModels.
+func (c *ModelListCache) fetch(ctx context.Context) ([]ModelInfo, error) {
+	if d, ok := c.Provider.(ModelDescriber); ok {
+		return d.DescribeModels(ctx)
+	}
+	ids, err := c.Provider.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]ModelInfo, len(ids))
+	for i, id := range ids {
+		models[i] = ModelInfo{ID: id}
+	}
+	return models, nil
+}
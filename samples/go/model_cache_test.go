@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// flakyLister is a fakeProvider whose ListModels can be made to fail.
+type flakyLister struct {
+	*fakeProvider
+	fail  bool
+	lists int
+}
+
+func (p *flakyLister) ListModels(ctx context.Context) ([]string, error) {
+	p.lists++
+	if p.fail {
+		return nil, ErrRateLimited
+	}
+	return p.fakeProvider.ListModels(ctx)
+}
+
+func TestModelListCache(t *testing.T) {
+	const ttl = 200 * time.Millisecond // Backoff starts at ttl/10
+	type step struct {
+		sleep     time.Duration
+		fail      bool
+		wantErr   bool
+		wantStale bool
+		wantCalls int
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"served from cache", []step{{wantCalls: 1}, {wantCalls: 1}}},
+		{"refreshed after TTL", []step{{wantCalls: 1}, {sleep: ttl, wantCalls: 2}}},
+		{"error without a list", []step{{fail: true, wantErr: true, wantCalls: 1}, {fail: true, wantErr: true, wantCalls: 2}}},
+		{"stale on error with backoff", []step{
+			{wantCalls: 1},
+			{sleep: ttl, fail: true, wantStale: true, wantCalls: 2},
+			{fail: true, wantStale: true, wantCalls: 2},                  // Within the first backoff
+			{sleep: ttl / 10, fail: true, wantStale: true, wantCalls: 3}, // Backoff doubles
+			{sleep: ttl / 10, fail: true, wantStale: true, wantCalls: 3}, // Still within it
+			{sleep: ttl / 10, wantCalls: 4},                              // Recovered
+			{wantCalls: 4},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyLister{fakeProvider: &fakeProvider{models: []string{"gpt-4o"}}}
+			c := NewModelListCache(inner, ttl)
+			for i, s := range tt.steps {
+				time.Sleep(s.sleep)
+				inner.fail = s.fail
+				ok, err := c.IsModelAvailable(context.Background(), "gpt-4o")
+				if (err != nil) != s.wantErr || !s.wantErr && !ok {
+					t.Fatalf("step %d: available %v, err %v", i, ok, err)
+				}
+				if stale, _ := c.Stale(); stale != s.wantStale {
+					t.Errorf("step %d: stale = %v, want %v", i, stale, s.wantStale)
+				}
+				if inner.lists != s.wantCalls {
+					t.Errorf("step %d: ListModels called %d times, want %d", i, inner.lists, s.wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestModelListCacheMetadata(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeJSON(w, `{"data":[{"id":"gpt-4o","owned_by":"openai","created":1715367049},{"id":"gpt-4o-mini"}]}`)
+	})
+	c := NewModelListCache(srv.provider(OpenAIConfig{}), time.Minute)
+	for range 2 {
+		info, ok, err := c.Model(context.Background(), "gpt-4o")
+		if err != nil || !ok {
+			t.Fatalf("Model = %v, %v", ok, err)
+		}
+		if want := (ModelInfo{ID: "gpt-4o", OwnedBy: "openai", Created: time.Unix(1715367049, 0)}); info != want {
+			t.Errorf("info = %+v, want %+v", info, want)
+		}
+	}
+	models, err := c.ListModels(context.Background())
+	if err != nil || !slices.Equal(models, []string{"gpt-4o", "gpt-4o-mini"}) {
+		t.Errorf("ListModels = %v, %v", models, err)
+	}
+	if n := len(srv.bodies); n != 1 {
+		t.Errorf("models endpoint called %d times, want 1", n)
+	}
+	if _, err := NewModelListCache(&flakyLister{fakeProvider: &fakeProvider{}, fail: true}, time.Minute).DescribeModels(context.Background()); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+}
@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ModelNormalizer canonicalizes user-typed model names, so that "GPT-4O",
+// "gpt4o" and "gpt_4o" all resolve to "gpt-4o".
+type ModelNormalizer struct {
+	// Aliases maps alternate names (compared after normalization) to canonical names.
+	Aliases map[string]string
+
+	// Models are the canonical model names. If empty, NormalizingProvider uses
+	// the wrapped provider's ListModels, cached for ModelsTTL (default 5m).
+	Models    []string
+	ModelsTTL time.Duration
+}
+
+// Normalize returns the canonical form of model, matching case- and
+// separator-insensitively against aliases and then known models. Names that
+// match nothing are returned lowercased and trimmed.
+func (n *ModelNormalizer) Normalize(model string, known []string) string {
+	cleaned := strings.ToLower(strings.TrimSpace(model))
+	squashed := squashModelName(cleaned)
+
+	for alias, canonical := range n.Aliases {
+		if squashModelName(strings.ToLower(alias)) == squashed {
+			return canonical
+		}
+	}
+	for _, m := range known {
+		if squashModelName(strings.ToLower(m)) == squashed {
+			return m
+		}
+	}
+	return cleaned
+}
+
+// squashModelName removes separators so that spelling variants compare equal.
+func squashModelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', ' ':
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// NormalizingProvider wraps a Provider and canonicalizes model names before
+// dispatch and availability checks.
+type NormalizingProvider struct {
+	Provider
+	normalizer *ModelNormalizer
+	known      *ModelListCache // Nil when the normalizer lists its models
+}
+
+// NewNormalizingProvider creates a provider that normalizes model names with normalizer.
+func NewNormalizingProvider(inner Provider, normalizer *ModelNormalizer) *NormalizingProvider {
+	p := &NormalizingProvider{Provider: inner, normalizer: normalizer}
+	if len(normalizer.Models) == 0 {
+		p.known = NewModelListCache(inner, normalizer.ModelsTTL)
+	}
+	return p
+}
+
+// Unwrap returns the wrapped provider.
+func (p *NormalizingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Canonical returns the canonical name of model on this provider.
+func (p *NormalizingProvider) Canonical(ctx context.Context, model string) (string, error) {
+	known := p.normalizer.Models
+	if p.known != nil {
+		var err error
+		if known, err = p.known.ListModels(ctx); err != nil {
+			return "", err
+		}
+	}
+	return p.normalizer.Normalize(model, known), nil
+}
+
+// Chat canonicalizes the request's model name and forwards it.
+func (p *NormalizingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	model, err := p.Canonical(ctx, req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := *req
+	normalized.Model = model
+	return p.Provider.Chat(ctx, &normalized)
+}
+
+// IsModelAvailable canonicalizes model before checking availability.
+func (p *NormalizingProvider) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	canonical, err := p.Canonical(ctx, model)
+	if err != nil {
+		return false, err
+	}
+	return p.Provider.IsModelAvailable(ctx, canonical)
+}
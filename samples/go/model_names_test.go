@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+// countingLister is a fakeProvider that counts ListModels calls.
+type countingLister struct {
+	*fakeProvider
+	lists int
+}
+
+func (p *countingLister) ListModels(ctx context.Context) ([]string, error) {
+	p.lists++
+	return p.fakeProvider.ListModels(ctx)
+}
+
+func TestModelNormalizer(t *testing.T) {
+	n := &ModelNormalizer{Aliases: map[string]string{"sonnet": "claude-sonnet-4"}}
+	known := []string{"gpt-4o", "gpt-4o-mini"}
+	tests := []struct {
+		in, want string
+	}{
+		{"GPT-4O", "gpt-4o"},
+		{"gpt4o", "gpt-4o"},
+		{"gpt_4o_mini", "gpt-4o-mini"},
+		{" Sonnet ", "claude-sonnet-4"},
+		{"Unknown-Model ", "unknown-model"},
+	}
+	for _, tt := range tests {
+		if got := n.Normalize(tt.in, known); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizingProviderCachesListedModels(t *testing.T) {
+	inner := &countingLister{fakeProvider: &fakeProvider{models: []string{"gpt-4o"}}}
+	p := NewNormalizingProvider(inner, &ModelNormalizer{})
+	for i := 0; i < 3; i++ {
+		if _, err := p.Chat(context.Background(), &ChatRequest{Model: "GPT4o"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := inner.requests()[2].Model; got != "gpt-4o" {
+		t.Errorf("sent model %q, want gpt-4o", got)
+	}
+	if inner.lists != 1 {
+		t.Errorf("ListModels called %d times, want 1", inner.lists)
+	}
+
+	static := &countingLister{fakeProvider: &fakeProvider{}}
+	NewNormalizingProvider(static, &ModelNormalizer{Models: []string{"gpt-4o"}}).Canonical(context.Background(), "gpt4o")
+	if static.lists != 0 {
+		t.Errorf("ListModels called with static models")
+	}
+}
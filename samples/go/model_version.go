@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// modelVersionPattern matches the snapshot suffixes providers append to model
+// names: "-2024-08-06" (OpenAI), "-20241022" (Anthropic) and "-0613" (legacy OpenAI).
+var modelVersionPattern = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}|\d{8}|\d{4})$`)
+
+// ParseModelVersion extracts the version component from a served model name.
+// It understands dated snapshot suffixes ("gpt-4o-2024-08-06") and Ollama tags
+// ("llama3:8b-instruct"). It returns an empty string if no version is present.
+func ParseModelVersion(model string) string {
+	if i := strings.LastIndex(model, ":"); i >= 0 {
+		return model[i+1:]
+	}
+	if m := modelVersionPattern.FindStringSubmatch(model); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// ModelVersionProvider wraps a Provider and records which model snapshot
+// actually served each response, for auditability.
+type ModelVersionProvider struct {
+	Provider
+}
+
+// NewModelVersionProvider creates a provider that tags responses with the served model version.
+func NewModelVersionProvider(inner Provider) *ModelVersionProvider {
+	return &ModelVersionProvider{Provider: inner}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ModelVersionProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and annotates the response with the requested model
+// and the version parsed from the served model name.
+func (p *ModelVersionProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Model == "" {
+		resp.Model = req.Model
+	}
+	if resp.Model != req.Model {
+		resp.RequestedModel = req.Model
+	}
+	resp.ModelVersion = ParseModelVersion(resp.Model)
+	return resp, nil
+}
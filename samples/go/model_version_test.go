@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseModelVersion(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-4o-2024-08-06", "2024-08-06"},
+		{"claude-3-5-sonnet-20241022", "20241022"},
+		{"gpt-4-0613", "0613"},
+		{"llama3:8b-instruct", "8b-instruct"},
+		{"gpt-4o", ""},
+	}
+	for _, tt := range tests {
+		if got := ParseModelVersion(tt.model); got != tt.want {
+			t.Errorf("ParseModelVersion(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestModelVersionProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		requested     string
+		served        string
+		wantModel     string
+		wantRequested string
+		wantVersion   string
+	}{
+		{"snapshot differs from request", "gpt-4o", "gpt-4o-2024-08-06", "gpt-4o-2024-08-06", "gpt-4o", "2024-08-06"},
+		{"served as requested", "gpt-4o-2024-08-06", "gpt-4o-2024-08-06", "gpt-4o-2024-08-06", "", "2024-08-06"},
+		{"provider omits model", "gpt-4o", "", "gpt-4o", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+				return &ChatResponse{Model: tt.served}, nil
+			}}
+			resp, err := NewModelVersionProvider(inner).Chat(context.Background(), &ChatRequest{Model: tt.requested})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Model != tt.wantModel || resp.RequestedModel != tt.wantRequested || resp.ModelVersion != tt.wantVersion {
+				t.Errorf("got model %q requested %q version %q, want %q %q %q",
+					resp.Model, resp.RequestedModel, resp.ModelVersion, tt.wantModel, tt.wantRequested, tt.wantVersion)
+			}
+		})
+	}
+}
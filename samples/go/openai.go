@@ -0,0 +1,650 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ProviderError is a non-success response from a provider's API.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Code       string // The provider's error code, e.g. "model_not_found"
+	Message    string
+
+	// Request is the redacted request that failed, when
+	// OpenAIConfig.CaptureRequests is set.
+	Request *CapturedRequest
+}
+
+func (e *ProviderError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: HTTP %d %s: %s", e.Provider, e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: HTTP %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Is maps well-known provider errors onto the package's sentinel errors.
+func (e *ProviderError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrModelNotAvailable:
+		return e.Code == "model_not_found"
+	case ErrModelDeprecated:
+		return e.Code == "model_deprecated" || e.Code == "model_decommissioned"
+	}
+	return false
+}
+
+// MetadataRawRequest holds the *CapturedRequest sent to the provider, when
+// OpenAIConfig.CaptureRequests is set.
+const MetadataRawRequest = "raw_request"
+
+// CapturedRequest is a redacted copy of an HTTP request sent to a provider.
+type CapturedRequest struct {
+	Header http.Header     // Headers sent, with credential headers masked
+	Body   json.RawMessage // JSON body sent, with its string values redacted
+}
+
+// OpenAIConfig configures an OpenAIProvider.
+type OpenAIConfig struct {
+	ID         string // Defaults to "openai"
+	BaseURL    string // Defaults to https://api.openai.com/v1
+	APIKey     string
+	HTTPClient *http.Client // Defaults to http.DefaultClient
+
+	// Headers are sent on every request, e.g. an Azure api-version or a
+	// gateway routing hint. Per-request headers set with WithHeaders override them.
+	Headers http.Header
+
+	// OnRateLimit, if set, is called with the rate-limit state reported on every
+	// API response, including errors, so a limiter can back off before a 429.
+	OnRateLimit func(RateLimitInfo)
+
+	// Metrics, if set, receives the size of every request and response body.
+	Metrics PayloadMetrics
+
+	// CaptureRequests records the request sent for each Chat call on the
+	// response's metadata under MetadataRawRequest, and on the ProviderError
+	// of a failed call. Credential headers are masked and the body's string
+	// values are passed through CaptureRedactor (default SecretRedactor),
+	// leaving the JSON structure intact. Off by default, since bodies can be
+	// large.
+	CaptureRequests bool
+	CaptureRedactor Redactor
+
+	// Grammar declares that the server accepts a GBNF "grammar" field, as
+	// llama.cpp and some gateways do. OpenAI itself does not.
+	Grammar bool
+
+	// OmitStreamUsage stops streams from requesting usage through
+	// stream_options unless ChatRequest.StreamUsage is set, for servers that
+	// reject the field. By default usage is requested on every stream, to keep
+	// accounting accurate.
+	OmitStreamUsage bool
+}
+
+// OpenAIProvider talks to the OpenAI chat completions API, or any server that
+// implements it (Azure OpenAI, Ollama, vLLM and most gateways).
+type OpenAIProvider struct {
+	id          string
+	baseURL     string
+	apiKey      string
+	client      *http.Client
+	onRateLimit func(RateLimitInfo)
+	grammar     bool
+	metrics     PayloadMetrics
+	capture     Redactor // Nil disables request capture
+	headers     http.Header
+	streamUsage bool // Request usage on every stream, not only when StreamUsage is set
+}
+
+// NewOpenAIProvider creates a provider for an OpenAI-compatible API.
+func NewOpenAIProvider(cfg OpenAIConfig) *OpenAIProvider {
+	if cfg.ID == "" {
+		cfg.ID = "openai"
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.openai.com/v1"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	var capture Redactor
+	if cfg.CaptureRequests {
+		capture = cfg.CaptureRedactor
+		if capture == nil {
+			capture = SecretRedactor
+		}
+	}
+	return &OpenAIProvider{
+		id:          cfg.ID,
+		baseURL:     strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:      cfg.APIKey,
+		client:      cfg.HTTPClient,
+		onRateLimit: cfg.OnRateLimit,
+		grammar:     cfg.Grammar,
+		metrics:     cfg.Metrics,
+		capture:     capture,
+		headers:     cfg.Headers.Clone(),
+		streamUsage: !cfg.OmitStreamUsage,
+	}
+}
+
+// ID returns the provider's identifier.
+func (p *OpenAIProvider) ID() string {
+	return p.id
+}
+
+type openAIMessage struct {
+	Role        string             `json:"role"`
+	Content     string             `json:"content"`
+	ToolCalls   []ToolCall         `json:"tool_calls"`
+	Annotations []openAIAnnotation `json:"annotations"`
+}
+
+type openAIAnnotation struct {
+	Type        string `json:"type"`
+	URLCitation *struct {
+		URL        string `json:"url"`
+		Title      string `json:"title"`
+		StartIndex int    `json:"start_index"`
+		EndIndex   int    `json:"end_index"`
+	} `json:"url_citation"`
+	FileCitation *struct {
+		FileID     string `json:"file_id"`
+		Filename   string `json:"filename"`
+		StartIndex int    `json:"start_index"`
+		EndIndex   int    `json:"end_index"`
+	} `json:"file_citation"`
+}
+
+type openAIChoice struct {
+	Index        int             `json:"index"`
+	Message      openAIMessage   `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+	Logprobs     *openAILogprobs `json:"logprobs"`
+}
+
+type openAIChatResponse struct {
+	Model             string         `json:"model"`
+	SystemFingerprint string         `json:"system_fingerprint"`
+	Choices           []openAIChoice `json:"choices"`
+	Usage             *UsageStats    `json:"usage"`
+}
+
+type openAIToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIStreamEvent struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Content   string                `json:"content"`
+			ToolCalls []openAIToolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *UsageStats `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+type openAIErrorBody struct {
+	Error struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// Chat sends a chat completion request.
+func (p *OpenAIProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+
+	body, err := p.encode(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := p.do(ctx, http.MethodPost, "/chat/completions", req.Model, body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var wire openAIChatResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&wire); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if len(wire.Choices) == 0 {
+		return nil, fmt.Errorf("%w: no choices", ErrInvalidResponse)
+	}
+
+	choice := wire.Choices[0]
+	resp := &ChatResponse{
+		Content:      choice.Message.Content,
+		Model:        wire.Model,
+		Fingerprint:  wire.SystemFingerprint,
+		FinishReason: choice.FinishReason,
+		ToolCalls:    choice.Message.ToolCalls,
+		Citations:    parseCitations(choice.Message),
+		Usage:        wire.Usage,
+		Latency:      time.Since(start),
+	}
+	if choice.Logprobs != nil {
+		resp.Logprobs = choice.Logprobs.Content
+	}
+	if info, ok := ParseRateLimitHeaders(httpResp.Header); ok {
+		resp.SetMetadata(MetadataRateLimit, info)
+	}
+	if w, ok := ParseDeprecationHeaders(httpResp.Header, req.Model); ok {
+		resp.SetMetadata(MetadataDeprecation, w)
+	}
+	p.captureRequest(resp, httpResp, body)
+	return resp, nil
+}
+
+// ChatStream sends a streaming chat completion request. Usage is requested via
+// stream_options, unless the provider was configured with OmitStreamUsage and
+// req.StreamUsage is unset, and is reported on the terminal chunk.
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	events, err := p.ChatStreamEvents(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return chunksFromEvents(ctx, events), nil
+}
+
+// ChatStreamEvents sends a streaming chat completion request and returns the
+// typed events of the stream in the order they arrived. It is the lower-level
+// API under ChatStream, for consumers that need tool-call deltas or per-choice
+// detail. Consumers must drain the channel or cancel ctx.
+func (p *OpenAIProvider) ChatStreamEvents(ctx context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
+	body, err := p.encodeStream(req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := p.do(ctx, http.MethodPost, "/chat/completions", req.Model, body)
+	if err != nil {
+		return nil, err
+	}
+	return p.streamEvents(ctx, httpResp), nil
+}
+
+// encodeStream serializes req for a streaming chat completion.
+func (p *OpenAIProvider) encodeStream(req *ChatRequest) ([]byte, error) {
+	extra := map[string]any{"stream": true}
+	if req.StreamUsage || p.streamUsage {
+		extra["stream_options"] = map[string]any{"include_usage": true}
+	}
+	return p.encode(req, extra)
+}
+
+// streamEvents parses the server-sent events of a streaming response,
+// closing its body when the stream ends.
+func (p *OpenAIProvider) streamEvents(ctx context.Context, httpResp *http.Response) <-chan StreamEvent {
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		defer httpResp.Body.Close()
+
+		var usage *UsageStats
+		var model, finishReason string
+		err := readSSE(httpResp.Body, func(data []byte) error {
+			received := time.Now()
+			var wire openAIStreamEvent
+			if err := json.Unmarshal(data, &wire); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+			}
+			if wire.Error != nil {
+				return &ProviderError{Provider: p.id, StatusCode: http.StatusOK, Code: wire.Error.Code, Message: wire.Error.Message}
+			}
+			if wire.Usage != nil {
+				usage = wire.Usage
+			}
+			if wire.Model != "" {
+				model = wire.Model
+			}
+
+			for _, c := range wire.Choices {
+				if c.FinishReason != "" {
+					finishReason = c.FinishReason
+				}
+				if c.Delta.Content != "" {
+					if !sendChunk(ctx, out, StreamEvent{Type: EventContentDelta, Index: c.Index, Content: c.Delta.Content, Model: model, Raw: data, Received: received}, 0) {
+						return ctx.Err()
+					}
+				}
+				for _, tc := range c.Delta.ToolCalls {
+					delta := &ToolCallDelta{Index: tc.Index, ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments}
+					if !sendChunk(ctx, out, StreamEvent{Type: EventToolCallDelta, Index: c.Index, ToolCall: delta, Model: model, Raw: data, Received: received}, 0) {
+						return ctx.Err()
+					}
+				}
+			}
+			return nil
+		})
+
+		// The terminal event is offered for terminalGrace after ctx ends, so a
+		// consumer that cancels and then drains still learns how the stream ended.
+		if err != nil {
+			sendChunk(ctx, out, StreamEvent{Type: EventError, Err: err, Usage: usage, Model: model, Received: time.Now()}, terminalGrace)
+			return
+		}
+		sendChunk(ctx, out, StreamEvent{Type: EventDone, Model: model, FinishReason: finishReason, Usage: usage, Received: time.Now()}, terminalGrace)
+	}()
+
+	return out
+}
+
+// ListModels returns the models exposed by the API.
+func (p *OpenAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	infos, err := p.DescribeModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(infos))
+	for _, m := range infos {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
+
+// DescribeModels returns the models exposed by the API with their metadata.
+func (p *OpenAIProvider) DescribeModels(ctx context.Context) ([]ModelInfo, error) {
+	httpResp, err := p.do(ctx, http.MethodGet, "/models", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var wire struct {
+		Data []struct {
+			ID      string `json:"id"`
+			OwnedBy string `json:"owned_by"`
+			Created int64  `json:"created"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&wire); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	models := make([]ModelInfo, 0, len(wire.Data))
+	for _, m := range wire.Data {
+		info := ModelInfo{ID: m.ID, OwnedBy: m.OwnedBy}
+		if m.Created > 0 {
+			info.Created = time.Unix(m.Created, 0)
+		}
+		models = append(models, info)
+	}
+	return models, nil
+}
+
+// IsModelAvailable checks whether model is listed by the API.
+func (p *OpenAIProvider) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	models, err := p.ListModels(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range models {
+		if m == model {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseCitations converts a message's annotations into citations, resolving
+// each one's cited text from the message content.
+func parseCitations(msg openAIMessage) []Citation {
+	if len(msg.Annotations) == 0 {
+		return nil
+	}
+
+	content := []rune(msg.Content)
+	span := func(start, end int) string {
+		if start < 0 || end > len(content) || start >= end {
+			return ""
+		}
+		return string(content[start:end])
+	}
+
+	var citations []Citation
+	for _, a := range msg.Annotations {
+		switch {
+		case a.URLCitation != nil:
+			c := a.URLCitation
+			citations = append(citations, Citation{
+				Source: a.Type, Title: c.Title, URL: c.URL, Text: span(c.StartIndex, c.EndIndex),
+				StartIndex: c.StartIndex, EndIndex: c.EndIndex,
+			})
+		case a.FileCitation != nil:
+			c := a.FileCitation
+			citations = append(citations, Citation{
+				Source: a.Type, Title: c.Filename, FileID: c.FileID, Text: span(c.StartIndex, c.EndIndex),
+				StartIndex: c.StartIndex, EndIndex: c.EndIndex,
+			})
+		}
+	}
+	return citations
+}
+
+// do issues an API request for model and converts non-2xx responses into a
+// ProviderError.
+func (p *OpenAIProvider) do(ctx context.Context, method, path, model string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := p.newRequest(ctx, method, path, reader)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	return p.checkResponse(httpReq, httpResp, model, body)
+}
+
+// newRequest builds an API request with the provider's headers and those set
+// on ctx.
+func (p *OpenAIProvider) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	applyHeaders(ctx, httpReq.Header, p.headers)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	return httpReq, nil
+}
+
+// checkResponse meters httpResp, reports its rate limits and turns a non-2xx
+// status into a ProviderError. body is the request body that was sent.
+func (p *OpenAIProvider) checkResponse(httpReq *http.Request, httpResp *http.Response, model string, body []byte) (*http.Response, error) {
+	if p.metrics != nil {
+		httpResp.Body = &meteredBody{ReadCloser: httpResp.Body, onClose: func(n int64) {
+			p.metrics.ObservePayload(p.id, model, int64(len(body)), n)
+		}}
+	}
+	if p.onRateLimit != nil {
+		if info, ok := ParseRateLimitHeaders(httpResp.Header); ok {
+			p.onRateLimit(info)
+		}
+	}
+	if httpResp.StatusCode/100 != 2 {
+		defer httpResp.Body.Close()
+		perr := p.errorFromResponse(httpResp)
+		if p.capture != nil && body != nil {
+			perr.Request = p.captured(httpReq.Header, body)
+		}
+		return nil, perr
+	}
+	return httpResp, nil
+}
+
+func (p *OpenAIProvider) errorFromResponse(httpResp *http.Response) *ProviderError {
+	data, _ := io.ReadAll(io.LimitReader(httpResp.Body, 64<<10))
+
+	perr := &ProviderError{Provider: p.id, StatusCode: httpResp.StatusCode, Message: strings.TrimSpace(string(data))}
+	var body openAIErrorBody
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		perr.Message = body.Error.Message
+		perr.Code = body.Error.Code
+		if perr.Code == "" {
+			perr.Code = body.Error.Type
+		}
+	}
+	return perr
+}
+
+// captureRequest records the request httpResp answered, which was sent with
+// body, on resp if capture is enabled.
+func (p *OpenAIProvider) captureRequest(resp *ChatResponse, httpResp *http.Response, body []byte) {
+	if p.capture != nil {
+		resp.SetMetadata(MetadataRawRequest, p.captured(httpResp.Request.Header, body))
+	}
+}
+
+// captured returns a copy of a request with its credential headers masked and
+// the string values in its JSON body redacted.
+func (p *OpenAIProvider) captured(header http.Header, body []byte) *CapturedRequest {
+	header = header.Clone()
+	for _, k := range reservedHeaders {
+		if _, ok := header[k]; ok {
+			header[k] = []string{"[REDACTED]"}
+		}
+	}
+	return &CapturedRequest{Header: header, Body: redactJSON(body, p.capture)}
+}
+
+// redactJSON applies redact to every string in the JSON document data,
+// leaving keys, numbers and structure alone, so the redacted body stays
+// valid JSON. A body that is not JSON is redacted as a whole, as a string.
+func redactJSON(data []byte, redact Redactor) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		v = string(data)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactValue(v, redact)); err != nil {
+		return nil
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactValue redacts the strings in a decoded JSON value in place.
+func redactValue(v any, redact Redactor) any {
+	switch v := v.(type) {
+	case string:
+		return redact(v)
+	case []any:
+		for i, x := range v {
+			v[i] = redactValue(x, redact)
+		}
+	case map[string]any:
+		for k, x := range v {
+			v[k] = redactValue(x, redact)
+		}
+	}
+	return v
+}
+
+// encode serializes req for the chat completions API, adding the grammar if
+// the server supports one and rejecting options it cannot express.
+func (p *OpenAIProvider) encode(req *ChatRequest, extra map[string]any) ([]byte, error) {
+	if err := checkGrammar(p, req); err != nil {
+		return nil, err
+	}
+	if err := checkBuiltinTools(p, req); err != nil {
+		return nil, err
+	}
+	if req.ReasoningSummary != "" && req.ReasoningSummary != ReasoningSummaryNone {
+		return nil, fmt.Errorf("%w: %s: reasoning summaries need the /responses API", ErrInvalidRequest, p.id)
+	}
+	if req.PromptID != "" {
+		return nil, fmt.Errorf("%w: %s: stored prompts need the /responses API", ErrInvalidRequest, p.id)
+	}
+	if req.Grammar != "" {
+		if extra == nil {
+			extra = map[string]any{}
+		}
+		extra["grammar"] = req.Grammar
+	}
+	return encodeRequest(req, extra)
+}
+
+// encodeRequest serializes req and merges in additional top-level fields.
+func encodeRequest(req *ChatRequest, extra map[string]any) ([]byte, error) {
+	data, err := json.Marshal(req)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range extra {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		fields[k] = raw
+	}
+	return json.Marshal(fields)
+}
+
+// readSSE calls onData with the payload of each server-sent event "data:" line
+// until the stream ends or the "[DONE]" sentinel is seen.
+func readSSE(r io.Reader, onData func(data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+		if err := onData([]byte(data)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Capabilities reports the features of the chat completions API.
+func (p *OpenAIProvider) Capabilities() Capabilities {
+	return Capabilities{Tools: true, Seed: true, Grammar: p.grammar, JSONMode: true, StructuredOutput: true, MaxEmbeddingBatch: 2048}
+}
@@ -0,0 +1,396 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openAIServer is a fake chat completions API that records request bodies.
+type openAIServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+// newOpenAIServer starts a fake API answering every request with handle.
+func newOpenAIServer(t *testing.T, handle func(w http.ResponseWriter, body map[string]any)) *openAIServer {
+	t.Helper()
+	s := &openAIServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &body); err != nil {
+				t.Errorf("request body is not JSON: %v", err)
+			}
+		}
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		s.mu.Unlock()
+		handle(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// provider returns an OpenAIProvider for the server, configured by cfg.
+func (s *openAIServer) provider(cfg OpenAIConfig) *OpenAIProvider {
+	cfg.BaseURL = s.URL
+	cfg.HTTPClient = s.Client()
+	return NewOpenAIProvider(cfg)
+}
+
+// lastBody returns the body of the most recent request.
+func (s *openAIServer) lastBody() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies[len(s.bodies)-1]
+}
+
+// writeSSE writes events as a server-sent event stream ending in [DONE].
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, ev := range events {
+		io.WriteString(w, "data: "+ev+"\n\n")
+	}
+	io.WriteString(w, "data: [DONE]\n\n")
+}
+
+// writeJSON writes v as the response body.
+func writeJSON(w http.ResponseWriter, v string) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, v)
+}
+
+func TestOpenAIStreamUsageOption(t *testing.T) {
+	tests := []struct {
+		name        string
+		omit        bool
+		streamUsage bool
+		want        bool
+	}{
+		{"default on", false, false, true},
+		{"omitted by config", true, false, false},
+		{"requested despite config", true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+				writeSSE(w, `{"choices":[{"index":0,"delta":{"content":"hi"}}]}`)
+			})
+			p := srv.provider(OpenAIConfig{OmitStreamUsage: tt.omit})
+			chunks, err := p.ChatStream(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi"), StreamUsage: tt.streamUsage})
+			if err != nil {
+				t.Fatal(err)
+			}
+			collectStream(chunks)
+
+			body := srv.lastBody()
+			opts, ok := body["stream_options"].(map[string]any)
+			if got := ok && opts["include_usage"] == true; got != tt.want {
+				t.Errorf("stream_options = %v, want include_usage %v", body["stream_options"], tt.want)
+			}
+			if body["stream"] != true {
+				t.Errorf("stream = %v, want true", body["stream"])
+			}
+		})
+	}
+}
+
+func TestOpenAIStreamParsesUsage(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeSSE(w,
+			`{"model":"m-2024","choices":[{"index":0,"delta":{"content":"Hel"}}]}`,
+			`{"model":"m-2024","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"length"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+		)
+	})
+	chunks, err := srv.provider(OpenAIConfig{}).ChatStream(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, final := collectStream(chunks)
+	if content != "Hello" {
+		t.Errorf("content = %q, want Hello", content)
+	}
+	if final.Reason != StreamCompleted || final.Err != nil {
+		t.Errorf("final = %+v, want completed", final)
+	}
+	if final.Model != "m-2024" || final.FinishReason != "length" {
+		t.Errorf("final model %q, finish reason %q; want m-2024, length", final.Model, final.FinishReason)
+	}
+	want := UsageStats{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}
+	if final.Usage == nil || *final.Usage != want {
+		t.Errorf("usage = %+v, want %+v", final.Usage, want)
+	}
+}
+
+func TestOpenAIChat(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeJSON(w, `{"model":"m-2024","system_fingerprint":"fp_1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop",
+			"logprobs":{"content":[{"token":"hi","logprob":-0.5}]}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	})
+	resp, err := srv.provider(OpenAIConfig{}).Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi"), Logprobs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "hi" || resp.Model != "m-2024" || resp.FinishReason != "stop" || resp.Fingerprint != "fp_1" {
+		t.Errorf("resp = %+v", resp)
+	}
+	if len(resp.Logprobs) != 1 || resp.Logprobs[0].Token != "hi" || resp.Logprobs[0].Logprob != -0.5 {
+		t.Errorf("logprobs = %+v", resp.Logprobs)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if body := srv.lastBody(); body["logprobs"] != true || body["stream"] != nil {
+		t.Errorf("body = %v", body)
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"message":"slow down","type":"rate_limit"}}`, ErrRateLimited},
+		{"unknown model", http.StatusNotFound, `{"error":{"message":"no such model","code":"model_not_found"}}`, ErrModelNotAvailable},
+		{"deprecated model", http.StatusGone, `{"error":{"message":"gone","code":"model_decommissioned"}}`, ErrModelDeprecated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+			_, err := srv.provider(OpenAIConfig{}).Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")})
+			var perr *ProviderError
+			if !errors.As(err, &perr) || perr.StatusCode != tt.status {
+				t.Fatalf("err = %v, want ProviderError with status %d", err, tt.status)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if strings.Contains(perr.Message, "{") {
+				t.Errorf("message %q was not decoded from the error body", perr.Message)
+			}
+		})
+	}
+}
+
+func TestParseCitations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations string
+		want        []Citation
+	}{
+		{"none", `[]`, nil},
+		{
+			"url citation",
+			`[{"type":"url_citation","url_citation":{"url":"https://go.dev","title":"Go","start_index":6,"end_index":12}}]`,
+			[]Citation{{Source: "url_citation", Title: "Go", URL: "https://go.dev", Text: "gophér", StartIndex: 6, EndIndex: 12}},
+		},
+		{
+			"file citation",
+			`[{"type":"file_citation","file_citation":{"file_id":"file-1","filename":"notes.md","start_index":0,"end_index":5}}]`,
+			[]Citation{{Source: "file_citation", Title: "notes.md", FileID: "file-1", Text: "Hello", StartIndex: 0, EndIndex: 5}},
+		},
+		{
+			"out of range span",
+			`[{"type":"url_citation","url_citation":{"url":"https://go.dev","start_index":10,"end_index":99}}]`,
+			[]Citation{{Source: "url_citation", URL: "https://go.dev", StartIndex: 10, EndIndex: 99}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := openAIMessage{Content: "Hello gophér!"}
+			if err := json.Unmarshal([]byte(tt.annotations), &msg.Annotations); err != nil {
+				t.Fatal(err)
+			}
+			if got := parseCitations(msg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("citations = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIGrammar(t *testing.T) {
+	const grammar = `root ::= "yes" | "no"`
+	tests := []struct {
+		name     string
+		supports bool
+		grammar  string
+		wantErr  bool
+	}{
+		{"no grammar", false, "", false},
+		{"supported", true, grammar, false},
+		{"unsupported", false, grammar, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+				writeJSON(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"yes"},"finish_reason":"stop"}]}`)
+			})
+			_, err := srv.provider(OpenAIConfig{Grammar: tt.supports}).Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("ok?"), Grammar: tt.grammar})
+			if tt.wantErr {
+				if !errors.Is(err, ErrGrammarNotSupported) || len(srv.bodies) != 0 {
+					t.Fatalf("err = %v, want ErrGrammarNotSupported before sending", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := srv.lastBody()["grammar"].(string); got != tt.grammar {
+				t.Errorf("grammar field = %q, want %q", got, tt.grammar)
+			}
+		})
+	}
+}
+
+func TestOpenAIStreamChoiceIndex(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeSSE(w,
+			`{"choices":[{"index":0,"delta":{"content":"A"}},{"index":1,"delta":{"content":"B"}}]}`,
+			`{"choices":[{"index":1,"delta":{"content":"b"}}]}`,
+		)
+	})
+	chunks, err := srv.provider(OpenAIConfig{}).ChatStream(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi"), N: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses, _ := DemuxChoices(chunks)
+	if len(responses) != 2 || responses[0].Content != "A" || responses[1].Content != "Bb" {
+		t.Errorf("responses = %+v", responses)
+	}
+	if srv.lastBody()["n"] != float64(2) {
+		t.Errorf("n = %v, want 2", srv.lastBody()["n"])
+	}
+}
+
+func TestOpenAICaptureRequests(t *testing.T) {
+	const secret = "sk-abcdefghijklmnopqrstuvwx"
+	tests := []struct {
+		name      string
+		cfg       OpenAIConfig
+		status    int
+		wantEmail string // The email in the captured prompt; empty if nothing is captured
+	}{
+		{name: "off", cfg: OpenAIConfig{APIKey: "key"}, status: http.StatusOK},
+		{name: "secrets only by default", cfg: OpenAIConfig{APIKey: "key", CaptureRequests: true}, status: http.StatusOK, wantEmail: "ada@example.com"},
+		{name: "custom redactor", cfg: OpenAIConfig{APIKey: "key", CaptureRequests: true, CaptureRedactor: DefaultRedactor}, status: http.StatusOK, wantEmail: "[EMAIL]"},
+		{name: "attached to errors", cfg: OpenAIConfig{APIKey: "key", CaptureRequests: true}, status: http.StatusBadRequest, wantEmail: "ada@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+				if tt.status != http.StatusOK {
+					w.WriteHeader(tt.status)
+					io.WriteString(w, `{"error":{"message":"bad","code":"invalid"}}`)
+					return
+				}
+				writeJSON(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+			})
+			tt.cfg.Headers = http.Header{"X-Trace": {"t1"}}
+			req := &ChatRequest{Model: "m", Messages: userMessages("mail ada@example.com with " + secret), MaxTokens: 12345678901}
+			resp, err := srv.provider(tt.cfg).Chat(context.Background(), req)
+
+			var captured *CapturedRequest
+			if tt.status != http.StatusOK {
+				var perr *ProviderError
+				if !errors.As(err, &perr) {
+					t.Fatalf("err = %v, want a ProviderError", err)
+				}
+				captured = perr.Request
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				captured, _ = resp.Metadata[MetadataRawRequest].(*CapturedRequest)
+			}
+			if tt.wantEmail == "" {
+				if captured != nil {
+					t.Fatalf("captured %+v with capture off", captured)
+				}
+				return
+			}
+			if captured == nil {
+				t.Fatal("request not captured")
+			}
+
+			if got := captured.Header.Get("Authorization"); got != "[REDACTED]" {
+				t.Errorf("Authorization = %q, want it masked", got)
+			}
+			if got := captured.Header.Get("X-Trace"); got != "t1" {
+				t.Errorf("X-Trace = %q, want it kept", got)
+			}
+			var body struct {
+				MaxTokens int64     `json:"max_tokens"`
+				Messages  []Message `json:"messages"`
+			}
+			if err := json.Unmarshal(captured.Body, &body); err != nil {
+				t.Fatalf("captured body is not JSON: %v", err)
+			}
+			want := "mail " + tt.wantEmail + " with [SECRET]"
+			if body.MaxTokens != 12345678901 || len(body.Messages) != 1 || body.Messages[0].Content != want {
+				t.Errorf("captured body = %s, want content %q and max_tokens intact", captured.Body, want)
+			}
+		})
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"strings only", `{"token_sk-abcdefghijklmnopqrstu":"sk-abcdefghijklmnopqrstu","n":1.50}`, `{"n":1.50,"token_sk-abcdefghijklmnopqrstu":"[SECRET]"}`},
+		{"nested", `{"a":[{"b":"x@y.io"}],"c":null}`, `{"a":[{"b":"[EMAIL]"}],"c":null}`},
+		{"html kept", `{"a":"<b>&"}`, `{"a":"<b>&"}`},
+		{"not json", `key=sk-abcdefghijklmnopqrstu`, `"key=[SECRET]"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactJSON([]byte(tt.in), DefaultRedactor)); got != tt.want {
+				t.Errorf("redactJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIStreamReceived(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeSSE(w, `{"choices":[{"delta":{"content":"a"}}]}`, `{"choices":[{"delta":{"content":"b"}}]}`)
+	})
+	start := time.Now()
+	chunks, err := srv.provider(OpenAIConfig{}).ChatStream(context.Background(), &ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prev time.Time
+	n := 0
+	for chunk := range chunks {
+		n++
+		if chunk.Received.Before(start) || chunk.Received.After(time.Now()) || chunk.Received.Before(prev) {
+			t.Errorf("chunk %d received at %v, want in order since %v", n, chunk.Received, start)
+		}
+		prev = chunk.Received
+	}
+	if n != 3 {
+		t.Errorf("got %d chunks, want two content chunks and the terminal one", n)
+	}
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrUnauthorizedOrigin is returned for requests without a valid origin.
+var ErrUnauthorizedOrigin = errors.New("unauthorized request origin")
+
+// MetadataOrigin holds the validated origin of the request.
+const MetadataOrigin = "origin"
+
+type originKey struct{}
+
+// WithOrigin returns a context carrying token, the calling service's identity
+// or a token that proves it, for OriginProvider to validate.
+func WithOrigin(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, originKey{}, token)
+}
+
+// OriginFromContext returns the token set by WithOrigin.
+func OriginFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(originKey{}).(string)
+	return token, ok && token != ""
+}
+
+// OriginValidator checks an origin token and returns the origin it identifies.
+type OriginValidator func(ctx context.Context, token string) (origin string, err error)
+
+// AllowOrigins returns an OriginValidator accepting the named origins as their
+// own tokens.
+func AllowOrigins(origins ...string) OriginValidator {
+	return func(_ context.Context, token string) (string, error) {
+		if !slices.Contains(origins, token) {
+			return "", fmt.Errorf("%w: %q is not allowed", ErrUnauthorizedOrigin, token)
+		}
+		return token, nil
+	}
+}
+
+// OriginProvider wraps a Provider and rejects unattributed traffic: every
+// request must carry an origin token, set with WithOrigin, that the validator
+// accepts. Responses record the validated origin in their metadata.
+type OriginProvider struct {
+	Provider
+	validate OriginValidator
+}
+
+// NewOriginProvider creates a provider that admits requests validate accepts.
+func NewOriginProvider(inner Provider, validate OriginValidator) *OriginProvider {
+	return &OriginProvider{Provider: inner, validate: validate}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *OriginProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat validates the request's origin and forwards it.
+func (p *OriginProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	origin, err := p.origin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.SetMetadata(MetadataOrigin, origin)
+	return resp, nil
+}
+
+// ChatStream validates the request's origin and starts a stream.
+func (p *OriginProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	inner, err := streamingInner(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.origin(ctx); err != nil {
+		return nil, err
+	}
+	return inner.ChatStream(ctx, req)
+}
+
+// origin validates the token on ctx. Validator errors that do not already
+// say so are wrapped in ErrUnauthorizedOrigin.
+func (p *OriginProvider) origin(ctx context.Context) (string, error) {
+	token, ok := OriginFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("%w: no origin on request", ErrUnauthorizedOrigin)
+	}
+	origin, err := p.validate(ctx, token)
+	if err != nil && !errors.Is(err, ErrUnauthorizedOrigin) {
+		err = fmt.Errorf("%w: %v", ErrUnauthorizedOrigin, err)
+	}
+	return origin, err
+}
@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOriginProvider(t *testing.T) {
+	errKeyServer := errors.New("key server unavailable")
+	tokens := func(_ context.Context, token string) (string, error) {
+		switch token {
+		case "tok-billing":
+			return "billing", nil
+		case "tok-broken":
+			return "", errKeyServer
+		}
+		return "", ErrUnauthorizedOrigin
+	}
+
+	tests := []struct {
+		name       string
+		validate   OriginValidator
+		ctx        context.Context
+		wantOrigin string
+		wantErr    error
+	}{
+		{name: "allowed", validate: AllowOrigins("search", "billing"), ctx: WithOrigin(context.Background(), "search"), wantOrigin: "search"},
+		{name: "not allowed", validate: AllowOrigins("search"), ctx: WithOrigin(context.Background(), "ads"), wantErr: ErrUnauthorizedOrigin},
+		{name: "missing", validate: AllowOrigins("search"), ctx: context.Background(), wantErr: ErrUnauthorizedOrigin},
+		{name: "empty", validate: AllowOrigins(""), ctx: WithOrigin(context.Background(), ""), wantErr: ErrUnauthorizedOrigin},
+		{name: "token maps to origin", validate: tokens, ctx: WithOrigin(context.Background(), "tok-billing"), wantOrigin: "billing"},
+		{name: "validator failure", validate: tokens, ctx: WithOrigin(context.Background(), "tok-broken"), wantErr: ErrUnauthorizedOrigin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := streamingReply("ok")
+			p := NewOriginProvider(inner, tt.validate)
+
+			resp, err := p.Chat(tt.ctx, &ChatRequest{})
+			_, streamErr := p.ChatStream(tt.ctx, &ChatRequest{})
+			if tt.wantErr != nil {
+				for _, err := range []error{err, streamErr} {
+					if !errors.Is(err, tt.wantErr) {
+						t.Errorf("err = %v, want %v", err, tt.wantErr)
+					}
+				}
+				if len(inner.requests()) != 0 {
+					t.Error("rejected request was forwarded")
+				}
+				return
+			}
+			if err != nil || streamErr != nil {
+				t.Fatal(err, streamErr)
+			}
+			if got := resp.Metadata[MetadataOrigin]; got != tt.wantOrigin {
+				t.Errorf("origin = %v, want %q", got, tt.wantOrigin)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// OutputCapError reports a request whose MaxTokens exceeds its model's ceiling.
+type OutputCapError struct {
+	Model     string
+	MaxTokens int
+	Ceiling   int
+}
+
+func (e *OutputCapError) Error() string {
+	return fmt.Sprintf("%v: max_tokens=%d exceeds the %d ceiling for %s", ErrInvalidRequest, e.MaxTokens, e.Ceiling, e.Model)
+}
+
+// Is reports whether target is ErrInvalidRequest.
+func (e *OutputCapError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// OutputCapProvider wraps a Provider and enforces per-model ceilings on
+// MaxTokens, guarding against runaway generation on expensive models. In
+// strict mode requests over the ceiling are rejected with an OutputCapError;
+// otherwise they are clamped. Requests that leave MaxTokens unset get the
+// ceiling, and models without a ceiling are not limited.
+type OutputCapProvider struct {
+	Provider
+	ceilings map[string]int
+	strict   bool
+}
+
+// NewOutputCapProvider creates a provider that caps MaxTokens at ceilings[model].
+func NewOutputCapProvider(inner Provider, ceilings map[string]int, strict bool) *OutputCapProvider {
+	return &OutputCapProvider{Provider: inner, ceilings: ceilings, strict: strict}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *OutputCapProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat applies the model's ceiling to the request and forwards it.
+func (p *OutputCapProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	capped, err := p.apply(req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Chat(ctx, capped)
+}
+
+// ChatStream applies the model's ceiling to the request and starts a stream.
+func (p *OutputCapProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	inner, err := streamingInner(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	capped, err := p.apply(req)
+	if err != nil {
+		return nil, err
+	}
+	return inner.ChatStream(ctx, capped)
+}
+
+func (p *OutputCapProvider) apply(req *ChatRequest) (*ChatRequest, error) {
+	ceiling, ok := p.ceilings[req.Model]
+	if !ok || ceiling <= 0 || (req.MaxTokens > 0 && req.MaxTokens <= ceiling) {
+		return req, nil
+	}
+	if p.strict && req.MaxTokens > ceiling {
+		return nil, &OutputCapError{Model: req.Model, MaxTokens: req.MaxTokens, Ceiling: ceiling}
+	}
+	capped := *req
+	capped.MaxTokens = ceiling
+	return &capped, nil
+}
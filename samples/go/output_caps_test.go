@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOutputCapProvider(t *testing.T) {
+	ceilings := map[string]int{"big": 1000}
+	tests := []struct {
+		name      string
+		strict    bool
+		model     string
+		maxTokens int
+		want      int // MaxTokens sent to the inner provider
+		wantErr   bool
+	}{
+		{name: "within the ceiling", model: "big", maxTokens: 500, want: 500},
+		{name: "clamped", model: "big", maxTokens: 5000, want: 1000},
+		{name: "unset gets the ceiling", model: "big", want: 1000},
+		{name: "unset in strict mode gets the ceiling", strict: true, model: "big", want: 1000},
+		{name: "rejected in strict mode", strict: true, model: "big", maxTokens: 5000, wantErr: true},
+		{name: "model without a ceiling", model: "small", maxTokens: 5000, want: 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeStreamer{fakeProvider: &fakeProvider{}, stream: func(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+				return streamOf(), nil
+			}}
+			p := NewOutputCapProvider(inner, ceilings, tt.strict)
+			req := &ChatRequest{Model: tt.model, MaxTokens: tt.maxTokens}
+
+			_, chatErr := p.Chat(context.Background(), req)
+			_, streamErr := p.ChatStream(context.Background(), req)
+			for _, err := range []error{chatErr, streamErr} {
+				if !tt.wantErr {
+					if err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				var capErr *OutputCapError
+				if !errors.As(err, &capErr) || !errors.Is(err, ErrInvalidRequest) || capErr.Ceiling != 1000 {
+					t.Fatalf("err = %v, want an OutputCapError", err)
+				}
+			}
+			if tt.wantErr {
+				if len(inner.requests()) != 0 {
+					t.Error("rejected request was forwarded")
+				}
+				return
+			}
+			for _, sent := range inner.requests() {
+				if sent.MaxTokens != tt.want {
+					t.Errorf("MaxTokens = %d, want %d", sent.MaxTokens, tt.want)
+				}
+			}
+			if req.MaxTokens != tt.maxTokens {
+				t.Error("caller's request was modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Range is an inclusive range of valid values. The zero Range is unbounded.
+type Range struct {
+	Min float64
+	Max float64
+}
+
+func (r Range) bounded() bool {
+	return r != Range{}
+}
+
+// ParameterRanges are the valid sampling parameter ranges for a provider.
+type ParameterRanges struct {
+	Temperature      Range
+	TopP             Range
+	FrequencyPenalty Range
+	PresencePenalty  Range
+}
+
+// DefaultParameterRanges are the documented ranges of common providers, keyed by provider type.
+var DefaultParameterRanges = map[string]ParameterRanges{
+	"openai": {
+		Temperature:      Range{Min: 0, Max: 2},
+		TopP:             Range{Min: 0, Max: 1},
+		FrequencyPenalty: Range{Min: -2, Max: 2},
+		PresencePenalty:  Range{Min: -2, Max: 2},
+	},
+	"azure": {
+		Temperature:      Range{Min: 0, Max: 2},
+		TopP:             Range{Min: 0, Max: 1},
+		FrequencyPenalty: Range{Min: -2, Max: 2},
+		PresencePenalty:  Range{Min: -2, Max: 2},
+	},
+	"anthropic": {
+		Temperature: Range{Min: 0, Max: 1},
+		TopP:        Range{Min: 0, Max: 1},
+	},
+	"ollama": {
+		// Ollama does not bound temperature; 5 is a practical ceiling.
+		Temperature:      Range{Min: 0, Max: 5},
+		TopP:             Range{Min: 0, Max: 1},
+		FrequencyPenalty: Range{Min: -2, Max: 2},
+		PresencePenalty:  Range{Min: -2, Max: 2},
+	},
+}
+
+// ParameterRangeError reports a sampling parameter outside the provider's valid range.
+type ParameterRangeError struct {
+	Provider string
+	Param    string
+	Value    float64
+	Range    Range
+}
+
+func (e *ParameterRangeError) Error() string {
+	return fmt.Sprintf("%v: %s %s=%g outside [%g, %g]", ErrInvalidRequest, e.Provider, e.Param, e.Value, e.Range.Min, e.Range.Max)
+}
+
+// Is reports whether target is ErrInvalidRequest.
+func (e *ParameterRangeError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// ParameterRangeProvider wraps a Provider and validates sampling parameters
+// against its valid ranges. In strict mode out-of-range values are rejected
+// with a ParameterRangeError; otherwise they are clamped.
+type ParameterRangeProvider struct {
+	Provider
+	ranges ParameterRanges
+	strict bool
+}
+
+// NewParameterRangeProvider creates a provider that enforces ranges on every request.
+func NewParameterRangeProvider(inner Provider, ranges ParameterRanges, strict bool) *ParameterRangeProvider {
+	return &ParameterRangeProvider{Provider: inner, ranges: ranges, strict: strict}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ParameterRangeProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat validates or clamps the request's sampling parameters and forwards it.
+func (p *ParameterRangeProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	normalized := *req
+	params := []struct {
+		name  string
+		value *float64
+		rng   Range
+	}{
+		{"temperature", &normalized.Temperature, p.ranges.Temperature},
+		{"top_p", &normalized.TopP, p.ranges.TopP},
+		{"frequency_penalty", &normalized.FrequencyPenalty, p.ranges.FrequencyPenalty},
+		{"presence_penalty", &normalized.PresencePenalty, p.ranges.PresencePenalty},
+	}
+
+	for _, param := range params {
+		v := *param.value
+		if !param.rng.bounded() || (v >= param.rng.Min && v <= param.rng.Max) {
+			continue
+		}
+		if p.strict {
+			return nil, &ParameterRangeError{Provider: p.ID(), Param: param.name, Value: v, Range: param.rng}
+		}
+		*param.value = min(max(v, param.rng.Min), param.rng.Max)
+	}
+
+	return p.Provider.Chat(ctx, &normalized)
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParameterRangeProvider(t *testing.T) {
+	ranges := DefaultParameterRanges["anthropic"]
+	tests := []struct {
+		name      string
+		strict    bool
+		req       ChatRequest
+		wantTemp  float64
+		wantTopP  float64
+		wantParam string // Rejected parameter in strict mode, if any
+	}{
+		{"in range", false, ChatRequest{Temperature: 0.7, TopP: 0.9}, 0.7, 0.9, ""},
+		{"clamped high", false, ChatRequest{Temperature: 1.5}, 1, 0, ""},
+		{"clamped low", false, ChatRequest{Temperature: -1, TopP: 2}, 0, 1, ""},
+		{"unbounded parameter", false, ChatRequest{FrequencyPenalty: 9}, 0, 0, ""},
+		{"strict rejects", true, ChatRequest{Temperature: 0.5, TopP: 1.2}, 0, 0, "top_p"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			p := NewParameterRangeProvider(inner, ranges, tt.strict)
+			req := tt.req
+			_, err := p.Chat(context.Background(), &req)
+			if tt.wantParam != "" {
+				var rangeErr *ParameterRangeError
+				if !errors.As(err, &rangeErr) || rangeErr.Param != tt.wantParam || !errors.Is(err, ErrInvalidRequest) {
+					t.Fatalf("err = %v, want ParameterRangeError for %s", err, tt.wantParam)
+				}
+				if len(inner.requests()) != 0 {
+					t.Error("rejected request reached the provider")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			sent := inner.requests()[0]
+			if sent.Temperature != tt.wantTemp || sent.TopP != tt.wantTopP {
+				t.Errorf("sent temperature %g, top_p %g; want %g, %g", sent.Temperature, sent.TopP, tt.wantTemp, tt.wantTopP)
+			}
+			if req.Temperature != tt.req.Temperature || req.TopP != tt.req.TopP {
+				t.Error("caller's request was modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"io"
+	"math/bits"
+	"slices"
+	"sync"
+)
+
+// PayloadMetrics receives the sizes of the HTTP payloads a provider sends and
+// receives, for capacity planning. Implementations must be safe for
+// concurrent use.
+type PayloadMetrics interface {
+	ObservePayload(provider, model string, requestBytes, responseBytes int64)
+}
+
+// PayloadHistograms is an in-memory PayloadMetrics that keeps power-of-two
+// histograms of request and response sizes per provider and model.
+type PayloadHistograms struct {
+	mu     sync.Mutex
+	series map[payloadSeries]*PayloadHistogram
+}
+
+type payloadSeries struct {
+	provider, model string
+}
+
+// PayloadHistogram counts payloads by size. Bucket i counts payloads of fewer
+// than 1<<i bytes that did not fit in a smaller bucket.
+type PayloadHistogram struct {
+	Requests, Responses []int64
+	RequestBytes        int64 // Sum of all request sizes
+	ResponseBytes       int64 // Sum of all response sizes
+	Count               int64
+}
+
+// NewPayloadHistograms creates an empty set of histograms.
+func NewPayloadHistograms() *PayloadHistograms {
+	return &PayloadHistograms{series: make(map[payloadSeries]*PayloadHistogram)}
+}
+
+// ObservePayload implements PayloadMetrics.
+func (h *PayloadHistograms) ObservePayload(provider, model string, requestBytes, responseBytes int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := payloadSeries{provider, model}
+	s, ok := h.series[key]
+	if !ok {
+		s = &PayloadHistogram{}
+		h.series[key] = s
+	}
+	s.Requests = observeSize(s.Requests, requestBytes)
+	s.Responses = observeSize(s.Responses, responseBytes)
+	s.RequestBytes += requestBytes
+	s.ResponseBytes += responseBytes
+	s.Count++
+}
+
+// Histogram returns a copy of the histogram for provider and model.
+func (h *PayloadHistograms) Histogram(provider, model string) (PayloadHistogram, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[payloadSeries{provider, model}]
+	if !ok {
+		return PayloadHistogram{}, false
+	}
+	out := *s
+	out.Requests = slices.Clone(s.Requests)
+	out.Responses = slices.Clone(s.Responses)
+	return out, true
+}
+
+func observeSize(buckets []int64, size int64) []int64 {
+	i := bits.Len64(uint64(max(size, 0)))
+	for len(buckets) <= i {
+		buckets = append(buckets, 0)
+	}
+	buckets[i]++
+	return buckets
+}
+
+// meteredBody counts the bytes read from a response body and reports the
+// total when it is closed, so streamed responses are measured in full.
+type meteredBody struct {
+	io.ReadCloser
+	n       int64
+	onClose func(n int64)
+	once    sync.Once
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *meteredBody) Close() error {
+	b.once.Do(func() { b.onClose(b.n) })
+	return b.ReadCloser.Close()
+}
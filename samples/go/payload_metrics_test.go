@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestPayloadHistograms(t *testing.T) {
+	tests := []struct {
+		name        string
+		requests    []int64
+		wantBuckets []int64
+		wantTotal   int64
+	}{
+		{name: "empty payload", requests: []int64{0}, wantBuckets: []int64{1}, wantTotal: 0},
+		{name: "powers of two", requests: []int64{1, 2, 3, 4}, wantBuckets: []int64{0, 1, 2, 1}, wantTotal: 10},
+		{name: "large payload", requests: []int64{1000}, wantBuckets: []int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, wantTotal: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewPayloadHistograms()
+			for _, size := range tt.requests {
+				h.ObservePayload("p", "m", size, 0)
+			}
+			got, ok := h.Histogram("p", "m")
+			if !ok {
+				t.Fatal("no histogram recorded")
+			}
+			if !slices.Equal(got.Requests, tt.wantBuckets) {
+				t.Errorf("request buckets = %v, want %v", got.Requests, tt.wantBuckets)
+			}
+			if got.RequestBytes != tt.wantTotal || got.Count != int64(len(tt.requests)) {
+				t.Errorf("totals = %d bytes over %d, want %d over %d", got.RequestBytes, got.Count, tt.wantTotal, len(tt.requests))
+			}
+			if _, ok := h.Histogram("p", "other"); ok {
+				t.Error("histogram reported for an unobserved model")
+			}
+		})
+	}
+}
+
+func TestOpenAIPayloadMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream bool
+		body   string
+	}{
+		{name: "chat", body: `{"model":"m","choices":[{"message":{"content":"hi"},"finish_reason":"stop"}]}`},
+		{
+			name:   "stream",
+			stream: true,
+			body: "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: [DONE]\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+				if tt.stream {
+					w.Header().Set("Content-Type", "text/event-stream")
+					io.WriteString(w, tt.body)
+					return
+				}
+				writeJSON(w, tt.body)
+			})
+			metrics := NewPayloadHistograms()
+			p := srv.provider(OpenAIConfig{Metrics: metrics})
+			req := &ChatRequest{Model: "m", Messages: userMessages("hello")}
+
+			if tt.stream {
+				chunks, err := p.ChatStream(context.Background(), req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				collectStream(chunks)
+			} else if _, err := p.Chat(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			got, ok := metrics.Histogram(p.ID(), "m")
+			if !ok {
+				t.Fatal("no payload observed")
+			}
+			if got.Count != 1 || got.RequestBytes == 0 || got.ResponseBytes != int64(len(tt.body)) {
+				t.Errorf("histogram = %+v, want one request and %d response bytes", got, len(tt.body))
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pinger is implemented by providers with a cheaper liveness check than ListModels.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// WithPrewarm makes Register warm each new provider in the background, so the
+// connection (and TLS session) is established before the first real request.
+// Each warm-up is bounded by timeout.
+func WithPrewarm(timeout time.Duration) RegistryOption {
+	return func(r *ProviderRegistry) {
+		r.prewarmTimeout = timeout
+	}
+}
+
+// Warmup pings every registered provider concurrently and returns the result
+// per provider ID. Call it at startup, after registration, to pay connection
+// setup costs before serving traffic.
+func (r *ProviderRegistry) Warmup(ctx context.Context) map[string]error {
+	r.mu.RLock()
+	providers := make(map[string]Provider, len(r.providers))
+	for id, p := range r.providers {
+		providers[id] = p
+	}
+	r.mu.RUnlock()
+
+	results := make(map[string]error, len(providers))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for id, provider := range providers {
+		wg.Add(1)
+		go func(id string, p Provider) {
+			defer wg.Done()
+
+			err := warm(ctx, p)
+
+			mu.Lock()
+			results[id] = err
+			mu.Unlock()
+		}(id, provider)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// warm issues the cheapest available call to p: Ping if supported, otherwise ListModels.
+func warm(ctx context.Context, p Provider) error {
+	if pinger, ok := p.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	_, err := p.ListModels(ctx)
+	return err
+}
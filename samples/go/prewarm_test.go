@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// pingProvider is a fakeProvider with a Ping that reports each call on pinged.
+type pingProvider struct {
+	*fakeProvider
+	err    error
+	pinged chan struct{}
+}
+
+func (p *pingProvider) Ping(context.Context) error {
+	if p.pinged != nil {
+		p.pinged <- struct{}{}
+	}
+	return p.err
+}
+
+func TestRegistryWarmup(t *testing.T) {
+	down := errors.New("connection refused")
+	registry := NewProviderRegistry()
+	registry.Register(&pingProvider{fakeProvider: &fakeProvider{id: "pinger"}})
+	registry.Register(&pingProvider{fakeProvider: &fakeProvider{id: "broken"}, err: down})
+	registry.Register(unreachableProvider{&fakeProvider{id: "lister"}})
+
+	results := registry.Warmup(context.Background())
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{"pinger", false},
+		{"broken", true},
+		{"lister", true}, // Falls back to ListModels, which fails
+	}
+	for _, tt := range tests {
+		if err, ok := results[tt.id]; !ok || (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v (reported %v), want error %v", tt.id, err, ok, tt.wantErr)
+		}
+	}
+}
+
+func TestRegisterPrewarms(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []RegistryOption
+		wantPing bool
+	}{
+		{"with prewarm", []RegistryOption{WithPrewarm(time.Second)}, true},
+		{"without prewarm", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinged := make(chan struct{}, 1)
+			NewProviderRegistry(tt.opts...).Register(&pingProvider{fakeProvider: &fakeProvider{}, pinged: pinged})
+			select {
+			case <-pinged:
+				if !tt.wantPing {
+					t.Error("provider was warmed")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantPing {
+					t.Error("provider was not warmed")
+				}
+			}
+		})
+	}
+}
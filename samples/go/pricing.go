@@ -0,0 +1,231 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PricingSource supplies current model pricing. Providers whose API reports
+// prices can implement it directly; PricingURL and PricingFile read a
+// published CostTable.
+type PricingSource interface {
+	Pricing(ctx context.Context) (CostTable, error)
+}
+
+// PricingURL returns a source that fetches a JSON-encoded CostTable from url.
+// A nil client uses http.DefaultClient.
+func PricingURL(url string, client *http.Client) PricingSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &urlPricing{url: url, client: client}
+}
+
+type urlPricing struct {
+	url    string
+	client *http.Client
+}
+
+func (s *urlPricing) Pricing(ctx context.Context) (CostTable, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("fetch pricing: HTTP %d", resp.StatusCode)
+	}
+
+	var table CostTable
+	if err := json.NewDecoder(resp.Body).Decode(&table); err != nil {
+		return nil, fmt.Errorf("decode pricing: %w", err)
+	}
+	return table, nil
+}
+
+// PricingFile returns a source that reads a JSON-encoded CostTable from path.
+func PricingFile(path string) PricingSource {
+	return filePricing(path)
+}
+
+type filePricing string
+
+func (path filePricing) Pricing(context.Context) (CostTable, error) {
+	data, err := os.ReadFile(string(path))
+	if err != nil {
+		return nil, err
+	}
+	var table CostTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("decode pricing %s: %w", path, err)
+	}
+	return table, nil
+}
+
+// ProviderPricing returns a source that merges the prices reported by the
+// providers registered with r that implement PricingSource, looking through
+// decorators. Providers are discovered on every fetch, so ones registered
+// later are included. Providers that fail are skipped; the fetch fails only
+// if all of them do.
+func ProviderPricing(r *ProviderRegistry) PricingSource {
+	return registryPricing{registry: r}
+}
+
+type registryPricing struct {
+	registry *ProviderRegistry
+}
+
+func (s registryPricing) Pricing(ctx context.Context) (CostTable, error) {
+	ids := s.registry.ListProviders()
+	slices.Sort(ids) // Merge in a stable order when providers price the same model
+
+	table := make(CostTable)
+	var errs []error
+	for _, id := range ids {
+		p, err := s.registry.Get(id)
+		if err != nil {
+			continue // Deregistered since it was listed
+		}
+		source, ok := pricingSourceOf(p)
+		if !ok {
+			continue
+		}
+		prices, err := source.Pricing(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		maps.Copy(table, prices)
+	}
+	if len(table) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return table, nil
+}
+
+// pricingSourceOf returns p, or the innermost provider it wraps, as a
+// PricingSource.
+func pricingSourceOf(p Provider) (PricingSource, bool) {
+	for p != nil {
+		if s, ok := p.(PricingSource); ok {
+			return s, true
+		}
+		u, ok := p.(Unwrapper)
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+	return nil, false
+}
+
+// pricingFetchTimeout bounds a refresh, which is detached from the caller
+// that triggered it so that caller giving up does not fail the refresh.
+const pricingFetchTimeout = 30 * time.Second
+
+// PricingCache combines prices from a PricingSource, cached for a TTL, with a
+// static CostTable used for models the source does not price and while the
+// source is unreachable. A failed refresh is retried sooner than the TTL:
+// after a tenth of it at first, doubling up to the TTL.
+type PricingCache struct {
+	source PricingSource
+	static CostTable
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	table      CostTable
+	fetchedAt  time.Time
+	lastErr    error
+	backoff    time.Duration // Delay after the latest failed refresh; zero when healthy
+	retryAt    time.Time     // No refresh is attempted before this after a failure
+	refreshing chan struct{} // Closed when the refresh in flight, if any, ends
+}
+
+// NewPricingCache creates a cache over source that refreshes after ttl
+// (default 1h) and falls back to static.
+func NewPricingCache(source PricingSource, static CostTable, ttl time.Duration) *PricingCache {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &PricingCache{source: source, static: static, ttl: ttl, now: time.Now, table: static}
+}
+
+// Table returns the current prices, refreshing them from the source if they
+// have expired. Concurrent callers share one refresh, which runs without
+// holding the cache's lock; a caller whose ctx ends first gets the previous
+// prices while the refresh carries on. A failed refresh keeps the previous
+// prices until the retry.
+func (c *PricingCache) Table(ctx context.Context) CostTable {
+	c.mu.Lock()
+	now := c.now()
+	if now.Sub(c.fetchedAt) < c.ttl || now.Before(c.retryAt) {
+		defer c.mu.Unlock()
+		return c.table
+	}
+	done := c.refreshing
+	if done == nil {
+		done = make(chan struct{})
+		c.refreshing = done
+		go c.refresh(context.WithoutCancel(ctx), done)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.table
+}
+
+// refresh fetches prices from the source and closes done when finished.
+func (c *PricingCache) refresh(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ctx, cancel := context.WithTimeout(ctx, pricingFetchTimeout)
+	defer cancel()
+
+	fetched, err := c.source.Pricing(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = nil
+	if c.lastErr = err; err != nil {
+		c.backoff = min(max(c.backoff*2, c.ttl/10), c.ttl)
+		c.retryAt = c.now().Add(c.backoff)
+		return
+	}
+	table := maps.Clone(c.static)
+	if table == nil {
+		table = make(CostTable, len(fetched))
+	}
+	maps.Copy(table, fetched)
+	c.table = table
+	c.fetchedAt = c.now()
+	c.backoff, c.retryAt = 0, time.Time{}
+}
+
+// Err returns the error of the last refresh, if it failed.
+func (c *PricingCache) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastErr
+}
+
+// UsageCost returns the USD cost of usage on model at current prices.
+func (c *PricingCache) UsageCost(ctx context.Context, model string, usage *UsageStats) (float64, error) {
+	return c.Table(ctx).UsageCost(model, usage)
+}
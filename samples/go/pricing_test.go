@@ -0,0 +1,211 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pricingFunc adapts a function to PricingSource.
+type pricingFunc func(ctx context.Context) (CostTable, error)
+
+func (f pricingFunc) Pricing(ctx context.Context) (CostTable, error) { return f(ctx) }
+
+// pricedProvider is a provider that reports its own prices.
+type pricedProvider struct {
+	*fakeProvider
+	prices CostTable
+	err    error
+}
+
+func (p *pricedProvider) Pricing(context.Context) (CostTable, error) { return p.prices, p.err }
+
+func TestPricingCacheRefresh(t *testing.T) {
+	static := CostTable{"a": {PromptPerMillion: 1}, "b": {PromptPerMillion: 2}}
+	fetched := CostTable{"b": {PromptPerMillion: 20}}
+	errDown := errors.New("down")
+
+	type step struct {
+		after time.Duration // Clock advance before the lookup
+		fail  bool          // Whether the source fails if called
+		want  float64       // Price of "b" returned
+		calls int           // Source calls made so far
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "cached until ttl",
+			steps: []step{
+				{want: 20, calls: 1},
+				{after: 30 * time.Minute, want: 20, calls: 1},
+				{after: 31 * time.Minute, want: 20, calls: 2},
+			},
+		},
+		{
+			name: "failure serves static and retries before ttl",
+			steps: []step{
+				{fail: true, want: 2, calls: 1},
+				{after: 5 * time.Minute, want: 2, calls: 1},
+				{after: time.Minute, want: 20, calls: 2},
+			},
+		},
+		{
+			name: "retry backs off while failing",
+			steps: []step{
+				{fail: true, want: 2, calls: 1},
+				{after: 6 * time.Minute, fail: true, want: 2, calls: 2},
+				{after: 6 * time.Minute, want: 2, calls: 2},
+				{after: 6 * time.Minute, want: 20, calls: 3},
+			},
+		},
+		{
+			name: "failure keeps fetched prices",
+			steps: []step{
+				{want: 20, calls: 1},
+				{after: time.Hour, fail: true, want: 20, calls: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, fail := 0, false
+			c := NewPricingCache(pricingFunc(func(context.Context) (CostTable, error) {
+				calls++
+				if fail {
+					return nil, errDown
+				}
+				return fetched, nil
+			}), static, time.Hour)
+			now := time.Now()
+			c.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				fail = s.fail
+				table := c.Table(context.Background())
+				if got := table["b"].PromptPerMillion; got != s.want {
+					t.Errorf("step %d: price = %v, want %v", i, got, s.want)
+				}
+				if table["a"].PromptPerMillion != 1 {
+					t.Errorf("step %d: static price for a missing", i)
+				}
+				if calls != s.calls {
+					t.Errorf("step %d: source calls = %d, want %d", i, calls, s.calls)
+				}
+				if s.fail && !errors.Is(c.Err(), errDown) {
+					t.Errorf("step %d: Err = %v, want %v", i, c.Err(), errDown)
+				}
+			}
+		})
+	}
+}
+
+func TestPricingCacheDetachedRefresh(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	var sawCanceled atomic.Bool
+	c := NewPricingCache(pricingFunc(func(ctx context.Context) (CostTable, error) {
+		calls.Add(1)
+		<-release
+		sawCanceled.Store(ctx.Err() != nil)
+		return CostTable{"m": {PromptPerMillion: 5}}, nil
+	}), nil, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := c.Table(ctx)["m"]; ok {
+				t.Error("canceled caller got prices before the refresh finished")
+			}
+		}()
+	}
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for c.Table(context.Background())["m"].PromptPerMillion != 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("refresh did not complete after its caller gave up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("source calls = %d, want 1 shared refresh", calls.Load())
+	}
+	if sawCanceled.Load() {
+		t.Error("refresh was canceled with its caller")
+	}
+}
+
+func TestProviderPricing(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []Provider
+		want      CostTable
+		wantErr   bool
+	}{
+		{
+			name: "discovers providers through decorators",
+			providers: []Provider{
+				&fakeProvider{id: "plain"},
+				NewModelListCache(&pricedProvider{fakeProvider: &fakeProvider{id: "a"}, prices: CostTable{"m1": {PromptPerMillion: 1}}}, 0),
+				&pricedProvider{fakeProvider: &fakeProvider{id: "b"}, prices: CostTable{"m2": {PromptPerMillion: 2}}},
+			},
+			want: CostTable{"m1": {PromptPerMillion: 1}, "m2": {PromptPerMillion: 2}},
+		},
+		{
+			name: "skips failing providers",
+			providers: []Provider{
+				&pricedProvider{fakeProvider: &fakeProvider{id: "a"}, err: errors.New("down")},
+				&pricedProvider{fakeProvider: &fakeProvider{id: "b"}, prices: CostTable{"m2": {PromptPerMillion: 2}}},
+			},
+			want: CostTable{"m2": {PromptPerMillion: 2}},
+		},
+		{
+			name: "fails when every provider fails",
+			providers: []Provider{
+				&pricedProvider{fakeProvider: &fakeProvider{id: "a"}, err: errors.New("down")},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "no pricing providers",
+			providers: []Provider{&fakeProvider{id: "plain"}},
+			want:      CostTable{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewProviderRegistry()
+			for _, p := range tt.providers {
+				r.Register(p)
+			}
+			got, err := ProviderPricing(r).Pricing(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("table = %v, want %v", got, tt.want)
+			}
+			for model, price := range tt.want {
+				if got[model] != price {
+					t.Errorf("%s = %+v, want %+v", model, got[model], price)
+				}
+			}
+		})
+	}
+}
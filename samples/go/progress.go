@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// ProgressUpdate reports how far a stream has got.
+type ProgressUpdate struct {
+	Tokens  int           // Content chunks received, approximately one token each
+	Bytes   int           // Content bytes received
+	Elapsed time.Duration // Time since the stream started
+	Done    bool          // True for the final update
+}
+
+// ProgressConfig configures a ProgressStreamProvider. At least one of
+// EveryTokens and Interval should be set.
+type ProgressConfig struct {
+	EveryTokens int                  // Report after every this many tokens
+	Interval    time.Duration        // Report at least this often
+	OnProgress  func(ProgressUpdate) // Nil disables reporting
+}
+
+// ProgressStreamProvider wraps a StreamingProvider and reports stream progress
+// to a callback, e.g. to drive a progress bar.
+//
+// The callback runs on its own goroutine and never blocks the stream; if it is
+// slower than the update rate, intermediate updates are skipped in favor of
+// the latest one.
+type ProgressStreamProvider struct {
+	StreamingProvider
+	config ProgressConfig
+}
+
+// NewProgressStreamProvider creates a provider that reports progress with config.
+func NewProgressStreamProvider(inner StreamingProvider, config ProgressConfig) *ProgressStreamProvider {
+	return &ProgressStreamProvider{StreamingProvider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ProgressStreamProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream starts a stream on the wrapped provider and reports its progress.
+func (p *ProgressStreamProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	if p.config.OnProgress == nil {
+		return p.StreamingProvider.ChatStream(ctx, req)
+	}
+
+	start := time.Now()
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	updates := make(chan ProgressUpdate, 1)
+	go func() {
+		for u := range updates {
+			p.config.OnProgress(u)
+		}
+	}()
+
+	// notify replaces any undelivered update with u, so it never blocks.
+	notify := func(u ProgressUpdate) {
+		for {
+			select {
+			case updates <- u:
+				return
+			default:
+			}
+			select {
+			case <-updates:
+			default:
+			}
+		}
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		defer close(updates)
+
+		var ticks <-chan time.Time
+		if p.config.Interval > 0 {
+			ticker := time.NewTicker(p.config.Interval)
+			defer ticker.Stop()
+			ticks = ticker.C
+		}
+
+		var progress ProgressUpdate
+		for {
+			select {
+			case chunk, ok := <-in:
+				chunk = normalizeChunk(streamCtx, chunk, ok)
+				progress.Elapsed = time.Since(start)
+				if chunk.Done {
+					progress.Done = true
+					notify(progress)
+					return chunk
+				}
+
+				emit(chunk)
+				progress.Tokens++
+				progress.Bytes += len(chunk.Content)
+				if p.config.EveryTokens > 0 && progress.Tokens%p.config.EveryTokens == 0 {
+					notify(progress)
+				}
+			case <-ticks:
+				progress.Elapsed = time.Since(start)
+				notify(progress)
+			case <-streamCtx.Done():
+				progress.Elapsed, progress.Done = time.Since(start), true
+				notify(progress)
+				return StreamChunk{Done: true, Reason: ctxEndReason(streamCtx), Err: streamCtx.Err()}
+			}
+		}
+	}), nil
+}
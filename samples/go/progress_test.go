@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProgressStreamProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    ProgressConfig
+		parts     []string
+		wantFinal ProgressUpdate
+	}{
+		{"every token", ProgressConfig{EveryTokens: 1}, []string{"ab", "cd", "e"}, ProgressUpdate{Tokens: 3, Bytes: 5, Done: true}},
+		{"every other token", ProgressConfig{EveryTokens: 2}, []string{"日本", "語"}, ProgressUpdate{Tokens: 2, Bytes: 9, Done: true}},
+		{"interval only", ProgressConfig{Interval: time.Hour}, []string{"a"}, ProgressUpdate{Tokens: 1, Bytes: 1, Done: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := make(chan ProgressUpdate, 16)
+			config := tt.config
+			config.OnProgress = func(u ProgressUpdate) { updates <- u }
+
+			p := NewProgressStreamProvider(streamingReply(tt.parts...), config)
+			chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			collectStream(chunks)
+
+			var last ProgressUpdate
+			for !last.Done {
+				select {
+				case last = <-updates:
+				case <-time.After(time.Second):
+					t.Fatal("final update not delivered")
+				}
+			}
+			last.Elapsed = 0
+			if last != tt.wantFinal {
+				t.Errorf("final update = %+v, want %+v", last, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestProgressStreamProviderWithoutCallback(t *testing.T) {
+	p := NewProgressStreamProvider(streamingReply("a", "b"), ProgressConfig{EveryTokens: 1})
+	chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, final := collectStream(chunks); content != "ab" || final.Reason != StreamCompleted {
+		t.Errorf("content %q, final %+v", content, final)
+	}
+}
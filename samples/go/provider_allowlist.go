@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+)
+
+// ErrProviderNotAllowed is returned by AllowlistProvider when policy forbids
+// its provider from serving the requested model, even if the provider offers it.
+var ErrProviderNotAllowed = errors.New("provider not allowed for model")
+
+// ProviderAllowlist maps model-family patterns to the IDs of the providers
+// allowed to serve matching models, e.g. to keep a family on EU-hosted
+// providers for data residency. Patterns use path.Match syntax, such as
+// "mistral-*". A model matching several patterns must be allowed by each of
+// them; models matching none are unrestricted.
+type ProviderAllowlist map[string][]string
+
+// Check returns an error wrapping ErrProviderNotAllowed if providerID may not
+// serve model.
+func (a ProviderAllowlist) Check(model, providerID string) error {
+	for pattern, allowed := range a {
+		if ok, _ := path.Match(pattern, model); ok && !slices.Contains(allowed, providerID) {
+			return fmt.Errorf("%w: %s may not serve %s (family %q)", ErrProviderNotAllowed, providerID, model, pattern)
+		}
+	}
+	return nil
+}
+
+// AllowlistProvider wraps a Provider and rejects requests for models its
+// allowlist does not permit the provider to serve. Rejected requests never
+// reach the wrapped provider.
+type AllowlistProvider struct {
+	Provider
+	allowlist ProviderAllowlist
+}
+
+// NewAllowlistProvider creates a provider that enforces allowlist against the
+// wrapped provider's ID.
+func NewAllowlistProvider(inner Provider, allowlist ProviderAllowlist) *AllowlistProvider {
+	return &AllowlistProvider{Provider: inner, allowlist: allowlist}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *AllowlistProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request if the allowlist permits its model.
+func (p *AllowlistProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := p.allowlist.Check(req.Model, p.ID()); err != nil {
+		return nil, err
+	}
+	return p.Provider.Chat(ctx, req)
+}
+
+// ChatStream starts a stream if the allowlist permits the request's model.
+func (p *AllowlistProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	sp, err := streamingInner(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.allowlist.Check(req.Model, p.ID()); err != nil {
+		return nil, err
+	}
+	return sp.ChatStream(ctx, req)
+}
+
+// IsModelAvailable reports disallowed models as unavailable, so that routers
+// looking for a provider pass this one over.
+func (p *AllowlistProvider) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	if p.allowlist.Check(model, p.ID()) != nil {
+		return false, nil
+	}
+	return p.Provider.IsModelAvailable(ctx, model)
+}
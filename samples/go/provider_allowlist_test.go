@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestProviderAllowlist(t *testing.T) {
+	allowlist := ProviderAllowlist{
+		"mistral-*":       {"eu", "eu-backup"},
+		"mistral-large-*": {"eu"},
+	}
+	tests := []struct {
+		model, provider string
+		want            error
+	}{
+		{"mistral-small", "eu", nil},
+		{"mistral-small", "eu-backup", nil},
+		{"mistral-small", "us", ErrProviderNotAllowed},
+		{"mistral-large-2", "eu", nil},
+		{"mistral-large-2", "eu-backup", ErrProviderNotAllowed}, // Must satisfy both patterns
+		{"gpt-4o", "us", nil},
+	}
+	for _, tt := range tests {
+		if err := allowlist.Check(tt.model, tt.provider); !errors.Is(err, tt.want) {
+			t.Errorf("Check(%q, %q) = %v, want %v", tt.model, tt.provider, err, tt.want)
+		}
+	}
+}
+
+func TestAllowlistProvider(t *testing.T) {
+	allowlist := ProviderAllowlist{"mistral-*": {"eu"}}
+	tests := []struct {
+		name          string
+		provider      string
+		model         string
+		wantErr       error
+		wantAvailable bool
+	}{
+		{name: "allowed", provider: "eu", model: "mistral-small", wantAvailable: true},
+		{name: "forbidden", provider: "us", model: "mistral-small", wantErr: ErrProviderNotAllowed},
+		{name: "unrestricted", provider: "us", model: "gpt-4o", wantAvailable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := streamingReply("ok")
+			inner.id = tt.provider
+			inner.models = []string{tt.model}
+			p := NewAllowlistProvider(inner, allowlist)
+			req := &ChatRequest{Model: tt.model, Messages: userMessages("hi")}
+
+			if _, err := p.Chat(context.Background(), req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Chat err = %v, want %v", err, tt.wantErr)
+			}
+			chunks, err := p.ChatStream(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChatStream err = %v, want %v", err, tt.wantErr)
+			}
+			if chunks != nil {
+				collectStream(chunks)
+			}
+			if tt.wantErr != nil && len(inner.requests()) != 0 {
+				t.Error("rejected request reached the wrapped provider")
+			}
+
+			available, err := p.IsModelAvailable(context.Background(), tt.model)
+			if err != nil || available != tt.wantAvailable {
+				t.Errorf("IsModelAvailable = %v, %v, want %v", available, err, tt.wantAvailable)
+			}
+		})
+	}
+}
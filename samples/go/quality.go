@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLowQuality is returned by ChatWithFallback when every provider's
+// response failed the registry's quality check.
+var ErrLowQuality = errors.New("response failed quality check")
+
+// QualityCheck judges a response, returning false and a reason for responses
+// that are unusable, such as empty output, refusals or the wrong format.
+type QualityCheck func(req *ChatRequest, resp *ChatResponse) (ok bool, reason string)
+
+// LowQualityError is the error recorded for a provider whose response failed
+// the quality check.
+type LowQualityError struct {
+	Provider string
+	Reason   string
+}
+
+func (e *LowQualityError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", ErrLowQuality, e.Provider, e.Reason)
+}
+
+// Is reports whether target is ErrLowQuality.
+func (e *LowQualityError) Is(target error) bool {
+	return target == ErrLowQuality
+}
+
+// WithQualityCheck makes ChatWithFallback treat responses that fail check as
+// failures and move on to the next provider.
+func WithQualityCheck(check QualityCheck) RegistryOption {
+	return func(r *ProviderRegistry) {
+		r.qualityCheck = check
+	}
+}
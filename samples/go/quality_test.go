@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistryQualityCheck(t *testing.T) {
+	nonEmpty := func(_ *ChatRequest, resp *ChatResponse) (bool, string) {
+		if strings.TrimSpace(resp.Content) == "" {
+			return false, "empty output"
+		}
+		return true, ""
+	}
+	reply := func(id, content string) *fakeProvider {
+		return &fakeProvider{id: id, chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+			return &ChatResponse{Content: content}, nil
+		}}
+	}
+	tests := []struct {
+		name        string
+		check       QualityCheck
+		providers   []*fakeProvider
+		wantContent string
+		wantErr     bool
+	}{
+		{"no check accepts anything", nil, []*fakeProvider{reply("a", ""), reply("b", "fine")}, "", false},
+		{"failing response falls over", nonEmpty, []*fakeProvider{reply("a", " "), reply("b", "fine")}, "fine", false},
+		{"every response fails", nonEmpty, []*fakeProvider{reply("a", ""), reply("b", "")}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewProviderRegistry(WithQualityCheck(tt.check))
+			var ids []string
+			for _, p := range tt.providers {
+				r.Register(p)
+				ids = append(ids, p.id)
+			}
+			resp, err := r.ChatWithFallback(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")}, ids)
+			if tt.wantErr {
+				var qerr *LowQualityError
+				if !errors.Is(err, ErrLowQuality) || !errors.As(err, &qerr) || qerr.Provider != "b" || qerr.Reason != "empty output" {
+					t.Fatalf("err = %v, want a LowQualityError for b", err)
+				}
+				return
+			}
+			if err != nil || resp.Content != tt.wantContent {
+				t.Errorf("ChatWithFallback = %+v, %v; want %q", resp, err, tt.wantContent)
+			}
+		})
+	}
+}
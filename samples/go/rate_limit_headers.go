@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataRateLimit holds the RateLimitInfo parsed from the response headers.
+const MetadataRateLimit = "rate_limit"
+
+// RateLimitInfo is the provider's view of the caller's remaining quota, as
+// reported in x-ratelimit-* response headers. Fields are -1 or zero when the
+// corresponding header is absent.
+type RateLimitInfo struct {
+	RemainingRequests int
+	RemainingTokens   int
+	ResetRequests     time.Duration // Until the request quota refills
+	ResetTokens       time.Duration // Until the token quota refills
+}
+
+// ParseRateLimitHeaders extracts rate-limit state from OpenAI-style headers
+// (x-ratelimit-remaining-requests, x-ratelimit-reset-tokens and so on). It
+// reports false if none of them are present.
+func ParseRateLimitHeaders(h http.Header) (RateLimitInfo, bool) {
+	info := RateLimitInfo{RemainingRequests: -1, RemainingTokens: -1}
+	found := false
+
+	if n, ok := headerInt(h, "X-Ratelimit-Remaining-Requests"); ok {
+		info.RemainingRequests, found = n, true
+	}
+	if n, ok := headerInt(h, "X-Ratelimit-Remaining-Tokens"); ok {
+		info.RemainingTokens, found = n, true
+	}
+	if d, ok := headerDuration(h, "X-Ratelimit-Reset-Requests"); ok {
+		info.ResetRequests, found = d, true
+	}
+	if d, ok := headerDuration(h, "X-Ratelimit-Reset-Tokens"); ok {
+		info.ResetTokens, found = d, true
+	}
+	return info, found
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(h.Get(key)))
+	return n, err == nil
+}
+
+// headerDuration parses reset values, which are Go-style durations ("6m0s",
+// "20ms") or, from some gateways, a bare number of seconds.
+func headerDuration(h http.Header, key string) (time.Duration, bool) {
+	v := strings.TrimSpace(h.Get(key))
+	if v == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, true
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    RateLimitInfo
+		found   bool
+	}{
+		{"absent", nil, RateLimitInfo{RemainingRequests: -1, RemainingTokens: -1}, false},
+		{
+			"all present",
+			map[string]string{
+				"x-ratelimit-remaining-requests": "59",
+				"x-ratelimit-remaining-tokens":   "149000",
+				"x-ratelimit-reset-requests":     "1s",
+				"x-ratelimit-reset-tokens":       "6m0s",
+			},
+			RateLimitInfo{RemainingRequests: 59, RemainingTokens: 149000, ResetRequests: time.Second, ResetTokens: 6 * time.Minute},
+			true,
+		},
+		{
+			"bare seconds",
+			map[string]string{"x-ratelimit-reset-tokens": "1.5"},
+			RateLimitInfo{RemainingRequests: -1, RemainingTokens: -1, ResetTokens: 1500 * time.Millisecond},
+			true,
+		},
+		{
+			"malformed values ignored",
+			map[string]string{"x-ratelimit-remaining-requests": "lots", "x-ratelimit-reset-requests": "soon"},
+			RateLimitInfo{RemainingRequests: -1, RemainingTokens: -1},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			got, found := ParseRateLimitHeaders(h)
+			if got != tt.want || found != tt.found {
+				t.Errorf("ParseRateLimitHeaders = %+v, %v; want %+v, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestOpenAIReportsRateLimits(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "7")
+		writeJSON(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	})
+	var mu sync.Mutex
+	var seen []RateLimitInfo
+	p := srv.provider(OpenAIConfig{OnRateLimit: func(info RateLimitInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, info)
+	}})
+	resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, ok := resp.Metadata[MetadataRateLimit].(RateLimitInfo)
+	if !ok || info.RemainingRequests != 7 {
+		t.Errorf("metadata = %v, want remaining requests 7", resp.Metadata[MetadataRateLimit])
+	}
+	if len(seen) != 1 || seen[0].RemainingRequests != 7 {
+		t.Errorf("OnRateLimit saw %+v", seen)
+	}
+}
@@ -0,0 +1,207 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Priority orders requests waiting on a RateLimiter. Higher values are served first.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose requests wait on rate limiters with priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority set by WithPriority, or PriorityNormal.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// RateLimiter is a token bucket whose waiters are served by priority rather
+// than arrival order, so interactive requests overtake queued batch work when
+// the limiter is saturated. A waiter's priority rises by one level for every
+// Aging it spends queued, so low-priority requests are not starved.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens per second
+	burst   float64
+	aging   time.Duration
+	tokens  float64
+	last    time.Time
+	paused  time.Time // No tokens accrue before this, see Observe
+	waiters []*rateWaiter
+	timer   *time.Timer
+}
+
+type rateWaiter struct {
+	priority Priority
+	queued   time.Time
+	ready    chan struct{}
+	granted  bool
+}
+
+// NewRateLimiter creates a limiter allowing perSecond requests on average and
+// bursts of up to burst. aging of zero disables anti-starvation aging. A
+// perSecond of zero or less means no limit: Acquire never waits and Observe
+// has no effect.
+func NewRateLimiter(perSecond float64, burst int, aging time.Duration) *RateLimiter {
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  float64(max(burst, 1)),
+		aging:  aging,
+		tokens: float64(max(burst, 1)),
+		last:   time.Now(),
+	}
+}
+
+// Acquire blocks until a token is available for a request of priority p, or
+// ctx is done.
+func (l *RateLimiter) Acquire(ctx context.Context, p Priority) error {
+	if l.rate <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	l.refill(time.Now())
+	if len(l.waiters) == 0 && l.tokens >= 1 {
+		l.tokens--
+		l.mu.Unlock()
+		return nil
+	}
+	w := &rateWaiter{priority: p, queued: time.Now(), ready: make(chan struct{})}
+	l.waiters = append(l.waiters, w)
+	l.dispatch()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if w.granted {
+			// Granted while giving up: return the token for the next waiter.
+			l.tokens++
+			l.dispatch()
+			return ctx.Err()
+		}
+		for i, queued := range l.waiters {
+			if queued == w {
+				l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+				break
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+// Observe adapts the limiter to the quota a provider reported, pausing it
+// until the quota resets once no requests remain. It is suitable as
+// OpenAIConfig.OnRateLimit.
+func (l *RateLimiter) Observe(info RateLimitInfo) {
+	if l.rate <= 0 || info.RemainingRequests != 0 || info.ResetRequests <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.refill(now)
+	l.tokens = 0
+	l.paused = now.Add(info.ResetRequests)
+	l.last = l.paused
+}
+
+// refill adds the tokens accrued since the last refill.
+func (l *RateLimiter) refill(now time.Time) {
+	if now.Before(l.paused) || !now.After(l.last) {
+		return
+	}
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// dispatch grants available tokens to the most urgent waiters and schedules
+// itself for when the next token accrues. l.mu must be held.
+func (l *RateLimiter) dispatch() {
+	now := time.Now()
+	l.refill(now)
+	for l.tokens >= 1 && len(l.waiters) > 0 {
+		next := l.next(now)
+		w := l.waiters[next]
+		l.waiters = append(l.waiters[:next], l.waiters[next+1:]...)
+		l.tokens--
+		w.granted = true
+		close(w.ready)
+	}
+
+	if len(l.waiters) == 0 || l.timer != nil {
+		return
+	}
+	wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if now.Before(l.paused) {
+		wait += l.paused.Sub(now)
+	}
+	l.timer = time.AfterFunc(wait, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.timer = nil
+		l.dispatch()
+	})
+}
+
+// next returns the index of the waiter to serve: highest aged priority, then
+// longest waiting.
+func (l *RateLimiter) next(now time.Time) int {
+	best := 0
+	for i, w := range l.waiters[1:] {
+		if l.effective(w, now) > l.effective(l.waiters[best], now) {
+			best = i + 1
+		}
+	}
+	return best
+}
+
+func (l *RateLimiter) effective(w *rateWaiter, now time.Time) Priority {
+	if l.aging <= 0 {
+		return w.priority
+	}
+	return w.priority + Priority(now.Sub(w.queued)/l.aging)
+}
+
+// RateLimitedProvider wraps a Provider and waits on a RateLimiter before each
+// request, at the priority set on the request's context with WithPriority.
+type RateLimitedProvider struct {
+	Provider
+	limiter *RateLimiter
+}
+
+// NewRateLimitedProvider creates a provider whose requests are paced by limiter.
+func NewRateLimitedProvider(inner Provider, limiter *RateLimiter) *RateLimitedProvider {
+	return &RateLimitedProvider{Provider: inner, limiter: limiter}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *RateLimitedProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat waits for the limiter, then forwards the request.
+func (p *RateLimitedProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := p.limiter.Acquire(ctx, PriorityFromContext(ctx)); err != nil {
+		return nil, err
+	}
+	return p.Provider.Chat(ctx, req)
+}
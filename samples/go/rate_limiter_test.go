@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAcquire(t *testing.T) {
+	tests := []struct {
+		name      string
+		perSecond float64
+		burst     int
+		acquires  int
+		wantBlock bool // The last Acquire waits past a short deadline
+	}{
+		{"within burst", 1, 3, 3, false},
+		{"burst exhausted", 1, 3, 4, true},
+		{"zero rate is unlimited", 0, 1, 50, false},
+		{"negative rate is unlimited", -5, 1, 50, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.perSecond, tt.burst, 0)
+			var err error
+			for range tt.acquires {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				err = l.Acquire(ctx, PriorityNormal)
+				cancel()
+				if err != nil {
+					break
+				}
+			}
+			if blocked := errors.Is(err, context.DeadlineExceeded); blocked != tt.wantBlock {
+				t.Errorf("last Acquire err = %v, want blocked: %v", err, tt.wantBlock)
+			}
+		})
+	}
+}
+
+func TestRateLimiterPriority(t *testing.T) {
+	l := NewRateLimiter(50, 1, 0)
+	if err := l.Acquire(context.Background(), PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Acquire(context.Background(), p); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+		}()
+		time.Sleep(2 * time.Millisecond) // Queue them before the first token accrues
+	}
+	wg.Wait()
+	if want := []Priority{PriorityHigh, PriorityNormal, PriorityLow}; !slices.Equal(order, want) {
+		t.Errorf("served %v, want %v", order, want)
+	}
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		perSecond float64
+		wantPause bool
+	}{
+		{"limited pauses until reset", 1000, true},
+		{"unlimited ignores quota", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.perSecond, 5, 0)
+			l.Observe(RateLimitInfo{RemainingRequests: 0, ResetRequests: 50 * time.Millisecond})
+
+			start := time.Now()
+			if err := l.Acquire(context.Background(), PriorityNormal); err != nil {
+				t.Fatal(err)
+			}
+			if paused := time.Since(start) >= 40*time.Millisecond; paused != tt.wantPause {
+				t.Errorf("Acquire returned after %v, want pause: %v", time.Since(start), tt.wantPause)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MetadataRemediation records the remediation that recovered a request.
+const MetadataRemediation = "remediation"
+
+// Remediation is the recovery action for a provider error code.
+type Remediation string
+
+const (
+	RemediateRetrySame Remediation = "retry_same" // Retry the same model after a pause
+	RemediateSwapModel Remediation = "swap_model" // Retry on the configured alternative model
+	RemediateFailFast  Remediation = "fail_fast"  // Return the error without retrying
+)
+
+// RemediationPlaybook maps a provider's error codes to recovery actions.
+// Codes not listed are returned to the caller unchanged.
+type RemediationPlaybook struct {
+	// Actions maps ProviderError.Code values to remediations.
+	Actions map[string]Remediation
+
+	// SwapModels maps a model to the alternative used by RemediateSwapModel.
+	// Requests for models without an alternative fail with the original error.
+	SwapModels map[string]string
+
+	// MaxRetries bounds the remediations applied to one request. Defaults to 2.
+	MaxRetries int
+
+	// RetryDelay is the pause before RemediateRetrySame. Defaults to 1s.
+	RetryDelay time.Duration
+}
+
+// RemediationProvider wraps a Provider and applies a provider-specific
+// playbook to the error codes it returns, for cases such as an "overloaded"
+// code where retrying the same model is futile but a sibling model works.
+type RemediationProvider struct {
+	Provider
+	playbook RemediationPlaybook
+}
+
+// NewRemediationProvider creates a provider that recovers from errors with playbook.
+func NewRemediationProvider(inner Provider, playbook RemediationPlaybook) *RemediationProvider {
+	if playbook.MaxRetries <= 0 {
+		playbook.MaxRetries = 2
+	}
+	if playbook.RetryDelay <= 0 {
+		playbook.RetryDelay = time.Second
+	}
+	return &RemediationProvider{Provider: inner, playbook: playbook}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *RemediationProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat sends the request, applying the playbook's remediation to failures.
+func (p *RemediationProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	current := req
+	var applied Remediation
+
+	for attempt := 0; ; attempt++ {
+		resp, err := p.Provider.Chat(ctx, current)
+		if err == nil {
+			if applied != "" {
+				resp.SetMetadata(MetadataRemediation, string(applied))
+			}
+			if current.Model != req.Model && resp.RequestedModel == "" {
+				resp.RequestedModel = req.Model
+			}
+			return resp, nil
+		}
+
+		action := p.remediation(err)
+		if attempt >= p.playbook.MaxRetries || action == "" || action == RemediateFailFast {
+			return nil, err
+		}
+
+		switch action {
+		case RemediateRetrySame:
+			if err := sleepContext(ctx, p.playbook.RetryDelay); err != nil {
+				return nil, err
+			}
+		case RemediateSwapModel:
+			model, ok := p.playbook.SwapModels[current.Model]
+			if !ok {
+				return nil, err
+			}
+			swapped := *current
+			swapped.Model = model
+			current = &swapped
+		}
+		applied = action
+	}
+}
+
+// remediation returns the playbook action for err, or "" if it has none.
+func (p *RemediationProvider) remediation(err error) Remediation {
+	var perr *ProviderError
+	if !errors.As(err, &perr) {
+		return ""
+	}
+	return p.playbook.Actions[perr.Code]
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRemediationProvider(t *testing.T) {
+	overloaded := &ProviderError{StatusCode: 529, Code: "overloaded"}
+	busy := &ProviderError{StatusCode: 503, Code: "busy"}
+	invalid := &ProviderError{StatusCode: 400, Code: "invalid_key"}
+	playbook := RemediationPlaybook{
+		Actions: map[string]Remediation{
+			"overloaded":  RemediateSwapModel,
+			"busy":        RemediateRetrySame,
+			"invalid_key": RemediateFailFast,
+		},
+		SwapModels: map[string]string{"big": "big-sibling"},
+		RetryDelay: time.Millisecond,
+	}
+	tests := []struct {
+		name            string
+		model           string
+		outcomes        []outcome
+		wantModels      []string
+		wantErr         error
+		wantRemediation string
+	}{
+		{"success", "big", []outcome{{resp: &ChatResponse{}}}, []string{"big"}, nil, ""},
+		{"swap model", "big", []outcome{{err: overloaded}, {resp: &ChatResponse{}}}, []string{"big", "big-sibling"}, nil, "swap_model"},
+		{"no alternative model", "small", []outcome{{err: overloaded}}, []string{"small"}, overloaded, ""},
+		{"retry same", "big", []outcome{{err: busy}, {resp: &ChatResponse{}}}, []string{"big", "big"}, nil, "retry_same"},
+		{"retries bounded", "big", []outcome{{err: busy}}, []string{"big", "big", "big"}, busy, ""},
+		{"fail fast", "big", []outcome{{err: invalid}}, []string{"big"}, invalid, ""},
+		{"unlisted error", "big", []outcome{{err: ErrRateLimited}}, []string{"big"}, ErrRateLimited, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(tt.outcomes...)}
+			resp, err := NewRemediationProvider(inner, playbook).Chat(context.Background(), &ChatRequest{Model: tt.model})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil) != (err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			var models []string
+			for _, req := range inner.requests() {
+				models = append(models, req.Model)
+			}
+			if !slices.Equal(models, tt.wantModels) {
+				t.Errorf("models = %v, want %v", models, tt.wantModels)
+			}
+			if err != nil {
+				return
+			}
+			if got, _ := resp.Metadata[MetadataRemediation].(string); got != tt.wantRemediation {
+				t.Errorf("remediation = %q, want %q", got, tt.wantRemediation)
+			}
+			if swapped := tt.wantRemediation == "swap_model"; swapped != (resp.RequestedModel == tt.model) {
+				t.Errorf("RequestedModel = %q after remediation %q", resp.RequestedModel, tt.wantRemediation)
+			}
+		})
+	}
+}
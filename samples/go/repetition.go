@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRepetitionLoop is returned when a stream degenerates into repeating the same text.
+var ErrRepetitionLoop = errors.New("repetition loop detected")
+
+// RepetitionLoopError reports the repeated phrase and the content generated
+// before the loop began.
+type RepetitionLoopError struct {
+	Phrase string
+	Prefix string
+}
+
+func (e *RepetitionLoopError) Error() string {
+	return fmt.Sprintf("%v: %q", ErrRepetitionLoop, e.Phrase)
+}
+
+// Is reports whether target is ErrRepetitionLoop.
+func (e *RepetitionLoopError) Is(target error) bool {
+	return target == ErrRepetitionLoop
+}
+
+// RepetitionConfig configures loop detection. A loop is a phrase of between
+// MinPhrase and MaxPhrase characters repeated back to back at least Repeats times.
+type RepetitionConfig struct {
+	MinPhrase int // Default 8
+	MaxPhrase int // Default 100
+	Repeats   int // Default 4
+}
+
+// RepetitionGuardProvider wraps a StreamingProvider and cancels streams that
+// get stuck repeating themselves, saving the tokens the loop would burn.
+type RepetitionGuardProvider struct {
+	StreamingProvider
+	config RepetitionConfig
+}
+
+// NewRepetitionGuardProvider creates a provider that detects loops with config.
+func NewRepetitionGuardProvider(inner StreamingProvider, config RepetitionConfig) *RepetitionGuardProvider {
+	if config.MinPhrase <= 0 {
+		config.MinPhrase = 8
+	}
+	if config.MaxPhrase < config.MinPhrase {
+		config.MaxPhrase = max(100, config.MinPhrase)
+	}
+	if config.Repeats < 2 {
+		config.Repeats = 4
+	}
+	return &RepetitionGuardProvider{StreamingProvider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *RepetitionGuardProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream starts a stream on the wrapped provider and ends it with
+// StreamRepetition and a RepetitionLoopError if it starts looping.
+func (p *RepetitionGuardProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		detector := newRepetitionDetector(p.config)
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				return chunk
+			}
+
+			emit(chunk)
+			if loop := detector.add(chunk.Content); loop != nil {
+				return StreamChunk{Done: true, Reason: StreamRepetition, Err: loop}
+			}
+		}
+	}), nil
+}
+
+// repetitionDetector finds loops incrementally. For each phrase length n it
+// counts how many of the latest runes equal the rune n before them; a run of
+// n*(Repeats-1) such runes means the content ends in its last n runes repeated
+// Repeats times. Each rune costs O(MaxPhrase), however long the stream gets.
+type repetitionDetector struct {
+	config RepetitionConfig
+	runes  []rune
+	runs   []int // runs[n] is the current match run for phrase length n
+}
+
+func newRepetitionDetector(config RepetitionConfig) *repetitionDetector {
+	return &repetitionDetector{config: config, runs: make([]int, config.MaxPhrase+1)}
+}
+
+// add appends text to the content seen so far and reports whether the content
+// now ends in a loop, preferring the shortest phrase.
+func (d *repetitionDetector) add(text string) *RepetitionLoopError {
+	for _, r := range text {
+		d.runes = append(d.runes, r)
+		i := len(d.runes) - 1
+		for n := d.config.MinPhrase; n <= d.config.MaxPhrase; n++ {
+			if i >= n && d.runes[i] == d.runes[i-n] {
+				d.runs[n]++
+			} else {
+				d.runs[n] = 0
+			}
+		}
+	}
+
+	end := len(d.runes)
+	for n := d.config.MinPhrase; n <= d.config.MaxPhrase; n++ {
+		if d.runs[n] < n*(d.config.Repeats-1) {
+			continue
+		}
+		if phrase := string(d.runes[end-n:]); strings.TrimSpace(phrase) != "" {
+			return &RepetitionLoopError{Phrase: phrase, Prefix: string(d.runes[:end-n*d.config.Repeats])}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRepetitionDetector(t *testing.T) {
+	config := RepetitionConfig{MinPhrase: 3, MaxPhrase: 10, Repeats: 3}
+	tests := []struct {
+		name       string
+		parts      []string
+		wantPhrase string
+		wantPrefix string
+	}{
+		{"no loop", []string{"the quick brown fox jumps over the lazy dog"}, "", ""},
+		{"loop in one chunk", []string{"intro: abcabcabc"}, "abc", "intro: "},
+		{"loop across chunks", []string{"intro: ab", "cabca", "bc"}, "abc", "intro: "},
+		{"multi-byte phrase", []string{"→ ", "日本語", "日本", "語日本語"}, "日本語", "→ "},
+		{"too few repeats", []string{"abcabc then more"}, "", ""},
+		{"whitespace is not a phrase", []string{"x" + strings.Repeat(" ", 30) + "y"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newRepetitionDetector(config)
+			var loop *RepetitionLoopError
+			for _, p := range tt.parts {
+				if loop = d.add(p); loop != nil {
+					break
+				}
+			}
+			if tt.wantPhrase == "" {
+				if loop != nil {
+					t.Errorf("detected %q, want no loop", loop.Phrase)
+				}
+				return
+			}
+			if loop == nil || loop.Phrase != tt.wantPhrase || loop.Prefix != tt.wantPrefix {
+				t.Errorf("loop = %+v, want phrase %q after %q", loop, tt.wantPhrase, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestRepetitionGuardProvider(t *testing.T) {
+	parts := []string{"Sure. "}
+	for i := 0; i < 10; i++ {
+		parts = append(parts, "I apologize. ")
+	}
+	p := NewRepetitionGuardProvider(streamingReply(parts...), RepetitionConfig{})
+	chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, final := collectStream(chunks)
+	var loop *RepetitionLoopError
+	if final.Reason != StreamRepetition || !errors.As(final.Err, &loop) {
+		t.Fatalf("final = %+v, want a repetition loop", final)
+	}
+	if loop.Prefix != "Sure. " || strings.Count(content, "I apologize.") != 4 {
+		t.Errorf("prefix %q after %q", loop.Prefix, content)
+	}
+}
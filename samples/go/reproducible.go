@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrFingerprintDrift is returned by VerifyReproducible when the response
+// could not be reproduced and the provider's backend configuration has
+// changed since it was recorded, so the mismatch is expected rather than a
+// sign of non-determinism.
+var ErrFingerprintDrift = errors.New("system fingerprint changed since the response was recorded")
+
+// VerifyReproducible re-issues a seeded request and reports whether p still
+// produces the recorded response: the same content and tool calls, from the
+// same system fingerprint when one was recorded. Seeded sampling is only
+// deterministic for a fixed backend configuration, so a mismatch after the
+// fingerprint changed is reported as ErrFingerprintDrift; a match despite a
+// changed fingerprint still counts as reproduced.
+func VerifyReproducible(ctx context.Context, p Provider, recorded *ChatResponse, req *ChatRequest) (bool, error) {
+	if req.Seed == nil {
+		return false, fmt.Errorf("%w: reproducibility requires a seed", ErrInvalidRequest)
+	}
+	if !CapabilitiesOf(p).Seed {
+		return false, fmt.Errorf("%w: %s does not support seeds", ErrInvalidRequest, p.ID())
+	}
+
+	resp, err := p.Chat(ctx, req)
+	if err != nil {
+		return false, err
+	}
+
+	same := resp.Content == recorded.Content &&
+		slices.EqualFunc(resp.ToolCalls, recorded.ToolCalls, func(a, b ToolCall) bool {
+			return a.Name == b.Name && sameJSON([]byte(a.Arguments), []byte(b.Arguments))
+		})
+	drifted := recorded.Fingerprint != "" && resp.Fingerprint != recorded.Fingerprint
+	if !same && drifted {
+		return false, fmt.Errorf("%w: %s, now %s", ErrFingerprintDrift, recorded.Fingerprint, resp.Fingerprint)
+	}
+	return same, nil
+}
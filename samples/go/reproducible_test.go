@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestVerifyReproducible(t *testing.T) {
+	seed := int64(42)
+	recorded := &ChatResponse{
+		Content:     "answer",
+		Fingerprint: "fp_1",
+		ToolCalls:   []ToolCall{{ID: "a", Name: "search", Arguments: `{"q":"x","n":1}`}},
+	}
+	tests := []struct {
+		name    string
+		seeded  bool
+		seed    *int64
+		replay  *ChatResponse
+		want    bool
+		wantErr error
+	}{
+		{
+			name:   "reproduced",
+			seeded: true,
+			seed:   &seed,
+			replay: &ChatResponse{Content: "answer", Fingerprint: "fp_1", ToolCalls: []ToolCall{{ID: "b", Name: "search", Arguments: `{"n":1,"q":"x"}`}}},
+			want:   true,
+		},
+		{
+			name:   "different content",
+			seeded: true,
+			seed:   &seed,
+			replay: &ChatResponse{Content: "other", Fingerprint: "fp_1", ToolCalls: recorded.ToolCalls},
+		},
+		{
+			name:   "different tool arguments",
+			seeded: true,
+			seed:   &seed,
+			replay: &ChatResponse{Content: "answer", Fingerprint: "fp_1", ToolCalls: []ToolCall{{Name: "search", Arguments: `{"q":"y","n":1}`}}},
+		},
+		{
+			name:    "drift explains a mismatch",
+			seeded:  true,
+			seed:    &seed,
+			replay:  &ChatResponse{Content: "other", Fingerprint: "fp_2"},
+			wantErr: ErrFingerprintDrift,
+		},
+		{
+			name:   "reproduced despite drift",
+			seeded: true,
+			seed:   &seed,
+			replay: &ChatResponse{Content: "answer", Fingerprint: "fp_2", ToolCalls: recorded.ToolCalls},
+			want:   true,
+		},
+		{name: "no seed", seeded: true, wantErr: ErrInvalidRequest},
+		{name: "provider without seeds", seed: &seed, wantErr: ErrInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeProvider{chat: scripted(outcome{resp: tt.replay})}
+			var p Provider = fake
+			if tt.seeded {
+				p = seedProvider{fake}
+			}
+			got, err := VerifyReproducible(context.Background(), p, recorded, &ChatRequest{Seed: tt.seed})
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("got %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+			if tt.wantErr == ErrInvalidRequest && len(fake.requests()) != 0 {
+				t.Error("invalid request was sent")
+			}
+		})
+	}
+}
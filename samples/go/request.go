@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Values for ChatRequest.ReasoningSummary.
+const (
+	ReasoningSummaryNone     = "none"
+	ReasoningSummaryConcise  = "concise"
+	ReasoningSummaryDetailed = "detailed"
+)
+
+// storedPromptRef is the wire form of a reference to a provider-stored prompt.
+type storedPromptRef struct {
+	ID        string            `json:"id"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// chatRequestWire is ChatRequest without its custom marshalers, so the
+// marshalers below can reuse the default field encoding.
+type chatRequestWire ChatRequest
+
+// MarshalJSON encodes the request, emitting a stored-prompt reference in place
+// of messages when PromptID is set.
+func (r ChatRequest) MarshalJSON() ([]byte, error) {
+	out := struct {
+		chatRequestWire
+		Messages []Message        `json:"messages,omitempty"`
+		Prompt   *storedPromptRef `json:"prompt,omitempty"`
+	}{chatRequestWire: chatRequestWire(r), Messages: r.Messages}
+
+	if r.PromptID != "" {
+		out.Prompt = &storedPromptRef{ID: r.PromptID, Variables: r.PromptVariables}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a request produced by MarshalJSON.
+func (r *ChatRequest) UnmarshalJSON(data []byte) error {
+	var in struct {
+		chatRequestWire
+		Prompt *storedPromptRef `json:"prompt,omitempty"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*r = ChatRequest(in.chatRequestWire)
+	if in.Prompt != nil {
+		r.PromptID = in.Prompt.ID
+		r.PromptVariables = in.Prompt.Variables
+	}
+	return nil
+}
+
+// Validate checks the request for conflicting or missing fields.
+func (r *ChatRequest) Validate() error {
+	if r.PromptID != "" && len(r.Messages) > 0 {
+		return fmt.Errorf("%w: PromptID and Messages are mutually exclusive", ErrInvalidRequest)
+	}
+	if r.PromptID == "" && len(r.PromptVariables) > 0 {
+		return fmt.Errorf("%w: PromptVariables requires PromptID", ErrInvalidRequest)
+	}
+	switch r.ReasoningSummary {
+	case "", ReasoningSummaryNone, ReasoningSummaryConcise, ReasoningSummaryDetailed:
+	default:
+		return fmt.Errorf("%w: unknown ReasoningSummary %q", ErrInvalidRequest, r.ReasoningSummary)
+	}
+	return nil
+}
+
+// withSystemInstruction returns a copy of req with instruction appended to its
+// system prompt, adding a leading system message if there is none.
+func withSystemInstruction(req *ChatRequest, instruction string) *ChatRequest {
+	out := *req
+	out.Messages = make([]Message, 0, len(req.Messages)+1)
+
+	if len(req.Messages) > 0 && req.Messages[0].Role == "system" {
+		first := req.Messages[0]
+		first.Content += "\n\n" + instruction
+		out.Messages = append(out.Messages, first)
+		out.Messages = append(out.Messages, req.Messages[1:]...)
+		return &out
+	}
+
+	out.Messages = append(out.Messages, Message{Role: "system", Content: instruction})
+	out.Messages = append(out.Messages, req.Messages...)
+	return &out
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// RequestSchemaVersion is the version of the persisted request format written
+// by MarshalPersistedRequest.
+//
+// Version 1 is the untagged output of ChatRequest.MarshalJSON persisted before
+// versioning. It has the same shape as version 2 but lacks the client-side
+// options, such as ModelFallbacks and Grammar, which the wire format omits.
+const RequestSchemaVersion = 2
+
+// ErrUnsupportedSchemaVersion is returned for persisted requests written by a
+// newer version of this package.
+var ErrUnsupportedSchemaVersion = errors.New("unsupported request schema version")
+
+// requestMigrations upgrade the fields of a persisted request from the version
+// they are keyed by to the next one.
+var requestMigrations = map[int]func(fields map[string]json.RawMessage) error{
+	1: migrateRequestV1,
+}
+
+// persistedRequestFields are the client-side ChatRequest options, which are
+// not sent to providers but are kept when a request is persisted.
+type persistedRequestFields struct {
+	SchemaVersion    int           `json:"schema_version"`
+	ReasoningSummary string        `json:"reasoning_summary,omitempty"`
+	StreamUsage      bool          `json:"stream_usage,omitempty"`
+	ModelFallbacks   []string      `json:"model_fallbacks,omitempty"`
+	Grammar          string        `json:"grammar,omitempty"`
+	BuiltinTools     []BuiltinTool `json:"builtin_tools,omitempty"`
+}
+
+// MarshalPersistedRequest encodes req for storage, tagged with
+// RequestSchemaVersion and including the options that the wire format omits.
+func MarshalPersistedRequest(req *ChatRequest) ([]byte, error) {
+	local, err := json.Marshal(persistedRequestFields{
+		SchemaVersion:    RequestSchemaVersion,
+		ReasoningSummary: req.ReasoningSummary,
+		StreamUsage:      req.StreamUsage,
+		ModelFallbacks:   req.ModelFallbacks,
+		Grammar:          req.Grammar,
+		BuiltinTools:     req.BuiltinTools,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var extra map[string]any
+	if err := json.Unmarshal(local, &extra); err != nil {
+		return nil, err
+	}
+	return encodeRequest(req, extra)
+}
+
+// UnmarshalPersistedRequest decodes a request written by
+// MarshalPersistedRequest, or persisted in any earlier format, migrating it to
+// the current shape. Requests from a newer schema version are rejected with
+// ErrUnsupportedSchemaVersion rather than silently losing fields.
+func UnmarshalPersistedRequest(data []byte) (*ChatRequest, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("decode persisted request: %w", err)
+	}
+
+	version := 1
+	if raw, ok := fields["schema_version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, fmt.Errorf("decode persisted request: schema_version: %w", err)
+		}
+	}
+	if version > RequestSchemaVersion || version < 1 {
+		return nil, fmt.Errorf("%w: %d (supported up to %d)", ErrUnsupportedSchemaVersion, version, RequestSchemaVersion)
+	}
+	for v := version; v < RequestSchemaVersion; v++ {
+		if err := requestMigrations[v](fields); err != nil {
+			return nil, fmt.Errorf("migrate persisted request from version %d: %w", v, err)
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var req ChatRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("decode persisted request: %w", err)
+	}
+	var local persistedRequestFields
+	if err := json.Unmarshal(data, &local); err != nil {
+		return nil, fmt.Errorf("decode persisted request: %w", err)
+	}
+	req.ReasoningSummary = local.ReasoningSummary
+	req.StreamUsage = local.StreamUsage
+	req.ModelFallbacks = local.ModelFallbacks
+	req.Grammar = local.Grammar
+	req.BuiltinTools = local.BuiltinTools
+	return &req, nil
+}
+
+// migrateRequestV1 upgrades a version 1 request. Version 1 recorded no
+// client-side options, and their absence decodes as the zero values they had
+// when the request was persisted, so no fields need to change.
+func migrateRequestV1(map[string]json.RawMessage) error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPersistedRequestRoundTrip(t *testing.T) {
+	req := &ChatRequest{
+		Model:            "gpt-4o",
+		Messages:         userMessages("hi"),
+		PromptID:         "greeting",
+		PromptVariables:  map[string]string{"name": "Ada"},
+		ReasoningSummary: "auto",
+		StreamUsage:      true,
+		ModelFallbacks:   []string{"gpt-4o-mini"},
+		Grammar:          "root ::= \"yes\"",
+		BuiltinTools:     []BuiltinTool{BuiltinWebSearch},
+	}
+	data, err := MarshalPersistedRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalPersistedRequest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, req) {
+		t.Errorf("round trip = %+v, want %+v", got, req)
+	}
+}
+
+func TestUnmarshalPersistedRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *ChatRequest
+		wantErr error
+	}{
+		// The version 1 payloads were written by json.Marshal before versioning.
+		{
+			name: "version 1 stored prompt",
+			data: `{"model":"gpt-4o","prompt":{"id":"greeting","variables":{"name":"Ada"}}}`,
+			want: &ChatRequest{Model: "gpt-4o", PromptID: "greeting", PromptVariables: map[string]string{"name": "Ada"}},
+		},
+		{
+			name: "version 1 messages",
+			data: `{"model":"gpt-4o","temperature":0.5,"messages":[{"role":"user","content":"hi"}]}`,
+			want: &ChatRequest{Model: "gpt-4o", Temperature: 0.5, Messages: userMessages("hi")},
+		},
+		{
+			name: "current version",
+			data: `{"schema_version":2,"model":"m","messages":[],"prompt":{"id":"p1"},"stream_usage":true}`,
+			want: &ChatRequest{Model: "m", Messages: []Message{}, PromptID: "p1", StreamUsage: true},
+		},
+		{
+			name:    "newer version",
+			data:    `{"schema_version":3,"model":"m"}`,
+			wantErr: ErrUnsupportedSchemaVersion,
+		},
+		{
+			name:    "invalid version",
+			data:    `{"schema_version":0,"model":"m"}`,
+			wantErr: ErrUnsupportedSchemaVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalPersistedRequest([]byte(tt.data))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStoredPromptSerialization(t *testing.T) {
+	tests := []struct {
+		name string
+		req  ChatRequest
+		want []string
+		omit []string
+	}{
+		{"stored prompt", ChatRequest{Model: "m", PromptID: "pmpt_1", PromptVariables: map[string]string{"city": "Paris"}},
+			[]string{`"prompt":{"id":"pmpt_1","variables":{"city":"Paris"}}`}, []string{`"messages"`}},
+		{"inline messages", ChatRequest{Model: "m", Messages: userMessages("hi")},
+			[]string{`"messages":[`}, []string{`"prompt"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("%s missing %s", data, s)
+				}
+			}
+			for _, s := range tt.omit {
+				if strings.Contains(string(data), s) {
+					t.Errorf("%s should not contain %s", data, s)
+				}
+			}
+
+			var back ChatRequest
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatal(err)
+			}
+			if back.PromptID != tt.req.PromptID || !reflect.DeepEqual(back.PromptVariables, tt.req.PromptVariables) {
+				t.Errorf("round trip = %+v, want prompt %q %v", back, tt.req.PromptID, tt.req.PromptVariables)
+			}
+		})
+	}
+}
+
+func TestValidateStoredPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ChatRequest
+		wantErr bool
+	}{
+		{"messages only", ChatRequest{Messages: userMessages("hi")}, false},
+		{"prompt only", ChatRequest{PromptID: "p", PromptVariables: map[string]string{"a": "b"}}, false},
+		{"both", ChatRequest{PromptID: "p", Messages: userMessages("hi")}, true},
+		{"variables without prompt", ChatRequest{PromptVariables: map[string]string{"a": "b"}}, true},
+	}
+	for _, tt := range tests {
+		err := tt.req.Validate()
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidRequest)) {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestStoredPromptRouting(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeJSON(w, `{"id":"r","status":"completed","output":[{"type":"message","content":[{"type":"output_text","text":"ok"}]}]}`)
+	})
+	req := &ChatRequest{Model: "m", PromptID: "pmpt_1", PromptVariables: map[string]string{"city": "Paris"}}
+
+	chat := srv.provider(OpenAIConfig{})
+	if _, err := chat.Chat(context.Background(), req); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("chat completions Chat: err = %v, want ErrInvalidRequest", err)
+	}
+	if _, err := chat.ChatStream(context.Background(), req); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("chat completions ChatStream: err = %v, want ErrInvalidRequest", err)
+	}
+	if n := len(srv.bodies); n != 0 {
+		t.Fatalf("sent %d requests to chat completions, want 0", n)
+	}
+
+	p := &ResponsesProvider{api: chat}
+	if _, err := p.Chat(context.Background(), req); err != nil {
+		t.Fatalf("responses API: %v", err)
+	}
+	if prompt, _ := srv.lastBody()["prompt"].(map[string]any); prompt["id"] != "pmpt_1" {
+		t.Errorf("sent prompt %v", srv.lastBody()["prompt"])
+	}
+}
+
+func TestWithSystemInstruction(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []Message
+		want     []Message
+	}{
+		{"adds system message", userMessages("hi"),
+			[]Message{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "hi"}}},
+		{"extends system message", []Message{{Role: "system", Content: "You help."}, {Role: "user", Content: "hi"}},
+			[]Message{{Role: "system", Content: "You help.\n\nBe brief."}, {Role: "user", Content: "hi"}}},
+	}
+	for _, tt := range tests {
+		req := &ChatRequest{Messages: tt.messages}
+		orig := slices.Clone(tt.messages)
+		got := withSystemInstruction(req, "Be brief.")
+		if !reflect.DeepEqual(got.Messages, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got.Messages, tt.want)
+		}
+		if !reflect.DeepEqual(req.Messages, orig) {
+			t.Errorf("%s: original request modified", tt.name)
+		}
+	}
+}
+
+func TestValidateReasoningSummary(t *testing.T) {
+	tests := []struct {
+		summary string
+		wantErr bool
+	}{
+		{"", false},
+		{ReasoningSummaryNone, false},
+		{ReasoningSummaryConcise, false},
+		{ReasoningSummaryDetailed, false},
+		{"verbose", true},
+	}
+	for _, tt := range tests {
+		err := (&ChatRequest{ReasoningSummary: tt.summary}).Validate()
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidRequest)) {
+			t.Errorf("Validate(%q) = %v, want error %v", tt.summary, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReasoningSummaryRouting(t *testing.T) {
+	chat := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeJSON(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	})
+	tests := []struct {
+		summary string
+		wantErr bool
+	}{
+		{"", false},
+		{ReasoningSummaryNone, false},
+		{ReasoningSummaryConcise, true},
+	}
+	for _, tt := range tests {
+		_, err := chat.provider(OpenAIConfig{}).Chat(context.Background(), &ChatRequest{Model: "m", ReasoningSummary: tt.summary})
+		if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidRequest)) {
+			t.Errorf("chat completions with summary %q: err = %v, want error %v", tt.summary, err, tt.wantErr)
+		}
+	}
+
+	responses := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeJSON(w, `{"id":"r","status":"completed","output":[{"type":"reasoning","summary":[{"text":"because"}]}]}`)
+	})
+	p := &ResponsesProvider{api: responses.provider(OpenAIConfig{})}
+	resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m", ReasoningSummary: ReasoningSummaryDetailed})
+	if err != nil || resp.ReasoningSummary != "because" {
+		t.Fatalf("responses API: %+v, %v", resp, err)
+	}
+	if reasoning, _ := responses.lastBody()["reasoning"].(map[string]any); reasoning["summary"] != ReasoningSummaryDetailed {
+		t.Errorf("sent reasoning %v", responses.lastBody()["reasoning"])
+	}
+}
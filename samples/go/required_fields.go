@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MissingFieldsError reports required fields absent or empty in a structured
+// response.
+type MissingFieldsError struct {
+	Paths []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("%v: missing or empty fields %s", ErrInvalidStructuredOutput, strings.Join(e.Paths, ", "))
+}
+
+// Is reports whether target is ErrInvalidStructuredOutput.
+func (e *MissingFieldsError) Is(target error) bool {
+	return target == ErrInvalidStructuredOutput
+}
+
+// RequireFields returns an OutputValidator that parses content as JSON and
+// checks that every path is present and non-empty. Paths are dot-separated
+// object keys, with numeric segments indexing arrays, e.g. "items.0.id".
+// Null, empty strings and empty arrays or objects count as empty. It can be
+// used on its own or with EscalatingProvider.
+func RequireFields(paths ...string) OutputValidator {
+	return func(content string) error {
+		var doc any
+		if err := json.Unmarshal([]byte(content), &doc); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidStructuredOutput, err)
+		}
+		var missing []string
+		for _, path := range paths {
+			if v, ok := lookupJSONPath(doc, path); !ok || emptyJSON(v) {
+				missing = append(missing, path)
+			}
+		}
+		if len(missing) > 0 {
+			return &MissingFieldsError{Paths: missing}
+		}
+		return nil
+	}
+}
+
+// RequiredFieldsProvider wraps a Provider and checks that structured
+// responses contain the required fields, asking the model up to maxRetries
+// times to supply the missing ones before failing with a MissingFieldsError.
+type RequiredFieldsProvider struct {
+	Provider
+	validate   OutputValidator
+	maxRetries int
+}
+
+// NewRequiredFieldsProvider creates a provider that requires paths in every
+// response; see RequireFields for the path syntax. A negative maxRetries
+// means no retries.
+func NewRequiredFieldsProvider(inner Provider, paths []string, maxRetries int) *RequiredFieldsProvider {
+	return &RequiredFieldsProvider{Provider: inner, validate: RequireFields(paths...), maxRetries: max(maxRetries, 0)}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *RequiredFieldsProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request, retrying with a corrective instruction while
+// required fields are missing.
+func (p *RequiredFieldsProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	attempt := req
+	for retries := 0; ; retries++ {
+		resp, err := p.Provider.Chat(ctx, attempt)
+		if err != nil {
+			return nil, err
+		}
+		err = p.validate(resp.Content)
+		if err == nil {
+			return resp, nil
+		}
+		if retries >= p.maxRetries {
+			return nil, err
+		}
+		attempt = repairRequest(attempt, resp.Content, err)
+	}
+}
+
+// lookupJSONPath resolves a dot-separated path in a decoded JSON document.
+func lookupJSONPath(doc any, path string) (any, bool) {
+	v := doc
+	for _, seg := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[seg]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func emptyJSON(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRequireFields(t *testing.T) {
+	paths := []string{"name", "items.0.id", "meta"}
+	tests := []struct {
+		name        string
+		content     string
+		wantMissing []string
+		wantErr     bool
+	}{
+		{name: "complete", content: `{"name":"a","items":[{"id":1}],"meta":{"k":false}}`},
+		{name: "absent", content: `{"items":[]}`, wantMissing: []string{"name", "items.0.id", "meta"}},
+		{name: "empty values", content: `{"name":"  ","items":[{"id":null}],"meta":{}}`, wantMissing: []string{"name", "items.0.id", "meta"}},
+		{name: "zero values are present", content: `{"name":"a","items":[{"id":0}],"meta":[false]}`},
+		{name: "path through a scalar", content: `{"name":"a","items":"x","meta":1}`, wantMissing: []string{"items.0.id"}},
+		{name: "not JSON", content: `name: a`, wantErr: true},
+	}
+	validate := RequireFields(paths...)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.content)
+			if tt.wantMissing == nil && !tt.wantErr {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidStructuredOutput) {
+				t.Fatalf("err = %v, want %v", err, ErrInvalidStructuredOutput)
+			}
+			var missing *MissingFieldsError
+			if errors.As(err, &missing) != (tt.wantMissing != nil) {
+				t.Fatalf("err = %v, want missing %v", err, tt.wantMissing)
+			}
+			if missing != nil && !slices.Equal(missing.Paths, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing.Paths, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestRequiredFieldsProvider(t *testing.T) {
+	incomplete := outcome{resp: &ChatResponse{Content: `{"name":""}`}}
+	complete := outcome{resp: &ChatResponse{Content: `{"name":"a"}`}}
+	tests := []struct {
+		name       string
+		maxRetries int
+		outcomes   []outcome
+		wantCalls  int
+		wantErr    bool
+	}{
+		{name: "complete", maxRetries: 2, outcomes: []outcome{complete}, wantCalls: 1},
+		{name: "repaired", maxRetries: 2, outcomes: []outcome{incomplete, complete}, wantCalls: 2},
+		{name: "retries exhausted", maxRetries: 2, outcomes: []outcome{incomplete}, wantCalls: 3, wantErr: true},
+		{name: "no retries", outcomes: []outcome{incomplete}, wantCalls: 1, wantErr: true},
+		{name: "negative retries", maxRetries: -1, outcomes: []outcome{incomplete}, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(tt.outcomes...)}
+			p := NewRequiredFieldsProvider(inner, []string{"name"}, tt.maxRetries)
+			_, err := p.Chat(context.Background(), &ChatRequest{Messages: userMessages("give me JSON")})
+			var missing *MissingFieldsError
+			if errors.As(err, &missing) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			calls := inner.requests()
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("calls = %d, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantCalls > 1 {
+				last := calls[1].Messages[len(calls[1].Messages)-1]
+				if last.Role != "user" || !strings.Contains(last.Content, "name") {
+					t.Errorf("repair message = %+v, want one naming the missing field", last)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Values for ResponseFormat.Type.
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object" // Any valid JSON object
+	ResponseFormatJSONSchema = "json_schema" // JSON conforming to JSONSchema
+)
+
+// MetadataFormatDowngraded records how a FormatDowngradeProvider adapted a
+// request's response format: "json_object" when JSON mode was used with the
+// schema in the prompt, or "prompt" when only the prompt carried it.
+const MetadataFormatDowngraded = "format_downgraded"
+
+// ResponseFormat is the structured-output mode of a request, in the OpenAI
+// wire format.
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"` // For ResponseFormatJSONSchema
+}
+
+// JSONSchemaFormat is the schema of a ResponseFormatJSONSchema response.
+type JSONSchemaFormat struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict,omitempty"`
+}
+
+// FormatDowngradeProvider wraps a Provider and adapts structured-output
+// requests to what it supports instead of letting them fail. A JSON-schema
+// request to a provider without Capabilities.StructuredOutput is sent in JSON
+// mode with the schema in the system prompt, or, without Capabilities.JSONMode
+// either, with only the prompt instruction; JSON-mode requests are downgraded
+// the same way. The response's metadata records any downgrade. Output is not
+// validated against the schema; combine with EscalatingProvider for that.
+type FormatDowngradeProvider struct {
+	Provider
+}
+
+// NewFormatDowngradeProvider creates a provider that downgrades response
+// formats inner does not support.
+func NewFormatDowngradeProvider(inner Provider) *FormatDowngradeProvider {
+	return &FormatDowngradeProvider{Provider: inner}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *FormatDowngradeProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat adapts the request's response format if needed and forwards it.
+func (p *FormatDowngradeProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	adapted, mode := p.downgrade(req)
+	resp, err := p.Provider.Chat(ctx, adapted)
+	if err != nil {
+		return nil, err
+	}
+	if mode != "" {
+		resp.SetMetadata(MetadataFormatDowngraded, mode)
+	}
+	return resp, nil
+}
+
+// downgrade returns req adapted to the provider's capabilities and the
+// downgrade applied, or "" if req is sent unchanged.
+func (p *FormatDowngradeProvider) downgrade(req *ChatRequest) (*ChatRequest, string) {
+	format := req.ResponseFormat
+	if format == nil || format.Type == ResponseFormatText {
+		return req, ""
+	}
+	caps := CapabilitiesOf(p.Provider)
+	if format.Type == ResponseFormatJSONSchema && caps.StructuredOutput ||
+		format.Type == ResponseFormatJSONObject && caps.JSONMode {
+		return req, ""
+	}
+
+	instruction := "Respond with a single valid JSON object and nothing else."
+	if format.Type == ResponseFormatJSONSchema && format.JSONSchema != nil {
+		instruction = fmt.Sprintf("Respond with a single valid JSON object, and nothing else, that conforms to this JSON schema:\n%s", format.JSONSchema.Schema)
+	}
+	out := withSystemInstruction(req, instruction)
+	if caps.JSONMode {
+		out.ResponseFormat = &ResponseFormat{Type: ResponseFormatJSONObject}
+		return out, ResponseFormatJSONObject
+	}
+	out.ResponseFormat = nil
+	return out, "prompt"
+}
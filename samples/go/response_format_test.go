@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// formatProvider is a fakeProvider declaring the given capabilities.
+type formatProvider struct {
+	*fakeProvider
+	caps Capabilities
+}
+
+func (p formatProvider) Capabilities() Capabilities { return p.caps }
+
+func TestFormatDowngradeProvider(t *testing.T) {
+	schema := &ResponseFormat{
+		Type:       ResponseFormatJSONSchema,
+		JSONSchema: &JSONSchemaFormat{Name: "answer", Schema: []byte(`{"type":"object"}`)},
+	}
+	tests := []struct {
+		name          string
+		caps          Capabilities
+		format        *ResponseFormat
+		wantFormat    string // Type of the forwarded format, or "" for none
+		wantDowngrade string
+		wantPrompt    string // Substring of the added system instruction, or "" for none
+	}{
+		{name: "no format", wantFormat: ""},
+		{name: "text", format: &ResponseFormat{Type: ResponseFormatText}, wantFormat: ResponseFormatText},
+		{name: "schema supported", caps: Capabilities{StructuredOutput: true}, format: schema, wantFormat: ResponseFormatJSONSchema},
+		{name: "json mode supported", caps: Capabilities{JSONMode: true}, format: &ResponseFormat{Type: ResponseFormatJSONObject}, wantFormat: ResponseFormatJSONObject},
+		{
+			name:          "schema to json mode",
+			caps:          Capabilities{JSONMode: true},
+			format:        schema,
+			wantFormat:    ResponseFormatJSONObject,
+			wantDowngrade: ResponseFormatJSONObject,
+			wantPrompt:    `{"type":"object"}`,
+		},
+		{name: "schema to prompt", format: schema, wantDowngrade: "prompt", wantPrompt: `{"type":"object"}`},
+		{
+			name:          "json mode to prompt",
+			format:        &ResponseFormat{Type: ResponseFormatJSONObject},
+			wantDowngrade: "prompt",
+			wantPrompt:    "single valid JSON object and nothing else",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := formatProvider{fakeProvider: &fakeProvider{}, caps: tt.caps}
+			req := &ChatRequest{Messages: userMessages("hi"), ResponseFormat: tt.format}
+			resp, err := NewFormatDowngradeProvider(inner).Chat(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sent := inner.requests()[0]
+			gotFormat := ""
+			if sent.ResponseFormat != nil {
+				gotFormat = sent.ResponseFormat.Type
+			}
+			if gotFormat != tt.wantFormat {
+				t.Errorf("forwarded format = %q, want %q", gotFormat, tt.wantFormat)
+			}
+			if got, _ := resp.Metadata[MetadataFormatDowngraded].(string); got != tt.wantDowngrade {
+				t.Errorf("downgrade = %q, want %q", got, tt.wantDowngrade)
+			}
+			if tt.wantPrompt == "" {
+				if len(sent.Messages) != 1 {
+					t.Errorf("messages = %+v, want the request unchanged", sent.Messages)
+				}
+				return
+			}
+			if sent.Messages[0].Role != "system" || !strings.Contains(sent.Messages[0].Content, tt.wantPrompt) {
+				t.Errorf("system message = %+v, want it to contain %q", sent.Messages[0], tt.wantPrompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ErrResponseTooLarge is returned when a response exceeds the configured byte limit.
+var ErrResponseTooLarge = errors.New("response exceeds maximum size")
+
+// MetadataSizeTruncated is set to the original content size in bytes on
+// responses truncated by ResponseSizeProvider. On a stream it is set on the
+// terminal chunk to the bytes received when the cap was hit, since the rest is
+// never read.
+const MetadataSizeTruncated = "size_truncated"
+
+// ResponseSizeProvider wraps a Provider and caps response content at a number
+// of bytes, independent of token limits. This matters for CJK and emoji-heavy
+// output, where a token can be several bytes.
+//
+// Oversized content is truncated at a rune boundary, or rejected with
+// ErrResponseTooLarge if reject is set.
+type ResponseSizeProvider struct {
+	Provider
+	maxBytes int
+	reject   bool
+}
+
+// NewResponseSizeProvider creates a provider that limits responses to maxBytes.
+func NewResponseSizeProvider(inner Provider, maxBytes int, reject bool) *ResponseSizeProvider {
+	return &ResponseSizeProvider{Provider: inner, maxBytes: maxBytes, reject: reject}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ResponseSizeProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and enforces the size limit on the response.
+func (p *ResponseSizeProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(resp.Content)
+	if size <= p.maxBytes {
+		return resp, nil
+	}
+	if p.reject {
+		return nil, fmt.Errorf("%w: %d > %d bytes", ErrResponseTooLarge, size, p.maxBytes)
+	}
+
+	resp.Content = truncateUTF8(resp.Content, p.maxBytes)
+	resp.SetMetadata(MetadataSizeTruncated, size)
+	return resp, nil
+}
+
+// ChatStream streams from the wrapped provider until the byte limit is reached,
+// then ends the stream with StreamByteCap. In reject mode the chunk that would
+// cross the limit is withheld and the terminal chunk carries ErrResponseTooLarge.
+func (p *ResponseSizeProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	inner, err := streamingInner(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := inner.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		size := 0
+		// Counting only whole runes keeps a cut from landing inside one that
+		// the provider split across chunks. A rune still incomplete when the
+		// stream ends is dropped rather than emitted as invalid UTF-8.
+		var carry runeCarry
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				return chunk
+			}
+
+			chunk.Content = carry.complete(chunk.Content)
+			if size+len(chunk.Content) <= p.maxBytes {
+				size += len(chunk.Content)
+				if chunk.Content != "" {
+					emit(chunk)
+				}
+				continue
+			}
+
+			if p.reject {
+				return StreamChunk{Done: true, Reason: StreamByteCap, Err: ErrResponseTooLarge}
+			}
+			received := size + len(chunk.Content)
+			if chunk.Content = truncateUTF8(chunk.Content, p.maxBytes-size); chunk.Content != "" {
+				emit(chunk)
+			}
+			final := StreamChunk{Done: true, Reason: StreamByteCap}
+			final.SetMetadata(MetadataSizeTruncated, received)
+			return final
+		}
+	}), nil
+}
+
+// truncateUTF8 returns the longest prefix of s that is at most n bytes and
+// does not split a multi-byte rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
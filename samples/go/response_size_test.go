@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResponseSizeProviderChat(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		max         int
+		reject      bool
+		wantContent string
+		wantErr     bool
+		wantMeta    any
+	}{
+		{"under the limit", "hello", 10, false, "hello", false, nil},
+		{"truncated", "hello world", 5, false, "hello", false, 11},
+		{"truncated at a rune boundary", "日本語", 7, false, "日本", false, 9},
+		{"rejected", "hello world", 5, true, "", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewResponseSizeProvider(&fakeProvider{chat: replyWith(tt.content)}, tt.max, tt.reject)
+			resp, err := p.Chat(context.Background(), &ChatRequest{})
+			if tt.wantErr {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Fatalf("err = %v, want ErrResponseTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Content != tt.wantContent || resp.Metadata[MetadataSizeTruncated] != tt.wantMeta {
+				t.Errorf("content %q, truncated %v; want %q, %v", resp.Content, resp.Metadata[MetadataSizeTruncated], tt.wantContent, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestResponseSizeProviderStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		parts       []string
+		max         int
+		reject      bool
+		wantContent string
+		wantReason  StreamEndReason
+		wantErr     error
+		wantSize    int // MetadataSizeTruncated on the terminal chunk; 0 if unset
+	}{
+		{"under the limit", []string{"ab", "cd"}, 10, false, "abcd", StreamCompleted, nil, 0},
+		{"truncated mid-chunk", []string{"abc", "def"}, 4, false, "abcd", StreamByteCap, nil, 6},
+		{"rune split across chunks", []string{"a\xe6\x97", "\xa5b"}, 4, false, "a日", StreamByteCap, nil, 5},
+		{"split rune that does not fit", []string{"ab\xe6\x97", "\xa5"}, 4, false, "ab", StreamByteCap, nil, 5},
+		{"incomplete rune at the end", []string{"ab\xe6\x97"}, 10, false, "ab", StreamCompleted, nil, 0},
+		{"rejected", []string{"abc", "def"}, 4, true, "abc", StreamByteCap, ErrResponseTooLarge, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewResponseSizeProvider(streamingReply(tt.parts...), tt.max, tt.reject)
+			chunks, err := p.ChatStream(context.Background(), &ChatRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, final := collectStream(chunks)
+			if content != tt.wantContent || final.Reason != tt.wantReason || !errors.Is(final.Err, tt.wantErr) {
+				t.Errorf("content %q, final %+v; want %q ending %s", content, final, tt.wantContent, tt.wantReason)
+			}
+			if size, _ := final.Metadata[MetadataSizeTruncated].(int); size != tt.wantSize {
+				t.Errorf("truncated size = %v, want %d", final.Metadata[MetadataSizeTruncated], tt.wantSize)
+			}
+		})
+	}
+
+	if _, err := NewResponseSizeProvider(&fakeProvider{}, 1, false).ChatStream(context.Background(), &ChatRequest{}); !errors.Is(err, ErrStreamingNotSupported) {
+		t.Errorf("non-streaming inner: err = %v, want ErrStreamingNotSupported", err)
+	}
+}
@@ -0,0 +1,300 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MetadataResponseID records the /responses API's ID for a response, which can
+// be used to continue a stateful conversation.
+const MetadataResponseID = "response_id"
+
+// ResponsesProvider talks to the OpenAI /responses API, which hosts newer
+// features such as built-in tools and stateful responses, behind the same
+// Provider interface as OpenAIProvider.
+type ResponsesProvider struct {
+	api *OpenAIProvider
+}
+
+// NewResponsesProvider creates a provider for the /responses API.
+func NewResponsesProvider(cfg OpenAIConfig) *ResponsesProvider {
+	return &ResponsesProvider{api: NewOpenAIProvider(cfg)}
+}
+
+// ID returns the provider's identifier.
+func (p *ResponsesProvider) ID() string {
+	return p.api.ID()
+}
+
+// Capabilities reports the features of the /responses API.
+func (p *ResponsesProvider) Capabilities() Capabilities {
+	return Capabilities{Tools: true, JSONMode: true, StructuredOutput: true, BuiltinTools: []BuiltinTool{BuiltinWebSearch, BuiltinCodeInterpreter}}
+}
+
+// ListModels returns the models exposed by the API.
+func (p *ResponsesProvider) ListModels(ctx context.Context) ([]string, error) {
+	return p.api.ListModels(ctx)
+}
+
+// IsModelAvailable checks whether model is listed by the API.
+func (p *ResponsesProvider) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	return p.api.IsModelAvailable(ctx, model)
+}
+
+type responsesInputItem struct {
+	Type      string `json:"type,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Content   string `json:"content,omitempty"`
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+type responsesTool struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Container   any             `json:"container,omitempty"` // Required by the code interpreter
+}
+
+type responsesRequest struct {
+	Model           string               `json:"model"`
+	Instructions    string               `json:"instructions,omitempty"`
+	Input           []responsesInputItem `json:"input,omitempty"`
+	Prompt          *storedPromptRef     `json:"prompt,omitempty"`
+	Temperature     float64              `json:"temperature,omitempty"`
+	TopP            float64              `json:"top_p,omitempty"`
+	MaxOutputTokens int                  `json:"max_output_tokens,omitempty"`
+	Tools           []responsesTool      `json:"tools,omitempty"`
+	Reasoning       *responsesReasoning  `json:"reasoning,omitempty"`
+	Text            *responsesText       `json:"text,omitempty"`
+}
+
+type responsesText struct {
+	Format responsesFormat `json:"format"`
+}
+
+// responsesFormat is a ResponseFormat in the /responses wire format, which
+// inlines the JSON schema fields.
+type responsesFormat struct {
+	Type   string          `json:"type"`
+	Name   string          `json:"name,omitempty"`
+	Schema json.RawMessage `json:"schema,omitempty"`
+	Strict bool            `json:"strict,omitempty"`
+}
+
+type responsesReasoning struct {
+	Summary string `json:"summary,omitempty"`
+}
+
+type responsesOutputItem struct {
+	Type    string `json:"type"` // "message", "function_call", "reasoning" or a built-in tool call
+	Summary []struct {
+		Text string `json:"text"`
+	} `json:"summary"`
+	Content []struct {
+		Type        string             `json:"type"`
+		Text        string             `json:"text"`
+		Annotations []openAIAnnotation `json:"annotations"`
+	} `json:"content"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Code      string `json:"code"` // For "code_interpreter_call"
+	Action    *struct {
+		Query   string `json:"query"`
+		Sources []struct {
+			URL string `json:"url"`
+		} `json:"sources"`
+	} `json:"action"` // For "web_search_call"
+}
+
+type responsesResponse struct {
+	ID                string                `json:"id"`
+	Model             string                `json:"model"`
+	Status            string                `json:"status"`
+	Output            []responsesOutputItem `json:"output"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Chat sends the request to the /responses endpoint and maps the result onto a ChatResponse.
+func (p *ResponsesProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+
+	if err := checkGrammar(p, req); err != nil {
+		return nil, err
+	}
+	if err := checkBuiltinTools(p, req); err != nil {
+		return nil, err
+	}
+	wireReq, err := toResponsesRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(wireReq)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := p.api.do(ctx, http.MethodPost, "/responses", req.Model, body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var wire responsesResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&wire); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	resp := fromResponsesResponse(&wire)
+	resp.Latency = time.Since(start)
+	if info, ok := ParseRateLimitHeaders(httpResp.Header); ok {
+		resp.SetMetadata(MetadataRateLimit, info)
+	}
+	if w, ok := ParseDeprecationHeaders(httpResp.Header, req.Model); ok {
+		resp.SetMetadata(MetadataDeprecation, w)
+	}
+	p.api.captureRequest(resp, httpResp, body)
+	return resp, nil
+}
+
+// toResponsesRequest maps a ChatRequest onto the /responses input format.
+// System messages become instructions; tool calls and results become
+// function_call and function_call_output items, and the response format
+// becomes text.format. Response formats the API cannot express are rejected
+// with ErrInvalidRequest.
+func toResponsesRequest(req *ChatRequest) (*responsesRequest, error) {
+	out := &responsesRequest{
+		Model:           req.Model,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		MaxOutputTokens: req.MaxTokens,
+	}
+	if req.PromptID != "" {
+		out.Prompt = &storedPromptRef{ID: req.PromptID, Variables: req.PromptVariables}
+	}
+	if req.ReasoningSummary != "" && req.ReasoningSummary != ReasoningSummaryNone {
+		out.Reasoning = &responsesReasoning{Summary: req.ReasoningSummary}
+	}
+	if f := req.ResponseFormat; f != nil {
+		format := responsesFormat{Type: f.Type}
+		switch f.Type {
+		case ResponseFormatText, ResponseFormatJSONObject:
+		case ResponseFormatJSONSchema:
+			if f.JSONSchema == nil {
+				return nil, fmt.Errorf("%w: %s response format without a schema", ErrInvalidRequest, f.Type)
+			}
+			format.Name, format.Schema, format.Strict = f.JSONSchema.Name, f.JSONSchema.Schema, f.JSONSchema.Strict
+		default:
+			return nil, fmt.Errorf("%w: unknown response format %q", ErrInvalidRequest, f.Type)
+		}
+		out.Text = &responsesText{Format: format}
+	}
+
+	var instructions []string
+	for _, m := range req.Messages {
+		switch {
+		case m.Role == "system":
+			instructions = append(instructions, m.Content)
+		case m.Role == "tool":
+			out.Input = append(out.Input, responsesInputItem{Type: "function_call_output", CallID: m.ToolCallID, Output: m.Content})
+		case len(m.ToolCalls) > 0:
+			if m.Content != "" {
+				out.Input = append(out.Input, responsesInputItem{Role: m.Role, Content: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				out.Input = append(out.Input, responsesInputItem{Type: "function_call", CallID: tc.ID, Name: tc.Name, Arguments: tc.Arguments})
+			}
+		default:
+			out.Input = append(out.Input, responsesInputItem{Role: m.Role, Content: m.Content})
+		}
+	}
+	out.Instructions = strings.Join(instructions, "\n\n")
+
+	for _, t := range req.Tools {
+		out.Tools = append(out.Tools, responsesTool{Type: "function", Name: t.Name, Description: t.Description, Parameters: t.Parameters})
+	}
+	for _, t := range req.BuiltinTools {
+		tool := responsesTool{Type: string(t)}
+		if t == BuiltinCodeInterpreter {
+			tool.Container = map[string]string{"type": "auto"}
+		}
+		out.Tools = append(out.Tools, tool)
+	}
+	return out, nil
+}
+
+// fromResponsesResponse maps a /responses result onto a ChatResponse.
+func fromResponsesResponse(wire *responsesResponse) *ChatResponse {
+	resp := &ChatResponse{Model: wire.Model, FinishReason: "stop"}
+	resp.SetMetadata(MetadataResponseID, wire.ID)
+
+	var content strings.Builder
+	var reasoning []string
+	var builtin []BuiltinToolCall
+	for _, item := range wire.Output {
+		switch item.Type {
+		case "reasoning":
+			for _, part := range item.Summary {
+				reasoning = append(reasoning, part.Text)
+			}
+		case "message":
+			for _, part := range item.Content {
+				if part.Type != "output_text" {
+					continue
+				}
+				resp.Citations = append(resp.Citations, parseCitations(openAIMessage{Content: part.Text, Annotations: part.Annotations})...)
+				content.WriteString(part.Text)
+			}
+		case "function_call":
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: item.CallID, Name: item.Name, Arguments: item.Arguments})
+		case "web_search_call":
+			call := BuiltinToolCall{Tool: BuiltinWebSearch}
+			if item.Action != nil {
+				call.Query = item.Action.Query
+				for _, src := range item.Action.Sources {
+					call.Sources = append(call.Sources, src.URL)
+				}
+			}
+			builtin = append(builtin, call)
+		case "code_interpreter_call":
+			builtin = append(builtin, BuiltinToolCall{Tool: BuiltinCodeInterpreter, Code: item.Code})
+		}
+	}
+	if len(builtin) > 0 {
+		resp.SetMetadata(MetadataBuiltinToolCalls, builtin)
+	}
+	resp.Content = content.String()
+	resp.ReasoningSummary = strings.Join(reasoning, "\n\n")
+
+	switch {
+	case len(resp.ToolCalls) > 0:
+		resp.FinishReason = "tool_calls"
+	case wire.Status == "incomplete" && wire.IncompleteDetails != nil && wire.IncompleteDetails.Reason == "max_output_tokens":
+		resp.FinishReason = "length"
+	case wire.Status == "incomplete":
+		resp.FinishReason = "incomplete"
+	}
+
+	if wire.Usage != nil {
+		resp.Usage = &UsageStats{
+			PromptTokens:     wire.Usage.InputTokens,
+			CompletionTokens: wire.Usage.OutputTokens,
+			TotalTokens:      wire.Usage.TotalTokens,
+		}
+	}
+	return resp
+}
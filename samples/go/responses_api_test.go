@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestToResponsesRequest(t *testing.T) {
+	req := &ChatRequest{
+		Model:     "m",
+		MaxTokens: 100,
+		Messages: []Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "Weather?"},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Name: "weather", Arguments: `{"city":"Oslo"}`}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "rain"},
+		},
+		Tools:            []ToolDefinition{{Name: "weather"}},
+		BuiltinTools:     []BuiltinTool{BuiltinCodeInterpreter},
+		ReasoningSummary: ReasoningSummaryConcise,
+	}
+	got, err := toResponsesRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantInput := []responsesInputItem{
+		{Role: "user", Content: "Weather?"},
+		{Type: "function_call", CallID: "call_1", Name: "weather", Arguments: `{"city":"Oslo"}`},
+		{Type: "function_call_output", CallID: "call_1", Output: "rain"},
+	}
+	if !reflect.DeepEqual(got.Input, wantInput) {
+		t.Errorf("input = %+v, want %+v", got.Input, wantInput)
+	}
+	if got.Instructions != "Be brief." || got.MaxOutputTokens != 100 {
+		t.Errorf("instructions %q, max output tokens %d", got.Instructions, got.MaxOutputTokens)
+	}
+	if len(got.Tools) != 2 || got.Tools[0].Type != "function" || got.Tools[1].Type != string(BuiltinCodeInterpreter) || got.Tools[1].Container == nil {
+		t.Errorf("tools = %+v", got.Tools)
+	}
+	if got.Reasoning == nil || got.Reasoning.Summary != ReasoningSummaryConcise {
+		t.Errorf("reasoning = %+v", got.Reasoning)
+	}
+}
+
+func TestToResponsesRequestFormat(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object"}`)
+	tests := []struct {
+		name    string
+		format  *ResponseFormat
+		want    string // JSON of the "text" field, or "" for none
+		wantErr error
+	}{
+		{name: "none"},
+		{name: "text", format: &ResponseFormat{Type: ResponseFormatText}, want: `{"format":{"type":"text"}}`},
+		{name: "json mode", format: &ResponseFormat{Type: ResponseFormatJSONObject}, want: `{"format":{"type":"json_object"}}`},
+		{
+			name:   "json schema",
+			format: &ResponseFormat{Type: ResponseFormatJSONSchema, JSONSchema: &JSONSchemaFormat{Name: "answer", Schema: schema, Strict: true}},
+			want:   `{"format":{"type":"json_schema","name":"answer","schema":{"type":"object"},"strict":true}}`,
+		},
+		{name: "schema missing", format: &ResponseFormat{Type: ResponseFormatJSONSchema}, wantErr: ErrInvalidRequest},
+		{name: "unknown type", format: &ResponseFormat{Type: "yaml"}, wantErr: ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toResponsesRequest(&ChatRequest{Model: "m", ResponseFormat: tt.format})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			text := ""
+			if got.Text != nil {
+				data, err := json.Marshal(got.Text)
+				if err != nil {
+					t.Fatal(err)
+				}
+				text = string(data)
+			}
+			if text != tt.want {
+				t.Errorf("text = %s, want %s", text, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromResponsesResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		wire       string
+		wantText   string
+		wantFinish string
+		wantCalls  int
+	}{
+		{
+			"message",
+			`{"id":"resp_1","model":"m","status":"completed","output":[{"type":"message","content":[{"type":"output_text","text":"Hi"},{"type":"refusal","text":"no"}]}]}`,
+			"Hi", "stop", 0,
+		},
+		{
+			"function call",
+			`{"id":"resp_1","status":"completed","output":[{"type":"function_call","call_id":"c1","name":"f","arguments":"{}"}]}`,
+			"", "tool_calls", 1,
+		},
+		{
+			"truncated",
+			`{"id":"resp_1","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"},"output":[]}`,
+			"", "length", 0,
+		},
+		{
+			"incomplete for another reason",
+			`{"id":"resp_1","status":"incomplete","incomplete_details":{"reason":"content_filter"},"output":[]}`,
+			"", "incomplete", 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wire responsesResponse
+			if err := json.Unmarshal([]byte(tt.wire), &wire); err != nil {
+				t.Fatal(err)
+			}
+			resp := fromResponsesResponse(&wire)
+			if resp.Content != tt.wantText || resp.FinishReason != tt.wantFinish || len(resp.ToolCalls) != tt.wantCalls {
+				t.Errorf("resp = %+v", resp)
+			}
+			if resp.Metadata[MetadataResponseID] != "resp_1" {
+				t.Errorf("response ID = %v", resp.Metadata[MetadataResponseID])
+			}
+		})
+	}
+}
+
+func TestResponsesProviderChat(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeJSON(w, `{"id":"resp_1","model":"m-2025","status":"completed",
+			"output":[{"type":"reasoning","summary":[{"text":"thought"}]},{"type":"web_search_call","action":{"query":"go","sources":[{"url":"https://go.dev"}]}},
+				{"type":"message","content":[{"type":"output_text","text":"Go","annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev","start_index":0,"end_index":2}}]}]}],
+			"usage":{"input_tokens":3,"output_tokens":1,"total_tokens":4}}`)
+	})
+	p := &ResponsesProvider{api: srv.provider(OpenAIConfig{})}
+	resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi"), BuiltinTools: []BuiltinTool{BuiltinWebSearch}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Go" || resp.Model != "m-2025" || resp.ReasoningSummary != "thought" {
+		t.Errorf("resp = %+v", resp)
+	}
+	if len(resp.Citations) != 1 || resp.Citations[0].Text != "Go" {
+		t.Errorf("citations = %+v", resp.Citations)
+	}
+	if calls, _ := resp.Metadata[MetadataBuiltinToolCalls].([]BuiltinToolCall); len(calls) != 1 || calls[0].Query != "go" {
+		t.Errorf("builtin tool calls = %+v", resp.Metadata[MetadataBuiltinToolCalls])
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if input, _ := srv.lastBody()["input"].([]any); len(input) != 1 {
+		t.Errorf("sent input %v", srv.lastBody()["input"])
+	}
+}
@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrEmptyResponse is returned when a provider keeps returning empty content
+// and RetryConfig.RetryEmpty is set.
+var ErrEmptyResponse = errors.New("empty response from provider")
+
+// RetryConfig configures a RetryProvider.
+type RetryConfig struct {
+	MaxAttempts int           // Total attempts including the first (default 3)
+	Backoff     time.Duration // Delay before the first retry, doubled each time (default 500ms)
+	MaxBackoff  time.Duration // Upper bound on the delay (default 10s)
+
+	// Retryable decides whether an error is worth retrying. Defaults to
+	// RetryTransientAndRateLimit; see the presets for the tradeoffs, or supply
+	// a custom classifier.
+	Retryable func(err error) bool
+
+	// RetryEmpty treats a successful response with no content and no tool calls
+	// as a transient failure. Off by default, since some callers expect empty output.
+	RetryEmpty bool
+
+	// IdempotentOnly restricts retries to requests that are safe to repeat:
+	// those without tools, or marked with WithIdempotent. Other requests fail
+	// on their first error.
+	IdempotentOnly bool
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks whether requests made with ctx are safe to retry,
+// overriding the tools-based default used by RetryConfig.IdempotentOnly.
+func WithIdempotent(ctx context.Context, idempotent bool) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, idempotent)
+}
+
+// IsIdempotent reports whether req may be retried: as marked with
+// WithIdempotent, or otherwise if it does not use tools, since a tool-using
+// turn can lead to side effects.
+func IsIdempotent(ctx context.Context, req *ChatRequest) bool {
+	if v, ok := ctx.Value(idempotentKey{}).(bool); ok {
+		return v
+	}
+	return !usesTools(req)
+}
+
+// RetryProvider wraps a Provider and retries transient failures with
+// exponential backoff.
+type RetryProvider struct {
+	Provider
+	config RetryConfig
+}
+
+// NewRetryProvider creates a provider that retries according to config.
+func NewRetryProvider(inner Provider, config RetryConfig) *RetryProvider {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 500 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 10 * time.Second
+	}
+	if config.Retryable == nil {
+		config.Retryable = RetryTransientAndRateLimit
+	}
+	return &RetryProvider{Provider: inner, config: config}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *RetryProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request, retrying transient failures.
+func (p *RetryProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if p.config.IdempotentOnly && !IsIdempotent(ctx, req) {
+		return p.Provider.Chat(ctx, req)
+	}
+
+	delay := p.config.Backoff
+	var lastErr error
+
+	for attempt := 1; ; attempt++ {
+		resp, err := p.Provider.Chat(ctx, req)
+		switch {
+		case err == nil && p.config.RetryEmpty && isEmptyResponse(resp):
+			lastErr = ErrEmptyResponse
+		case err == nil:
+			return resp, nil
+		case !p.config.Retryable(err):
+			return nil, err
+		default:
+			lastErr = err
+		}
+
+		if attempt >= p.config.MaxAttempts {
+			return nil, fmt.Errorf("after %d attempts: %w", attempt, lastErr)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+		delay = min(delay*2, p.config.MaxBackoff)
+	}
+}
+
+// Presets for RetryConfig.Retryable.
+//
+// RetryTransientAndRateLimit retries every transient failure, including rate
+// limits: it maximizes the chance of each request succeeding, at the cost of
+// adding load to a provider that is already shedding it.
+//
+// RetryServerErrorsOnly retries only server errors, timeouts and dropped
+// connections, and fails fast on rate limits so that callers under pressure
+// shed load, or fall back to another provider, instead of queueing retries.
+var (
+	RetryTransientAndRateLimit = IsTransient
+	RetryServerErrorsOnly      = IsServerError
+)
+
+// IsTransient reports whether err is likely to succeed on retry: rate limits,
+// server errors, timeouts and dropped connections. Context cancellation is
+// never transient.
+func IsTransient(err error) bool {
+	if isContextError(err) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrEmptyResponse) {
+		return true
+	}
+	return IsServerError(err)
+}
+
+// IsServerError reports whether err is a server-side or connection failure:
+// a 5xx or 408 status, a network error or a dropped connection. Rate limits
+// and context cancellation are not server errors.
+func IsServerError(err error) bool {
+	if isContextError(err) {
+		return false
+	}
+
+	var perr *ProviderError
+	if errors.As(err, &perr) {
+		return perr.StatusCode >= 500 || perr.StatusCode == http.StatusRequestTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isEmptyResponse reports whether a response carries nothing usable.
+func isEmptyResponse(resp *ChatResponse) bool {
+	return strings.TrimSpace(resp.Content) == "" && len(resp.ToolCalls) == 0
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
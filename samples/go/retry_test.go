@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryProvider(t *testing.T) {
+	ok := outcome{resp: &ChatResponse{Content: "ok"}}
+	empty := outcome{resp: &ChatResponse{Content: "  "}}
+	serverErr := outcome{err: &ProviderError{StatusCode: http.StatusBadGateway}}
+	rateLimited := outcome{err: &ProviderError{StatusCode: http.StatusTooManyRequests}}
+	badRequest := outcome{err: &ProviderError{StatusCode: http.StatusBadRequest}}
+	tools := []ToolDefinition{{Name: "send_email"}}
+
+	tests := []struct {
+		name      string
+		config    RetryConfig
+		outcomes  []outcome
+		tools     []ToolDefinition
+		ctx       context.Context
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", RetryConfig{}, []outcome{ok}, nil, nil, 1, nil},
+		{"recovers from a server error", RetryConfig{}, []outcome{serverErr, ok}, nil, nil, 2, nil},
+		{"gives up after max attempts", RetryConfig{MaxAttempts: 3}, []outcome{serverErr}, nil, nil, 3, &ProviderError{}},
+		{"does not retry client errors", RetryConfig{}, []outcome{badRequest, ok}, nil, nil, 1, &ProviderError{}},
+		{"retries rate limits by default", RetryConfig{}, []outcome{rateLimited, ok}, nil, nil, 2, nil},
+		{"server errors only retries server errors", RetryConfig{Retryable: RetryServerErrorsOnly}, []outcome{serverErr, ok}, nil, nil, 2, nil},
+		{"server errors only skips rate limits", RetryConfig{Retryable: RetryServerErrorsOnly}, []outcome{rateLimited, ok}, nil, nil, 1, ErrRateLimited},
+		{"empty accepted by default", RetryConfig{}, []outcome{empty, ok}, nil, nil, 1, nil},
+		{"empty retried when enabled", RetryConfig{RetryEmpty: true}, []outcome{empty, ok}, nil, nil, 2, nil},
+		{"persistently empty", RetryConfig{RetryEmpty: true, MaxAttempts: 2}, []outcome{empty}, nil, nil, 2, ErrEmptyResponse},
+		{"tool requests are not retried", RetryConfig{IdempotentOnly: true}, []outcome{serverErr, ok}, tools, nil, 1, &ProviderError{}},
+		{"marked idempotent", RetryConfig{IdempotentOnly: true}, []outcome{serverErr, ok}, tools, WithIdempotent(context.Background(), true), 2, nil},
+		{"marked non-idempotent", RetryConfig{IdempotentOnly: true}, []outcome{serverErr, ok}, nil, WithIdempotent(context.Background(), false), 1, &ProviderError{}},
+		{"tool requests retried when unrestricted", RetryConfig{}, []outcome{serverErr, ok}, tools, nil, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(tt.outcomes...)}
+			config := tt.config
+			config.Backoff = time.Millisecond
+			p := NewRetryProvider(inner, config)
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			_, err := p.Chat(ctx, &ChatRequest{Tools: tt.tools})
+			if n := len(inner.requests()); n != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", n, tt.wantCalls)
+			}
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("err = %v", err)
+				}
+			case *ProviderError:
+				var perr *ProviderError
+				if !errors.As(err, &perr) {
+					t.Errorf("err = %v, want a ProviderError", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("err = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	tools := []ToolDefinition{{Name: "send_email"}}
+	bg := context.Background()
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		tools []ToolDefinition
+		want  bool
+	}{
+		{"plain request", bg, nil, true},
+		{"uses tools", bg, tools, false},
+		{"tools marked idempotent", WithIdempotent(bg, true), tools, true},
+		{"plain marked non-idempotent", WithIdempotent(bg, false), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsIdempotent(tt.ctx, &ChatRequest{Tools: tt.tools}); got != tt.want {
+				t.Errorf("IsIdempotent = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryProviderStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	inner := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		cancel()
+		return nil, &ProviderError{StatusCode: http.StatusServiceUnavailable}
+	}}
+	_, err := NewRetryProvider(inner, RetryConfig{Backoff: time.Hour}).Chat(ctx, &ChatRequest{})
+	if !errors.Is(err, context.Canceled) || len(inner.requests()) != 1 {
+		t.Errorf("err = %v after %d calls, want cancellation after 1", err, len(inner.requests()))
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err         error
+		transient   bool
+		serverError bool
+	}{
+		{&ProviderError{StatusCode: 503}, true, true},
+		{&ProviderError{StatusCode: 408}, true, true},
+		{&ProviderError{StatusCode: 429}, true, false},
+		{&ProviderError{StatusCode: 400}, false, false},
+		{fmt.Errorf("%w: slow down", ErrRateLimited), true, false},
+		{ErrEmptyResponse, true, false},
+		{io.ErrUnexpectedEOF, true, true},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true, true},
+		{fmt.Errorf("request: %w", context.Canceled), false, false},
+		{context.Canceled, false, false},
+		{context.DeadlineExceeded, false, false},
+		{errors.New("boom"), false, false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.transient {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.transient)
+		}
+		if got := IsServerError(tt.err); got != tt.serverError {
+			t.Errorf("IsServerError(%v) = %v, want %v", tt.err, got, tt.serverError)
+		}
+	}
+}
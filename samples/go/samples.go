@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Redactor removes sensitive information from text.
+type Redactor func(text string) string
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern  = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)[\s.-]?|\b\d{2,4}[\s.-])\d{3,4}[\s.-]?\d{4}\b|\+\d{10,14}\b`)
+	secretPattern = regexp.MustCompile(`\b(sk|pk|api|key|token)[-_][A-Za-z0-9_-]{16,}\b`)
+)
+
+// DefaultRedactor masks email addresses, phone numbers and API-key-like tokens.
+// Phone numbers must be grouped, as in "555-123-4567" or "+44 20 7946 0958",
+// or written in international form, so that dates, versions and bare IDs are
+// left alone.
+func DefaultRedactor(text string) string {
+	text = SecretRedactor(text)
+	text = emailPattern.ReplaceAllString(text, "[EMAIL]")
+	return phonePattern.ReplaceAllString(text, "[PHONE]")
+}
+
+// SecretRedactor masks only API-key-like tokens, leaving the rest of the text
+// as it was.
+func SecretRedactor(text string) string {
+	return secretPattern.ReplaceAllString(text, "[SECRET]")
+}
+
+// RequestSample is a redacted record of one request and its outcome.
+type RequestSample struct {
+	Time     time.Time     `json:"time"`
+	Provider string        `json:"provider"`
+	Request  ChatRequest   `json:"request"`
+	Response *ChatResponse `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Latency  time.Duration `json:"latency"`
+}
+
+// SampleBufferProvider wraps a Provider and keeps the most recent requests and
+// responses, redacted, in a fixed-size ring buffer for debugging without full logging.
+type SampleBufferProvider struct {
+	Provider
+	redact Redactor
+
+	mu      sync.Mutex
+	samples []RequestSample
+	next    int
+	full    bool
+}
+
+// NewSampleBufferProvider creates a provider that retains the last size samples.
+// A nil redact uses DefaultRedactor.
+func NewSampleBufferProvider(inner Provider, size int, redact Redactor) *SampleBufferProvider {
+	if redact == nil {
+		redact = DefaultRedactor
+	}
+	return &SampleBufferProvider{Provider: inner, redact: redact, samples: make([]RequestSample, max(size, 1))}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *SampleBufferProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and records a sample of it.
+func (p *SampleBufferProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+	resp, err := p.Provider.Chat(ctx, req)
+
+	sample := RequestSample{
+		Time:     start,
+		Provider: p.ID(),
+		Request:  p.redactRequest(req),
+		Latency:  time.Since(start),
+	}
+	if err != nil {
+		sample.Error = p.redact(err.Error())
+	} else {
+		sample.Response = cloneResponse(resp)
+		sample.Response.Content = p.redact(resp.Content)
+		sample.Response.ToolCalls = p.redactToolCalls(resp.ToolCalls)
+	}
+	p.add(sample)
+
+	return resp, err
+}
+
+// Snapshot returns the retained samples, oldest first.
+func (p *SampleBufferProvider) Snapshot() []RequestSample {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.full {
+		return append([]RequestSample(nil), p.samples[:p.next]...)
+	}
+	out := make([]RequestSample, 0, len(p.samples))
+	out = append(out, p.samples[p.next:]...)
+	return append(out, p.samples[:p.next]...)
+}
+
+func (p *SampleBufferProvider) add(sample RequestSample) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.samples[p.next] = sample
+	p.next = (p.next + 1) % len(p.samples)
+	if p.next == 0 {
+		p.full = true
+	}
+}
+
+func (p *SampleBufferProvider) redactRequest(req *ChatRequest) ChatRequest {
+	out := *req
+	out.Messages = make([]Message, len(req.Messages))
+	for i, m := range req.Messages {
+		m.Content = p.redact(m.Content)
+		m.ToolCalls = p.redactToolCalls(m.ToolCalls)
+		out.Messages[i] = m
+	}
+	if len(req.PromptVariables) > 0 {
+		out.PromptVariables = make(map[string]string, len(req.PromptVariables))
+		for k, v := range req.PromptVariables {
+			out.PromptVariables[k] = p.redact(v)
+		}
+	}
+	return out
+}
+
+// redactToolCalls returns a copy of calls with their arguments redacted.
+func (p *SampleBufferProvider) redactToolCalls(calls []ToolCall) []ToolCall {
+	if len(calls) == 0 {
+		return calls
+	}
+	out := make([]ToolCall, len(calls))
+	for i, tc := range calls {
+		tc.Arguments = p.redact(tc.Arguments)
+		out[i] = tc
+	}
+	return out
+}
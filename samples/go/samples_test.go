@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRedactor(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"mail ada@example.com now", "mail [EMAIL] now"},
+		{"call 555-123-4567", "call [PHONE]"},
+		{"call +1 (555) 123-4567", "call [PHONE]"},
+		{"ring +44 20 7946 0958", "ring [PHONE]"},
+		{"text +4915112345678", "text [PHONE]"},
+		{"key sk-abcdefghijklmnop1234", "key [SECRET]"},
+		{"released 2024-01-15", "released 2024-01-15"},
+		{"order 1234567890 shipped", "order 1234567890 shipped"},
+		{"host 192.168.100.200", "host 192.168.100.200"},
+		{"pi is 3.14159265", "pi is 3.14159265"},
+	}
+	for _, tt := range tests {
+		if got := DefaultRedactor(tt.in); got != tt.want {
+			t.Errorf("DefaultRedactor(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSampleBufferProvider(t *testing.T) {
+	inner := &fakeProvider{chat: func(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+		if req.Model == "fail" {
+			return nil, errors.New("quota exceeded for ada@example.com")
+		}
+		return &ChatResponse{
+			Content:   "Emailing ada@example.com",
+			ToolCalls: []ToolCall{{ID: "c1", Name: "send", Arguments: `{"to":"ada@example.com"}`}},
+		}, nil
+	}}
+	p := NewSampleBufferProvider(inner, 2, nil)
+
+	history := []Message{
+		{Role: "user", Content: "My number is 555-123-4567"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "c0", Name: "lookup", Arguments: `{"phone":"555-123-4567"}`}}},
+	}
+	resp, err := p.Chat(context.Background(), &ChatRequest{Model: "m", Messages: history, PromptVariables: map[string]string{"email": "ada@example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.ToolCalls[0].Arguments, "ada@example.com") || !strings.Contains(history[1].ToolCalls[0].Arguments, "555") {
+		t.Fatal("redaction modified the caller's request or response")
+	}
+	p.Chat(context.Background(), &ChatRequest{Model: "fail"})
+	p.Chat(context.Background(), &ChatRequest{Model: "m"})
+
+	samples := p.Snapshot()
+	if len(samples) != 2 || samples[0].Request.Model != "fail" || samples[1].Request.Model != "m" {
+		t.Fatalf("samples = %+v, want the last two, oldest first", samples)
+	}
+	if samples[0].Error != "quota exceeded for [EMAIL]" {
+		t.Errorf("error = %q", samples[0].Error)
+	}
+
+	// Re-record the first request to inspect its redaction.
+	p.Chat(context.Background(), &ChatRequest{Model: "m", Messages: history, PromptVariables: map[string]string{"email": "ada@example.com"}})
+	s := p.Snapshot()[1]
+	for _, text := range []string{
+		s.Request.Messages[0].Content,
+		s.Request.Messages[1].ToolCalls[0].Arguments,
+		s.Request.PromptVariables["email"],
+		s.Response.Content,
+		s.Response.ToolCalls[0].Arguments,
+	} {
+		if strings.Contains(text, "ada@example.com") || strings.Contains(text, "555-123-4567") {
+			t.Errorf("sample retains personal data: %q", text)
+		}
+	}
+}
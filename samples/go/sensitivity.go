@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrNoApprovedProvider is returned by SensitivityRouter when no provider
+// approved for a request's sensitivity level offers its model.
+var ErrNoApprovedProvider = errors.New("no provider approved for sensitivity level")
+
+// MetadataSensitivity records the sensitivity level a SensitivityRouter routed a request under.
+const MetadataSensitivity = "sensitivity"
+
+// Sensitivity is a data-sensitivity label, ordered from least to most sensitive.
+type Sensitivity string
+
+const (
+	SensitivityPublic       Sensitivity = "public"
+	SensitivityInternal     Sensitivity = "internal"
+	SensitivityConfidential Sensitivity = "confidential"
+)
+
+// rank orders sensitivity levels. Unknown labels rank as confidential, so a
+// mistyped label never loosens routing.
+func (s Sensitivity) rank() int {
+	switch s {
+	case SensitivityPublic:
+		return 0
+	case SensitivityInternal:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type sensitivityKey struct{}
+
+// WithSensitivity returns a context whose requests carry the given data-sensitivity label.
+func WithSensitivity(ctx context.Context, s Sensitivity) context.Context {
+	return context.WithValue(ctx, sensitivityKey{}, s)
+}
+
+// SensitivityFromContext returns the label set by WithSensitivity, if any.
+func SensitivityFromContext(ctx context.Context) (Sensitivity, bool) {
+	s, ok := ctx.Value(sensitivityKey{}).(Sensitivity)
+	return s, ok
+}
+
+// SensitivityRoute is a provider and the most sensitive level it is approved
+// for, e.g. SensitivityConfidential for an on-prem Ollama deployment.
+type SensitivityRoute struct {
+	Provider    Provider
+	MaxApproved Sensitivity
+}
+
+// SensitivityRouter is a Provider that sends each request to the first route
+// approved for the request's sensitivity label and offering its model, so that
+// confidential traffic only ever reaches approved providers.
+type SensitivityRouter struct {
+	id        string
+	routes    []SensitivityRoute
+	unlabeled Sensitivity
+}
+
+// NewSensitivityRouter creates a router over routes, in order of preference,
+// applying the level unlabeled to requests that carry no label.
+func NewSensitivityRouter(id string, routes []SensitivityRoute, unlabeled Sensitivity) *SensitivityRouter {
+	return &SensitivityRouter{id: id, routes: routes, unlabeled: unlabeled}
+}
+
+// ID returns the router's identifier.
+func (r *SensitivityRouter) ID() string {
+	return r.id
+}
+
+// Route returns the provider for a request under ctx's sensitivity label and the level applied.
+func (r *SensitivityRouter) Route(ctx context.Context, req *ChatRequest) (Provider, Sensitivity, error) {
+	level, ok := SensitivityFromContext(ctx)
+	if !ok {
+		level = r.unlabeled
+	}
+	for _, route := range r.routes {
+		if route.MaxApproved.rank() < level.rank() {
+			continue
+		}
+		if ok, err := route.Provider.IsModelAvailable(ctx, req.Model); err == nil && ok {
+			return route.Provider, level, nil
+		}
+	}
+	return nil, level, fmt.Errorf("%w: %s for %s", ErrNoApprovedProvider, level, req.Model)
+}
+
+// Chat sends the request to the provider chosen for its sensitivity label.
+func (r *SensitivityRouter) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	provider, level, err := r.Route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.SetMetadata(MetadataSensitivity, level)
+	return resp, nil
+}
+
+// ChatStream streams from the provider chosen for the request's sensitivity label.
+func (r *SensitivityRouter) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	provider, _, err := r.Route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := streamingInner(provider)
+	if err != nil {
+		return nil, err
+	}
+	return sp.ChatStream(ctx, req)
+}
+
+// IsModelAvailable reports whether any route's provider offers model.
+func (r *SensitivityRouter) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	for _, route := range r.routes {
+		ok, err := route.Provider.IsModelAvailable(ctx, model)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListModels returns the models of every route's provider.
+func (r *SensitivityRouter) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	for _, route := range r.routes {
+		m, err := route.Provider.ListModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range m {
+			if !slices.Contains(models, name) {
+				models = append(models, name)
+			}
+		}
+	}
+	return models, nil
+}
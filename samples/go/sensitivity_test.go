@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSensitivityRouter(t *testing.T) {
+	newRouter := func() *SensitivityRouter {
+		cloud := &fakeProvider{id: "cloud", models: []string{"gpt-4o", "llama3"}}
+		internal := &fakeProvider{id: "internal", models: []string{"gpt-4o"}}
+		onPrem := streamingReply("ok")
+		onPrem.id, onPrem.models = "onprem", []string{"llama3"}
+		return NewSensitivityRouter("router", []SensitivityRoute{
+			{Provider: cloud, MaxApproved: SensitivityPublic},
+			{Provider: internal, MaxApproved: SensitivityInternal},
+			{Provider: onPrem, MaxApproved: SensitivityConfidential},
+		}, SensitivityInternal)
+	}
+
+	tests := []struct {
+		name    string
+		label   Sensitivity // "" for an unlabeled request
+		model   string
+		want    string
+		wantErr error
+	}{
+		{name: "public prefers the first route", label: SensitivityPublic, model: "gpt-4o", want: "cloud"},
+		{name: "unlabeled uses the default level", model: "gpt-4o", want: "internal"},
+		{name: "confidential stays on prem", label: SensitivityConfidential, model: "llama3", want: "onprem"},
+		{name: "unknown label is confidential", label: "secret", model: "llama3", want: "onprem"},
+		{name: "no approved provider offers the model", label: SensitivityConfidential, model: "gpt-4o", wantErr: ErrNoApprovedProvider},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.label != "" {
+				ctx = WithSensitivity(ctx, tt.label)
+			}
+			req := &ChatRequest{Model: tt.model, Messages: userMessages("hi")}
+			r := newRouter()
+
+			provider, _, err := r.Route(ctx, req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Route err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if _, err := r.Chat(ctx, req); !errors.Is(err, tt.wantErr) {
+					t.Errorf("Chat err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if provider.ID() != tt.want {
+				t.Fatalf("routed to %s, want %s", provider.ID(), tt.want)
+			}
+
+			resp, err := r.Chat(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantLevel := tt.label
+			if wantLevel == "" {
+				wantLevel = SensitivityInternal
+			}
+			if resp.Metadata[MetadataSensitivity] != wantLevel {
+				t.Errorf("sensitivity = %v, want %v", resp.Metadata[MetadataSensitivity], wantLevel)
+			}
+		})
+	}
+}
+
+func TestSensitivityRouterStream(t *testing.T) {
+	cloud := &fakeProvider{id: "cloud"}
+	onPrem := streamingReply("a", "b")
+	r := NewSensitivityRouter("router", []SensitivityRoute{
+		{Provider: cloud, MaxApproved: SensitivityPublic},
+		{Provider: onPrem, MaxApproved: SensitivityConfidential},
+	}, SensitivityPublic)
+	req := &ChatRequest{Model: "m"}
+
+	chunks, err := r.ChatStream(WithSensitivity(context.Background(), SensitivityConfidential), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := collectStream(chunks); content != "ab" {
+		t.Errorf("content = %q, want the on-prem stream", content)
+	}
+	if _, err := r.ChatStream(context.Background(), req); !errors.Is(err, ErrStreamingNotSupported) {
+		t.Errorf("err = %v, want %v from the non-streaming route", err, ErrStreamingNotSupported)
+	}
+}
+
+func TestSensitivityRouterModels(t *testing.T) {
+	r := NewSensitivityRouter("router", []SensitivityRoute{
+		{Provider: &fakeProvider{models: []string{"a", "b"}}, MaxApproved: SensitivityPublic},
+		{Provider: &fakeProvider{models: []string{"b", "c"}}, MaxApproved: SensitivityConfidential},
+	}, SensitivityPublic)
+
+	models, err := r.ListModels(context.Background())
+	if err != nil || !slices.Equal(models, []string{"a", "b", "c"}) {
+		t.Errorf("ListModels = %v, %v, want [a b c]", models, err)
+	}
+	for model, want := range map[string]bool{"c": true, "d": false} {
+		if ok, err := r.IsModelAvailable(context.Background(), model); err != nil || ok != want {
+			t.Errorf("IsModelAvailable(%q) = %v, %v, want %v", model, ok, err, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sentenceAbbreviations end with a period without ending a sentence. They are
+// compared lowercased and without the trailing period.
+var sentenceAbbreviations = []string{
+	"mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "vs", "etc", "inc", "ltd", "co",
+	"no", "fig", "e.g", "i.e", "approx", "dept", "est", "jan", "feb", "mar", "apr",
+	"jun", "jul", "aug", "sep", "sept", "oct", "nov", "dec",
+}
+
+// SentenceStreamProvider wraps a StreamingProvider and re-chunks its stream so
+// that every chunk is one or more complete sentences, for text-to-speech
+// pipelines that should start speaking as soon as a sentence is complete.
+// Line breaks also end a chunk. Whatever remains when the stream ends is
+// flushed before the terminal chunk.
+type SentenceStreamProvider struct {
+	StreamingProvider
+}
+
+// NewSentenceStreamProvider creates a provider that flushes at sentence boundaries.
+func NewSentenceStreamProvider(inner StreamingProvider) *SentenceStreamProvider {
+	return &SentenceStreamProvider{StreamingProvider: inner}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *SentenceStreamProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream relays the stream in sentence-aligned chunks.
+func (p *SentenceStreamProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		var pending string
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				if pending != "" {
+					emit(StreamChunk{Content: pending})
+				}
+				return chunk
+			}
+
+			pending += chunk.Content
+			if n := sentenceEnd(pending); n > 0 {
+				emit(StreamChunk{Content: pending[:n]})
+				pending = pending[n:]
+			}
+		}
+	}), nil
+}
+
+// sentenceEnd returns the length of the longest prefix of s made of complete
+// sentences, including the whitespace that follows the last one, or 0 if s
+// does not yet contain a complete sentence. A sentence is complete once the
+// character after its terminator has arrived and is whitespace.
+func sentenceEnd(s string) int {
+	end := 0
+	for i, r := range s {
+		if r == '\n' {
+			end = i + 1
+			continue
+		}
+		if !unicode.IsSpace(r) || i == 0 {
+			continue
+		}
+
+		// Step back over closing quotes and brackets to the terminator.
+		j := i
+		for j > 0 {
+			prev, size := utf8.DecodeLastRuneInString(s[:j])
+			if !strings.ContainsRune(`"')]”’»`, prev) {
+				break
+			}
+			j -= size
+		}
+		term, size := utf8.DecodeLastRuneInString(s[:j])
+		switch term {
+		case '!', '?', '…':
+		case '.':
+			if isAbbreviation(s[:j-size]) {
+				continue
+			}
+		default:
+			continue
+		}
+		end = i + utf8.RuneLen(r)
+	}
+	return end
+}
+
+// isAbbreviation reports whether the word ending text, which precedes a
+// period, is an abbreviation or an initial rather than the end of a sentence.
+func isAbbreviation(text string) bool {
+	start := strings.LastIndexFunc(text, unicode.IsSpace) + 1
+	word := strings.ToLower(strings.TrimLeft(text[start:], `"'([“‘«`))
+	if utf8.RuneCountInString(word) == 1 {
+		r, _ := utf8.DecodeRuneInString(word)
+		return unicode.IsLetter(r) // An initial, as in "J. Smith"
+	}
+	return slices.Contains(sentenceAbbreviations, word)
+}
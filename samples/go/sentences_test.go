@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSentenceEnd(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // The complete-sentence prefix
+	}{
+		{"Hello world", ""},
+		{"Hello world.", ""}, // The next character has not arrived
+		{"Hello world. How", "Hello world. "},
+		{"One! Two? Three", "One! Two? "},
+		{`He said "stop." Then`, `He said "stop." `},
+		{"Ask Dr. Smith", ""},
+		{"See J. Smith now", ""},
+		{"Costs rose, e.g. rent", ""},
+		{"Wait… what", "Wait… "},
+		{"line one\nline", "line one\n"},
+		{"3.14 is pi", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.in[:sentenceEnd(tt.in)]; got != tt.want {
+			t.Errorf("sentenceEnd(%q) prefix = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSentenceStreamProvider(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		want  []string
+	}{
+		{"sentences across chunks", []string{"Hel", "lo. Ho", "w are", " you? Fine"}, []string{"Hello. ", "How are you? ", "Fine"}},
+		{"several sentences in one chunk", []string{"Go on. Stop here. Then"}, []string{"Go on. Stop here. ", "Then"}},
+		{"no terminator", []string{"just ", "words"}, []string{"just words"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := NewSentenceStreamProvider(streamingReply(tt.parts...)).ChatStream(context.Background(), &ChatRequest{Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for c := range chunks {
+				if !c.Done {
+					got = append(got, c.Content)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Errors returned by ReplayProvider.
+var (
+	ErrReplayDiverged  = errors.New("replayed request diverges from recording")
+	ErrReplayExhausted = errors.New("recording has no more turns")
+)
+
+// RecordedTurn is one request of a recorded session and its outcome.
+type RecordedTurn struct {
+	Request  *ChatRequest  `json:"request"`
+	Response *ChatResponse `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+
+	// ErrorClass names the package sentinel the error matched, if any, so the
+	// replayed error still satisfies errors.Is; see recordedErrorClasses.
+	ErrorClass string `json:"error_class,omitempty"`
+}
+
+// recordedErrorClasses are the sentinels a recorded error is classified as,
+// most specific first, under names that stay stable in saved recordings.
+var recordedErrorClasses = []struct {
+	name string
+	err  error
+}{
+	{"rate_limited", ErrRateLimited},
+	{"model_deprecated", ErrModelDeprecated},
+	{"model_not_available", ErrModelNotAvailable},
+	{"invalid_request", ErrInvalidRequest},
+	{"invalid_response", ErrInvalidResponse},
+	{"provider_not_found", ErrProviderNotFound},
+	{"context_canceled", ErrContextCanceled},
+	{"canceled", context.Canceled},
+	{"deadline_exceeded", context.DeadlineExceeded},
+}
+
+// errorClass returns the name of the first class err matches, or "".
+func errorClass(err error) string {
+	for _, c := range recordedErrorClasses {
+		if errors.Is(err, c.err) {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// ReplayedError is a recorded error returned by ReplayProvider. It has the
+// recorded message and matches the recorded sentinel class with errors.Is.
+type ReplayedError struct {
+	Message string
+	Class   string
+}
+
+func (e *ReplayedError) Error() string {
+	return e.Message
+}
+
+// Is reports whether target is the sentinel of the recorded class.
+func (e *ReplayedError) Is(target error) bool {
+	for _, c := range recordedErrorClasses {
+		if c.name == e.Class {
+			return target == c.err
+		}
+	}
+	return false
+}
+
+// MarshalJSON encodes the turn with its request in the persisted request
+// format, so that recordings survive changes to ChatRequest.
+func (t RecordedTurn) MarshalJSON() ([]byte, error) {
+	type turnWire RecordedTurn
+	out := struct {
+		turnWire
+		Request json.RawMessage `json:"request"`
+	}{turnWire: turnWire(t)}
+
+	if t.Request != nil {
+		data, err := MarshalPersistedRequest(t.Request)
+		if err != nil {
+			return nil, err
+		}
+		out.Request = data
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a turn, migrating its request to the current format.
+func (t *RecordedTurn) UnmarshalJSON(data []byte) error {
+	type turnWire RecordedTurn
+	var in struct {
+		turnWire
+		Request json.RawMessage `json:"request"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*t = RecordedTurn(in.turnWire)
+	if len(in.Request) > 0 && string(in.Request) != "null" {
+		req, err := UnmarshalPersistedRequest(in.Request)
+		if err != nil {
+			return err
+		}
+		t.Request = req
+	}
+	return nil
+}
+
+// SessionRecording is the ordered sequence of turns of a session.
+type SessionRecording struct {
+	Turns []RecordedTurn `json:"turns"`
+}
+
+// LoadSessionRecording decodes a recording written by RecordingProvider.Save.
+func LoadSessionRecording(r io.Reader) (*SessionRecording, error) {
+	var rec SessionRecording
+	if err := json.NewDecoder(r).Decode(&rec); err != nil {
+		return nil, fmt.Errorf("decode session recording: %w", err)
+	}
+	return &rec, nil
+}
+
+// RecordingProvider wraps a Provider and records every request and outcome
+// in order, so that multi-turn sessions such as agent runs can be replayed
+// with ReplayProvider.
+type RecordingProvider struct {
+	Provider
+
+	mu    sync.Mutex
+	turns []RecordedTurn
+}
+
+// NewRecordingProvider creates a provider that records the session through inner.
+func NewRecordingProvider(inner Provider) *RecordingProvider {
+	return &RecordingProvider{Provider: inner}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *RecordingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and records it with its response or error.
+func (p *RecordingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+
+	turn := RecordedTurn{Request: cloneRequest(req)}
+	if err != nil {
+		turn.Error, turn.ErrorClass = err.Error(), errorClass(err)
+	} else {
+		turn.Response = cloneResponse(resp)
+	}
+	p.mu.Lock()
+	p.turns = append(p.turns, turn)
+	p.mu.Unlock()
+
+	return resp, err
+}
+
+// Recording returns the session recorded so far.
+func (p *RecordingProvider) Recording() *SessionRecording {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &SessionRecording{Turns: slices.Clone(p.turns)}
+}
+
+// Save writes the session recorded so far as JSON.
+func (p *RecordingProvider) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p.Recording())
+}
+
+// ReplayProvider serves a recorded session's responses in order, without
+// calling a backend, to reproduce a session deterministically. Each request
+// must match the recorded one; a divergence returns ErrReplayDiverged.
+// Recorded errors are returned as *ReplayedError.
+type ReplayProvider struct {
+	id  string
+	rec *SessionRecording
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewReplayProvider creates a provider with id that replays rec.
+func NewReplayProvider(id string, rec *SessionRecording) *ReplayProvider {
+	return &ReplayProvider{id: id, rec: rec}
+}
+
+// ID returns the provider's identifier.
+func (p *ReplayProvider) ID() string {
+	return p.id
+}
+
+// Chat returns the next recorded outcome if req matches the recorded request.
+func (p *ReplayProvider) Chat(_ context.Context, req *ChatRequest) (*ChatResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.next >= len(p.rec.Turns) {
+		return nil, fmt.Errorf("%w: after %d turns", ErrReplayExhausted, len(p.rec.Turns))
+	}
+	turn := p.rec.Turns[p.next]
+	if turn.Request == nil {
+		// A hand-edited or truncated recording may lack the request.
+		return nil, fmt.Errorf("%w: turn %d has no request", ErrReplayDiverged, p.next+1)
+	}
+
+	same, err := sameRequest(turn.Request, req)
+	if err != nil {
+		return nil, err
+	}
+	if !same {
+		return nil, fmt.Errorf("%w: turn %d", ErrReplayDiverged, p.next+1)
+	}
+	p.next++
+
+	if turn.Error != "" {
+		return nil, &ReplayedError{Message: turn.Error, Class: turn.ErrorClass}
+	}
+	return cloneResponse(turn.Response), nil
+}
+
+// Remaining returns the number of recorded turns not yet replayed.
+func (p *ReplayProvider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.rec.Turns) - p.next
+}
+
+// IsModelAvailable reports whether model appears in the recording.
+func (p *ReplayProvider) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	models, _ := p.ListModels(ctx)
+	return slices.Contains(models, model), nil
+}
+
+// ListModels returns the models requested in the recording.
+func (p *ReplayProvider) ListModels(context.Context) ([]string, error) {
+	var models []string
+	for _, t := range p.rec.Turns {
+		if t.Request != nil && !slices.Contains(models, t.Request.Model) {
+			models = append(models, t.Request.Model)
+		}
+	}
+	return models, nil
+}
+
+// sameRequest compares requests by their persisted form, which unlike the
+// wire form includes client-side options such as ModelFallbacks.
+func sameRequest(a, b *ChatRequest) (bool, error) {
+	ja, err := MarshalPersistedRequest(a)
+	if err != nil {
+		return false, err
+	}
+	jb, err := MarshalPersistedRequest(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ja, jb), nil
+}
+
+// cloneRequest returns a copy of req that shares nothing mutable with it.
+func cloneRequest(req *ChatRequest) *ChatRequest {
+	out := *req
+	out.Messages = slices.Clone(req.Messages)
+	out.Tools = slices.Clone(req.Tools)
+	return &out
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSessionRecordAndReplay(t *testing.T) {
+	inner := &fakeProvider{chat: scripted(
+		outcome{resp: &ChatResponse{Content: "first", Model: "m"}},
+		outcome{err: fmt.Errorf("upstream: %w", ErrRateLimited)},
+		outcome{resp: &ChatResponse{Content: "third", Model: "m"}},
+	)}
+	turns := []*ChatRequest{
+		{Model: "m", Messages: userMessages("one")},
+		{Model: "m", Messages: userMessages("two"), ModelFallbacks: []string{"m-mini"}},
+		{Model: "m", Messages: userMessages("three")},
+	}
+	rec := NewRecordingProvider(inner)
+	for _, req := range turns {
+		rec.Chat(context.Background(), req)
+	}
+	var saved bytes.Buffer
+	if err := rec.Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSessionRecording(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		reqs    []*ChatRequest
+		wantErr error // Returned by the last request
+	}{
+		{"faithful replay", turns, nil},
+		{"recorded error keeps its class", turns[:2], ErrRateLimited},
+		{"different message diverges", []*ChatRequest{{Model: "m", Messages: userMessages("uno")}}, ErrReplayDiverged},
+		{"different client-side option diverges", []*ChatRequest{turns[0], {Model: "m", Messages: userMessages("two")}}, ErrReplayDiverged},
+		{"past the recording", append(turns[:3:3], turns[0]), ErrReplayExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewReplayProvider("replay", loaded)
+			var resp *ChatResponse
+			var err error
+			for _, req := range tt.reqs {
+				resp, err = p.Chat(context.Background(), req)
+			}
+			if tt.wantErr == nil {
+				if err != nil || resp.Content != "third" || p.Remaining() != 0 {
+					t.Fatalf("last turn = %+v, %v; remaining %d", resp, err, p.Remaining())
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	var replayed *ReplayedError
+	p := NewReplayProvider("replay", loaded)
+	p.Chat(context.Background(), turns[0])
+	if _, err := p.Chat(context.Background(), turns[1]); !errors.As(err, &replayed) || replayed.Message != "upstream: rate limited" || errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("err = %#v, want the recorded rate-limit error", err)
+	}
+}
+
+func TestReplayRecordingWithoutRequest(t *testing.T) {
+	rec, err := LoadSessionRecording(strings.NewReader(`{"turns":[{"response":{"content":"hi"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewReplayProvider("replay", rec)
+	if _, err := p.Chat(context.Background(), &ChatRequest{Model: "m"}); !errors.Is(err, ErrReplayDiverged) {
+		t.Errorf("err = %v, want ErrReplayDiverged", err)
+	}
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers set by SigningTransport.
+const (
+	SignatureHeader          = "X-Aura-Signature"
+	SignatureTimestampHeader = "X-Aura-Timestamp"
+)
+
+// ErrInvalidSignature is returned by VerifyRequestSignature for missing,
+// malformed, expired or incorrect signatures.
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// SigningTransport is an http.RoundTripper that signs each request for an LLM
+// gateway. The signature is a hex HMAC-SHA256 over "<unix timestamp>.<body>".
+//
+// Use it as the Transport of the HTTP client passed to a concrete provider.
+type SigningTransport struct {
+	Base   http.RoundTripper // Defaults to http.DefaultTransport
+	Secret []byte
+
+	// Now returns the signing time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// RoundTrip signs a copy of req and sends it with the base transport.
+func (t *SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now
+	if t.Now != nil {
+		now = t.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(body))
+	signed.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	signed.Header.Set(SignatureTimestampHeader, timestamp)
+	signed.Header.Set(SignatureHeader, ComputeSignature(t.Secret, timestamp, body))
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// ComputeSignature returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret.
+func ComputeSignature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequestSignature checks a signed request's signature against body,
+// rejecting timestamps more than maxSkew away from now in either direction.
+func VerifyRequestSignature(header http.Header, body, secret []byte, now time.Time, maxSkew time.Duration) error {
+	timestamp := header.Get(SignatureTimestampHeader)
+	signature := header.Get(SignatureHeader)
+	if timestamp == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if skew := now.Sub(time.Unix(unix, 0)).Abs(); skew > maxSkew {
+		return ErrInvalidSignature
+	}
+
+	expected := ComputeSignature(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestSigningTransport(t *testing.T) {
+	secret := []byte("s3cret")
+	now := time.Unix(1700000000, 0)
+
+	var sent *http.Request
+	var sentBody []byte
+	transport := &SigningTransport{
+		Secret: secret,
+		Now:    func() time.Time { return now },
+		Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req
+			sentBody, _ = io.ReadAll(req.Body)
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://gateway.example/v1/chat", strings.NewReader(`{"model":"m"}`))
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get(SignatureHeader) != "" {
+		t.Error("caller's request was modified")
+	}
+	if string(sentBody) != `{"model":"m"}` {
+		t.Errorf("forwarded body %q", sentBody)
+	}
+	if err := VerifyRequestSignature(sent.Header, sentBody, secret, now, time.Minute); err != nil {
+		t.Errorf("signed request does not verify: %v", err)
+	}
+}
+
+func TestVerifyRequestSignature(t *testing.T) {
+	secret := []byte("s3cret")
+	body := []byte(`{"model":"m"}`)
+	now := time.Unix(1700000000, 0)
+	signed := func(at time.Time, key, content []byte) http.Header {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		h := http.Header{}
+		h.Set(SignatureTimestampHeader, ts)
+		h.Set(SignatureHeader, ComputeSignature(key, ts, content))
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		wantErr bool
+	}{
+		{"valid", signed(now, secret, body), false},
+		{"within skew", signed(now.Add(-30*time.Second), secret, body), false},
+		{"expired", signed(now.Add(-2*time.Minute), secret, body), true},
+		{"from the future", signed(now.Add(2*time.Minute), secret, body), true},
+		{"wrong secret", signed(now, []byte("other"), body), true},
+		{"tampered body", signed(now, secret, []byte(`{"model":"x"}`)), true},
+		{"missing headers", http.Header{}, true},
+		{"malformed timestamp", http.Header{SignatureTimestampHeader: {"soon"}, SignatureHeader: {"ab"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyRequestSignature(tt.header, body, secret, now, time.Minute)
+			if tt.wantErr != (err != nil) || (err != nil && !errors.Is(err, ErrInvalidSignature)) {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
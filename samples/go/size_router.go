@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// MetadataSizeTier records the name of the tier a SizeRouter chose.
+const MetadataSizeTier = "size_tier"
+
+// SizeTier is a destination for prompts up to a size.
+type SizeTier struct {
+	Name            string
+	MaxPromptTokens int // Zero means unbounded; only sensible for the last tier
+	Provider        Provider
+	Model           string // Overrides the request's model if set
+}
+
+// SizeRouter is a Provider that sends each request to the first tier large
+// enough for its estimated prompt size, so short prompts go to cheaper, faster
+// models and long ones to large-context models.
+type SizeRouter struct {
+	id      string
+	tiers   []SizeTier
+	counter TokenCounter
+}
+
+// NewSizeRouter creates a router over tiers, ordered smallest first, that
+// estimates prompt size with counter.
+func NewSizeRouter(id string, tiers []SizeTier, counter TokenCounter) *SizeRouter {
+	return &SizeRouter{id: id, tiers: tiers, counter: counter}
+}
+
+// ID returns the router's identifier.
+func (r *SizeRouter) ID() string {
+	return r.id
+}
+
+// Route returns the tier for a request and its estimated prompt size.
+func (r *SizeRouter) Route(req *ChatRequest) (SizeTier, int, error) {
+	tokens := r.counter.CountMessages(req.Model, req.Messages)
+	for _, t := range r.tiers {
+		if t.MaxPromptTokens == 0 || tokens <= t.MaxPromptTokens {
+			return t, tokens, nil
+		}
+	}
+	return SizeTier{}, tokens, fmt.Errorf("%w: prompt of ~%d tokens exceeds every tier", ErrModelNotAvailable, tokens)
+}
+
+// Chat sends the request to the tier chosen by its prompt size.
+func (r *SizeRouter) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	tier, _, err := r.Route(req)
+	if err != nil {
+		return nil, err
+	}
+
+	routed := req
+	if tier.Model != "" {
+		copied := *req
+		copied.Model = tier.Model
+		routed = &copied
+	}
+
+	resp, err := tier.Provider.Chat(ctx, routed)
+	if err != nil {
+		return nil, err
+	}
+	resp.SetMetadata(MetadataSizeTier, tier.Name)
+	return resp, nil
+}
+
+// IsModelAvailable reports whether any tier's provider offers model.
+func (r *SizeRouter) IsModelAvailable(ctx context.Context, model string) (bool, error) {
+	for _, t := range r.tiers {
+		ok, err := t.Provider.IsModelAvailable(ctx, model)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListModels returns the models of every tier's provider.
+func (r *SizeRouter) ListModels(ctx context.Context) ([]string, error) {
+	var models []string
+	for _, t := range r.tiers {
+		m, err := t.Provider.ListModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range m {
+			if !slices.Contains(models, name) {
+				models = append(models, name)
+			}
+		}
+	}
+	return models, nil
+}
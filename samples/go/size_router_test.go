@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSizeRouter(t *testing.T) {
+	small := &fakeProvider{id: "small", models: []string{"mini"}}
+	large := &fakeProvider{id: "large", models: []string{"big", "mini"}}
+	tiers := []SizeTier{
+		{Name: "small", MaxPromptTokens: 10, Provider: small, Model: "mini"},
+		{Name: "large", MaxPromptTokens: 100, Provider: large},
+	}
+	tests := []struct {
+		name      string
+		tokens    int
+		wantTier  string
+		wantModel string
+		wantErr   bool
+	}{
+		{"short prompt", 5, "small", "mini", false},
+		{"at the tier bound", 10, "small", "mini", false},
+		{"long prompt keeps its model", 11, "large", "req-model", false},
+		{"exceeds every tier", 101, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewSizeRouter("sized", tiers, wordCounter{})
+			req := &ChatRequest{Model: "req-model", Messages: userMessages(strings.Repeat("a", tt.tokens))}
+			resp, err := r.Chat(context.Background(), req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrModelNotAvailable) {
+					t.Fatalf("err = %v, want ErrModelNotAvailable", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Metadata[MetadataSizeTier]; got != tt.wantTier {
+				t.Errorf("tier = %v, want %s", got, tt.wantTier)
+			}
+			p := small
+			if tt.wantTier == "large" {
+				p = large
+			}
+			reqs := p.requests()
+			if got := reqs[len(reqs)-1].Model; got != tt.wantModel {
+				t.Errorf("sent model %q, want %q", got, tt.wantModel)
+			}
+			if req.Model != "req-model" {
+				t.Errorf("caller's request was modified: model %q", req.Model)
+			}
+		})
+	}
+
+	models, err := NewSizeRouter("sized", tiers, wordCounter{}).ListModels(context.Background())
+	if err != nil || strings.Join(models, ",") != "mini,big" {
+		t.Errorf("ListModels = %v, %v; want deduplicated [mini big]", models, err)
+	}
+}
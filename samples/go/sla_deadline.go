@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSLABudgetExhausted is returned when too little of the SLA budget remains to call the provider.
+var ErrSLABudgetExhausted = errors.New("SLA budget exhausted")
+
+type slaDeadlineKey struct{}
+
+// WithSLADeadline returns a context carrying the time by which the overall
+// operation must have responded.
+func WithSLADeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, slaDeadlineKey{}, deadline)
+}
+
+// SLADeadlineFromContext returns the deadline set by WithSLADeadline.
+func SLADeadlineFromContext(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(slaDeadlineKey{}).(time.Time)
+	return deadline, ok
+}
+
+// SLADeadlineProvider wraps a Provider and bounds each call by the remaining
+// SLA budget, less a margin reserved for the caller's own processing.
+// Requests without an SLA deadline are forwarded unchanged.
+type SLADeadlineProvider struct {
+	Provider
+	margin time.Duration
+}
+
+// NewSLADeadlineProvider creates a provider that reserves margin of every SLA budget.
+func NewSLADeadlineProvider(inner Provider, margin time.Duration) *SLADeadlineProvider {
+	return &SLADeadlineProvider{Provider: inner, margin: margin}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *SLADeadlineProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request with a deadline of the SLA deadline minus the margin,
+// failing fast if that is already in the past.
+func (p *SLADeadlineProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	sla, ok := SLADeadlineFromContext(ctx)
+	if !ok {
+		return p.Provider.Chat(ctx, req)
+	}
+
+	deadline := sla.Add(-p.margin)
+	if remaining := time.Until(deadline); remaining <= 0 {
+		return nil, fmt.Errorf("%w: %v past the provider deadline", ErrSLABudgetExhausted, -remaining)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	return p.Provider.Chat(ctx, req)
+}
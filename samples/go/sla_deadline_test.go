@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSLADeadlineProvider(t *testing.T) {
+	margin := 100 * time.Millisecond
+	tests := []struct {
+		name         string
+		sla          time.Duration // SLA deadline relative to now; zero means none
+		wantErr      bool
+		wantDeadline time.Duration // Expected provider deadline relative to now, if any
+	}{
+		{"no SLA", 0, false, 0},
+		{"budget remaining", time.Second, false, 900 * time.Millisecond},
+		{"budget within the margin", 50 * time.Millisecond, true, 0},
+		{"SLA already past", -time.Second, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			var hasDeadline bool
+			inner := &fakeProvider{chat: func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+				deadline, hasDeadline = ctx.Deadline()
+				return &ChatResponse{}, nil
+			}}
+			ctx := context.Background()
+			start := time.Now()
+			if tt.sla != 0 {
+				ctx = WithSLADeadline(ctx, start.Add(tt.sla))
+			}
+			_, err := NewSLADeadlineProvider(inner, margin).Chat(ctx, &ChatRequest{})
+			if tt.wantErr {
+				if !errors.Is(err, ErrSLABudgetExhausted) || len(inner.requests()) != 0 {
+					t.Fatalf("err = %v, want ErrSLABudgetExhausted before dispatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hasDeadline != (tt.wantDeadline != 0) {
+				t.Fatalf("provider deadline set: %v, want %v", hasDeadline, tt.wantDeadline != 0)
+			}
+			if hasDeadline && deadline.Sub(start) != tt.wantDeadline {
+				t.Errorf("provider deadline %v after start, want %v", deadline.Sub(start), tt.wantDeadline)
+			}
+		})
+	}
+}
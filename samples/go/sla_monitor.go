@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SLAThresholds configures an SLAMonitor. Zero thresholds are not checked.
+type SLAThresholds struct {
+	MaxErrorRate float64       // Largest acceptable fraction of failed calls, from 0 to 1
+	MaxP95       time.Duration // Largest acceptable 95th-percentile latency
+
+	Window     int // Calls per provider the rates are computed over (default 100)
+	MinSamples int // Calls needed before a provider is judged (default 10)
+
+	// Debounce is how long a breach, or a recovery, must persist before the
+	// callback fires, so a provider hovering at a threshold does not flap.
+	Debounce time.Duration
+}
+
+// SLAStatus describes a provider's SLA state when an alert or recovery fires.
+type SLAStatus struct {
+	Provider  string
+	ErrorRate float64
+	P95       time.Duration
+	Reason    string // Which threshold was breached; empty on recovery
+}
+
+// SLAMonitor tracks each provider's rolling error rate and latency and calls
+// OnBreach when a threshold is crossed and OnRecover when the provider is back
+// within its thresholds, for paging. Callbacks run synchronously on the
+// goroutine that recorded the triggering call.
+type SLAMonitor struct {
+	thresholds SLAThresholds
+	onBreach   func(SLAStatus)
+	onRecover  func(SLAStatus)
+	now        func() time.Time
+
+	mu    sync.Mutex
+	state map[string]*slaState
+}
+
+type slaState struct {
+	samples  []slaSample
+	breached bool      // The state last reported through a callback
+	changing time.Time // When the measured state began to differ from breached
+}
+
+type slaSample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// NewSLAMonitor creates a monitor that reports breaches of thresholds.
+// Either callback may be nil.
+func NewSLAMonitor(thresholds SLAThresholds, onBreach, onRecover func(SLAStatus)) *SLAMonitor {
+	if thresholds.Window <= 0 {
+		thresholds.Window = 100
+	}
+	if thresholds.MinSamples <= 0 {
+		thresholds.MinSamples = 10
+	}
+	return &SLAMonitor{
+		thresholds: thresholds,
+		onBreach:   onBreach,
+		onRecover:  onRecover,
+		now:        time.Now,
+		state:      make(map[string]*slaState),
+	}
+}
+
+// Record adds the outcome of one call to provider and fires a callback if
+// its SLA state has settled into a change.
+func (m *SLAMonitor) Record(provider string, latency time.Duration, err error) {
+	m.mu.Lock()
+	s, ok := m.state[provider]
+	if !ok {
+		s = &slaState{}
+		m.state[provider] = s
+	}
+	s.samples = append(s.samples, slaSample{latency: latency, failed: err != nil})
+	if len(s.samples) > m.thresholds.Window {
+		s.samples = s.samples[len(s.samples)-m.thresholds.Window:]
+	}
+	status, fire := m.evaluate(provider, s)
+	m.mu.Unlock()
+
+	switch {
+	case fire && status.Reason != "" && m.onBreach != nil:
+		m.onBreach(status)
+	case fire && status.Reason == "" && m.onRecover != nil:
+		m.onRecover(status)
+	}
+}
+
+// Breached reports whether provider is currently reported as breaching its SLA.
+func (m *SLAMonitor) Breached(provider string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.state[provider]
+	return ok && s.breached
+}
+
+// Track returns p wrapped so that every Chat call is recorded.
+func (m *SLAMonitor) Track(p Provider) Provider {
+	return &slaTrackingProvider{Provider: p, monitor: m}
+}
+
+// evaluate measures s and reports whether the debounced state changed.
+// m.mu must be held.
+func (m *SLAMonitor) evaluate(provider string, s *slaState) (SLAStatus, bool) {
+	if len(s.samples) < m.thresholds.MinSamples {
+		return SLAStatus{}, false
+	}
+
+	status := SLAStatus{Provider: provider}
+	latencies := make([]time.Duration, 0, len(s.samples))
+	failures := 0
+	for _, sample := range s.samples {
+		if sample.failed {
+			failures++
+		} else {
+			latencies = append(latencies, sample.latency)
+		}
+	}
+	status.ErrorRate = float64(failures) / float64(len(s.samples))
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		status.P95 = percentile(latencies, 95)
+	}
+
+	switch {
+	case m.thresholds.MaxErrorRate > 0 && status.ErrorRate > m.thresholds.MaxErrorRate:
+		status.Reason = fmt.Sprintf("error rate %.1f%% above %.1f%%", status.ErrorRate*100, m.thresholds.MaxErrorRate*100)
+	case m.thresholds.MaxP95 > 0 && status.P95 > m.thresholds.MaxP95:
+		status.Reason = fmt.Sprintf("p95 latency %s above %s", status.P95, m.thresholds.MaxP95)
+	}
+
+	breached := status.Reason != ""
+	if breached == s.breached {
+		s.changing = time.Time{}
+		return status, false
+	}
+	now := m.now()
+	if s.changing.IsZero() {
+		s.changing = now
+	}
+	if now.Sub(s.changing) < m.thresholds.Debounce {
+		return status, false
+	}
+	s.breached, s.changing = breached, time.Time{}
+	return status, true
+}
+
+// slaTrackingProvider records Chat outcomes with an SLAMonitor.
+type slaTrackingProvider struct {
+	Provider
+	monitor *SLAMonitor
+}
+
+// Unwrap returns the wrapped provider.
+func (p *slaTrackingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+func (p *slaTrackingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+	resp, err := p.Provider.Chat(ctx, req)
+	if ctx.Err() == nil { // The caller giving up says nothing about the provider
+		p.monitor.Record(p.ID(), time.Since(start), err)
+	}
+	return resp, err
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSLAMonitorDebounce(t *testing.T) {
+	type step struct {
+		at      time.Duration // Clock offset the calls are recorded at
+		calls   int
+		latency time.Duration
+		failed  bool
+	}
+	base := SLAThresholds{MaxErrorRate: 0.5, MaxP95: 100 * time.Millisecond, Window: 4, MinSamples: 4, Debounce: 10 * time.Second}
+
+	tests := []struct {
+		name         string
+		debounce     time.Duration
+		steps        []step
+		want         []string
+		wantBreached bool
+	}{
+		{
+			name: "breach then recover after debounce",
+			steps: []step{
+				{at: 0, calls: 4, failed: true},
+				{at: 10 * time.Second, calls: 1, failed: true},
+				{at: 20 * time.Second, calls: 4},
+				{at: 30 * time.Second, calls: 1},
+			},
+			want: []string{"breach", "recover"},
+		},
+		{
+			name: "brief breach is not reported",
+			steps: []step{
+				{at: 0, calls: 4, failed: true},
+				{at: 5 * time.Second, calls: 4},
+				{at: 20 * time.Second, calls: 1},
+			},
+		},
+		{
+			name: "brief recovery keeps the breach",
+			steps: []step{
+				{at: 0, calls: 4, failed: true},
+				{at: 10 * time.Second, calls: 1, failed: true},
+				{at: 15 * time.Second, calls: 4},
+				{at: 20 * time.Second, calls: 4, failed: true},
+				{at: 30 * time.Second, calls: 1},
+			},
+			want:         []string{"breach"},
+			wantBreached: true,
+		},
+		{
+			name:         "slow calls breach latency",
+			steps:        []step{{at: 0, calls: 4, latency: time.Second}, {at: 10 * time.Second, calls: 1, latency: time.Second}},
+			want:         []string{"breach"},
+			wantBreached: true,
+		},
+		{
+			name:         "zero debounce fires at once",
+			debounce:     -1,
+			steps:        []step{{at: 0, calls: 4, failed: true}},
+			want:         []string{"breach"},
+			wantBreached: true,
+		},
+		{
+			name:     "too few samples are not judged",
+			debounce: -1,
+			steps:    []step{{at: 0, calls: 3, failed: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := base
+			if tt.debounce < 0 {
+				thresholds.Debounce = 0
+			}
+			var events []string
+			m := NewSLAMonitor(thresholds,
+				func(s SLAStatus) {
+					if s.Reason == "" {
+						t.Error("breach reported without a reason")
+					}
+					events = append(events, "breach")
+				},
+				func(s SLAStatus) { events = append(events, "recover") })
+
+			start := time.Now()
+			var now time.Time
+			m.now = func() time.Time { return now }
+			for _, s := range tt.steps {
+				now = start.Add(s.at)
+				for range s.calls {
+					var err error
+					if s.failed {
+						err = errors.New("boom")
+					}
+					m.Record("p", s.latency, err)
+				}
+			}
+
+			if !slices.Equal(events, tt.want) {
+				t.Errorf("events = %v, want %v", events, tt.want)
+			}
+			if got := m.Breached("p"); got != tt.wantBreached {
+				t.Errorf("Breached = %v, want %v", got, tt.wantBreached)
+			}
+		})
+	}
+}
+
+func TestSLAMonitorTrack(t *testing.T) {
+	var breaches []SLAStatus
+	m := NewSLAMonitor(SLAThresholds{MaxErrorRate: 0.5, MinSamples: 2}, func(s SLAStatus) { breaches = append(breaches, s) }, nil)
+	failing := &fakeProvider{id: "p", chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		return nil, errors.New("boom")
+	}}
+	tracked := m.Track(failing)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tracked.Chat(canceled, &ChatRequest{})
+	tracked.Chat(canceled, &ChatRequest{})
+	if len(breaches) != 0 {
+		t.Fatalf("canceled calls were recorded: %v", breaches)
+	}
+
+	tracked.Chat(context.Background(), &ChatRequest{})
+	tracked.Chat(context.Background(), &ChatRequest{})
+	if len(breaches) != 1 || breaches[0].Provider != "p" || breaches[0].ErrorRate != 1 {
+		t.Errorf("breaches = %+v, want one for p at a 100%% error rate", breaches)
+	}
+}
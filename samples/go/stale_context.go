@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStaleContext is returned when the data attached to a request is older
+// than the allowed age.
+var ErrStaleContext = errors.New("request context data is stale")
+
+type dataTimestampKey struct{}
+
+// WithDataTimestamp records when the data in a request's prompt was
+// retrieved, for StaleContextProvider to check.
+func WithDataTimestamp(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, dataTimestampKey{}, t)
+}
+
+// DataTimestampFromContext returns the timestamp set by WithDataTimestamp.
+func DataTimestampFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(dataTimestampKey{}).(time.Time)
+	return t, ok
+}
+
+// StaleContextProvider wraps a Provider and rejects requests whose attached
+// data, as timestamped with WithDataTimestamp, is older than maxAge, so
+// time-sensitive answers are never built on outdated context. Requests
+// without a timestamp are forwarded.
+type StaleContextProvider struct {
+	Provider
+	maxAge time.Duration
+}
+
+// NewStaleContextProvider creates a provider that rejects data older than maxAge.
+func NewStaleContextProvider(inner Provider, maxAge time.Duration) *StaleContextProvider {
+	return &StaleContextProvider{Provider: inner, maxAge: maxAge}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *StaleContextProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat rejects the request if its data is stale, otherwise forwards it.
+func (p *StaleContextProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if t, ok := DataTimestampFromContext(ctx); ok {
+		if age := time.Since(t); age > p.maxAge {
+			return nil, fmt.Errorf("%w: data is %s old, limit %s", ErrStaleContext, age.Round(time.Second), p.maxAge)
+		}
+	}
+	return p.Provider.Chat(ctx, req)
+}
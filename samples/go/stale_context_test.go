@@ -0,0 +1,36 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStaleContextProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		age     time.Duration // Zero means no timestamp
+		wantErr bool
+	}{
+		{"no timestamp", 0, false},
+		{"fresh data", time.Minute, false},
+		{"stale data", 2 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.age != 0 {
+				ctx = WithDataTimestamp(ctx, time.Now().Add(-tt.age))
+			}
+			inner := &fakeProvider{}
+			_, err := NewStaleContextProvider(inner, time.Hour).Chat(ctx, &ChatRequest{Model: "m"})
+			if got := errors.Is(err, ErrStaleContext); got != tt.wantErr {
+				t.Fatalf("err = %v, want ErrStaleContext: %v", err, tt.wantErr)
+			}
+			if called := len(inner.requests()) == 1; called == tt.wantErr {
+				t.Errorf("inner called: %v", called)
+			}
+		})
+	}
+}
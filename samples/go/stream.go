@@ -0,0 +1,350 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// StreamEndReason describes why a stream terminated.
+type StreamEndReason string
+
+// Reasons reported on the terminal StreamChunk.
+const (
+	StreamCompleted     StreamEndReason = "completed"         // The provider finished generating
+	StreamTokenCap      StreamEndReason = "token_cap"         // A configured token limit was reached
+	StreamStopPredicate StreamEndReason = "stop_predicate"    // A caller-supplied stop predicate matched
+	StreamTimeout       StreamEndReason = "timeout"           // A deadline expired
+	StreamCanceled      StreamEndReason = "canceled"          // The caller canceled the context
+	StreamError         StreamEndReason = "error"             // The stream failed; see StreamChunk.Err
+	StreamFlagged       StreamEndReason = "content_flagged"   // A content-safety classifier flagged the output
+	StreamLatency       StreamEndReason = "latency_truncated" // A latency budget was exceeded
+	StreamRepetition    StreamEndReason = "repetition_loop"   // The model got stuck repeating itself
+	StreamByteCap       StreamEndReason = "byte_cap"          // A response size limit was reached
+	StreamSlow          StreamEndReason = "slow_generation"   // Throughput fell below a configured floor
+)
+
+// ErrStreamingNotSupported is returned by decorators asked to stream when the
+// provider they wrap cannot.
+var ErrStreamingNotSupported = errors.New("provider does not support streaming")
+
+// StreamChunk is a single increment of a streaming chat completion.
+//
+// Producers send zero or more content chunks followed by exactly one terminal
+// chunk with Done set. The terminal chunk carries the end reason and, when
+// available, usage and any error that ended the stream; ErrorClass says which
+// layer the error came from.
+//
+// Index is the choice a content chunk belongs to when ChatRequest.N asks for
+// several completions; providers interleave the choices' chunks. Decorators
+// that regroup content assume a single choice.
+//
+// Received is when the producer got the chunk from the provider, for
+// inter-token latency analysis. Decorators forward it unchanged; chunks they
+// synthesize by regrouping content leave it zero.
+//
+// Model is the model serving the stream, when the provider reports it. The
+// terminal chunk also carries the provider's FinishReason and any Metadata
+// added by decorators, which ChatResponse-producing consumers copy over.
+type StreamChunk struct {
+	Index    int             `json:"index,omitempty"`
+	Content  string          `json:"content,omitempty"`
+	Done     bool            `json:"done,omitempty"`
+	Reason   StreamEndReason `json:"reason,omitempty"`
+	Usage    *UsageStats     `json:"usage,omitempty"`
+	Received time.Time       `json:"received,omitzero"`
+	Err      error           `json:"-"`
+
+	Model        string         `json:"model,omitempty"`
+	FinishReason string         `json:"finish_reason,omitempty"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+// SetMetadata records an annotation on the chunk, allocating Metadata if needed.
+func (c *StreamChunk) SetMetadata(key string, value any) {
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]any)
+	}
+	c.Metadata[key] = value
+}
+
+// StreamingProvider is implemented by providers that can stream completions.
+type StreamingProvider interface {
+	Provider
+
+	// ChatStream sends a chat completion request and returns a channel of chunks.
+	// The channel is closed after the terminal chunk has been sent. Consumers must
+	// drain the channel or cancel ctx; producers stop sending once ctx is done.
+	ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error)
+}
+
+// StreamLimits configures the policies enforced by LimitedStreamProvider.
+// Zero values disable the corresponding policy.
+type StreamLimits struct {
+	// MaxTokens stops the stream after this many content chunks. Providers emit
+	// roughly one token per chunk, so the chunk count is used as the token count.
+	MaxTokens int
+
+	// Stop is called with the accumulated content after every chunk; returning
+	// true ends the stream.
+	Stop func(content string) bool
+
+	// Timeout bounds the total duration of the stream.
+	Timeout time.Duration
+}
+
+// LimitedStreamProvider wraps a StreamingProvider and ends streams early when a
+// StreamLimits policy triggers, reporting the cause on the terminal chunk.
+type LimitedStreamProvider struct {
+	StreamingProvider
+	limits StreamLimits
+}
+
+// NewLimitedStreamProvider creates a provider that enforces limits on every stream.
+func NewLimitedStreamProvider(inner StreamingProvider, limits StreamLimits) *LimitedStreamProvider {
+	return &LimitedStreamProvider{StreamingProvider: inner, limits: limits}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *LimitedStreamProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream starts a stream on the wrapped provider and enforces the configured limits.
+func (p *LimitedStreamProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := withOptionalTimeout(ctx, p.limits.Timeout)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		var content strings.Builder
+		tokens := 0
+
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				return chunk
+			}
+
+			emit(chunk)
+			content.WriteString(chunk.Content)
+			tokens++
+
+			if p.limits.MaxTokens > 0 && tokens >= p.limits.MaxTokens {
+				return StreamChunk{Done: true, Reason: StreamTokenCap}
+			}
+			if p.limits.Stop != nil && p.limits.Stop(content.String()) {
+				return StreamChunk{Done: true, Reason: StreamStopPredicate}
+			}
+		}
+	}), nil
+}
+
+// streamingInner returns p as a StreamingProvider, or ErrStreamingNotSupported.
+func streamingInner(p Provider) (StreamingProvider, error) {
+	sp, ok := p.(StreamingProvider)
+	if !ok {
+		return nil, ErrStreamingNotSupported
+	}
+	return sp, nil
+}
+
+// collectStream reads in until it closes, returning the concatenated content
+// and the terminal chunk.
+func collectStream(in <-chan StreamChunk) (string, StreamChunk) {
+	var content strings.Builder
+	final := StreamChunk{Done: true, Reason: StreamCompleted}
+	for chunk := range in {
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		content.WriteString(chunk.Content)
+	}
+	return content.String(), final
+}
+
+// relayStream runs a stream policy in its own goroutine. run reads from in,
+// forwards chunks with emit and returns the terminal chunk; relayStream sends
+// it, closes the output and cancels the upstream stream. Sends give up once
+// ctx, the stream's context, is done, so a consumer that stops reading and
+// cancels does not leak the goroutine.
+func relayStream(ctx context.Context, cancel context.CancelFunc, in <-chan StreamChunk, run func(emit func(StreamChunk)) StreamChunk) <-chan StreamChunk {
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+		defer drainStream(in)
+
+		emit := func(chunk StreamChunk) { sendChunk(ctx, out, chunk, 0) }
+		sendChunk(ctx, out, run(emit), terminalGrace)
+	}()
+	return out
+}
+
+// terminalGrace is how long a producer whose context has ended keeps offering
+// the terminal chunk, so that a consumer draining the stream after canceling
+// still learns why it ended, before concluding the consumer is gone.
+const terminalGrace = time.Second
+
+// sendChunk sends chunk, a StreamChunk or StreamEvent, on out, giving up if
+// ctx is done first. Once ctx is done the chunk is still delivered to a
+// consumer that takes it within grace.
+func sendChunk[T any](ctx context.Context, out chan<- T, chunk T, grace time.Duration) bool {
+	select {
+	case out <- chunk:
+		return true
+	case <-ctx.Done():
+	}
+	if grace <= 0 {
+		select {
+		case out <- chunk:
+			return true
+		default:
+			return false
+		}
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case out <- chunk:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// receive returns the next chunk from in. If in closes without a terminal
+// chunk, or ctx is done first, it synthesizes the terminal chunk. A done ctx
+// takes precedence over chunks already waiting in in.
+func receive(ctx context.Context, in <-chan StreamChunk) StreamChunk {
+	if ctx.Err() != nil {
+		return StreamChunk{Done: true, Reason: ctxEndReason(ctx), Err: ctx.Err()}
+	}
+	select {
+	case chunk, ok := <-in:
+		return normalizeChunk(ctx, chunk, ok)
+	case <-ctx.Done():
+		return StreamChunk{Done: true, Reason: ctxEndReason(ctx), Err: ctx.Err()}
+	}
+}
+
+// normalizeChunk turns a receive from a closed channel (ok false) into a
+// terminal chunk and fills in a missing terminal reason. A channel closed
+// after ctx ended is reported as canceled or timed out, not completed.
+func normalizeChunk(ctx context.Context, chunk StreamChunk, ok bool) StreamChunk {
+	if !ok {
+		if ctx.Err() != nil {
+			return StreamChunk{Done: true, Reason: ctxEndReason(ctx), Err: ctx.Err()}
+		}
+		return StreamChunk{Done: true, Reason: StreamCompleted}
+	}
+	if chunk.Done && chunk.Reason == "" {
+		chunk.Reason = StreamCompleted
+		if chunk.Err != nil {
+			chunk.Reason = StreamError
+		}
+	}
+	return chunk
+}
+
+// withOptionalTimeout derives a cancelable context, bounded by timeout when it is positive.
+func withOptionalTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// ctxEndReason classifies why a stream's context finished.
+func ctxEndReason(ctx context.Context) StreamEndReason {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return StreamTimeout
+	}
+	return StreamCanceled
+}
+
+// drainStream discards any remaining chunks so an abandoned producer is not
+// left blocked on a send.
+func drainStream(in <-chan StreamChunk) {
+	go func() {
+		for range in {
+		}
+	}()
+}
+
+// runeCarry holds back an incomplete UTF-8 sequence at the end of streamed
+// content until the next chunk completes it, for transformers that cut or
+// regroup content by bytes. Providers may split a multi-byte rune across
+// chunks; emitting such a chunk on its own produces invalid text.
+type runeCarry struct {
+	partial string
+}
+
+// complete prepends any held bytes to s and returns the longest prefix that
+// ends on a rune boundary, holding back the rest.
+func (c *runeCarry) complete(s string) string {
+	s = c.partial + s
+	cut := len(s)
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	s, c.partial = s[:cut], s[cut:]
+	return s
+}
+
+// flush returns the held bytes at the end of the stream, when they can no
+// longer be completed.
+func (c *runeCarry) flush() string {
+	s := c.partial
+	c.partial = ""
+	return s
+}
+
+// DemuxChoices reads a stream of interleaved choices, as produced for
+// ChatRequest.N greater than one, until it closes and reassembles each
+// choice's content into its own response, ordered by choice index. The
+// terminal chunk is returned alongside; its usage covers all choices and is
+// not split between the responses. Chunks with a negative index belong to no
+// choice and are ignored.
+func DemuxChoices(in <-chan StreamChunk) ([]*ChatResponse, StreamChunk) {
+	var builders []*strings.Builder
+	final := StreamChunk{Done: true, Reason: StreamCompleted}
+
+	for chunk := range in {
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		if chunk.Index < 0 {
+			continue
+		}
+		for len(builders) <= chunk.Index {
+			builders = append(builders, &strings.Builder{})
+		}
+		builders[chunk.Index].WriteString(chunk.Content)
+	}
+
+	finishReason := "stop"
+	if final.FinishReason != "" {
+		finishReason = final.FinishReason
+	}
+	if final.Reason != StreamCompleted {
+		finishReason = string(final.Reason)
+	}
+	responses := make([]*ChatResponse, len(builders))
+	for i, b := range builders {
+		responses[i] = &ChatResponse{Content: b.String(), Model: final.Model, FinishReason: finishReason}
+	}
+	return responses, final
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// StreamConcurrencyProvider wraps a StreamingProvider and caps how many of
+// its streams can be open at once, separately from blocking Chat calls.
+// ChatStream waits for a free slot, or for ctx to end; a slot is released
+// when the stream's channel closes.
+type StreamConcurrencyProvider struct {
+	StreamingProvider
+	slots chan struct{} // Nil when unlimited
+	open  atomic.Int64
+}
+
+// NewStreamConcurrencyProvider creates a provider allowing at most limit open
+// streams. A limit of zero or less means no limit.
+func NewStreamConcurrencyProvider(inner StreamingProvider, limit int) *StreamConcurrencyProvider {
+	p := &StreamConcurrencyProvider{StreamingProvider: inner}
+	if limit > 0 {
+		p.slots = make(chan struct{}, limit)
+	}
+	return p
+}
+
+// Unwrap returns the wrapped provider.
+func (p *StreamConcurrencyProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream waits for a slot and starts the stream.
+func (p *StreamConcurrencyProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ErrContextCanceled
+		}
+	}
+	p.open.Add(1)
+	release := func() {
+		p.open.Add(-1)
+		if p.slots != nil {
+			<-p.slots
+		}
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		release()
+		return nil, err
+	}
+
+	done := func() {
+		cancel()
+		release()
+	}
+	return relayStream(streamCtx, done, in, func(emit func(StreamChunk)) StreamChunk {
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				return chunk
+			}
+			emit(chunk)
+		}
+	}), nil
+}
+
+// Open returns the number of streams currently open.
+func (p *StreamConcurrencyProvider) Open() int {
+	return int(p.open.Load())
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStreamConcurrencyProvider(t *testing.T) {
+	errStart := errors.New("start failed")
+	failing := &fakeStreamer{
+		fakeProvider: &fakeProvider{},
+		stream: func(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+			return nil, errStart
+		},
+	}
+
+	tests := []struct {
+		name     string
+		inner    StreamingProvider
+		wantErr  error
+		wantOpen int // Streams open after the first ChatStream returns
+	}{
+		{name: "open stream holds its slot", inner: endlessStreamer(), wantOpen: 1},
+		{name: "failed start releases its slot", inner: failing, wantErr: errStart},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewStreamConcurrencyProvider(tt.inner, 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			chunks, err := p.ChatStream(ctx, &ChatRequest{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if p.Open() != tt.wantOpen {
+				t.Errorf("Open = %d, want %d", p.Open(), tt.wantOpen)
+			}
+
+			// A second stream waits for the slot while the first is open.
+			waitCtx, stop := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer stop()
+			_, err = p.ChatStream(waitCtx, &ChatRequest{})
+			if tt.wantOpen > 0 && !errors.Is(err, ErrContextCanceled) {
+				t.Errorf("second stream err = %v, want %v while the slot is taken", err, ErrContextCanceled)
+			}
+			if tt.wantOpen == 0 && !errors.Is(err, errStart) {
+				t.Errorf("second stream err = %v, want the slot to be free", err)
+			}
+
+			if chunks != nil {
+				cancel()
+				for range chunks {
+				}
+			}
+			cancel()
+			if p.Open() != 0 {
+				t.Errorf("Open = %d after the stream closed, want 0", p.Open())
+			}
+		})
+	}
+}
+
+func TestStreamConcurrencyProviderUnlimited(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		p := NewStreamConcurrencyProvider(endlessStreamer(), limit)
+		ctx, cancel := context.WithCancel(context.Background())
+		var streams []<-chan StreamChunk
+		for range 3 {
+			chunks, err := p.ChatStream(ctx, &ChatRequest{})
+			if err != nil {
+				t.Fatalf("limit %d: %v", limit, err)
+			}
+			streams = append(streams, chunks)
+		}
+		if p.Open() != 3 {
+			t.Errorf("limit %d: Open = %d, want 3", limit, p.Open())
+		}
+		cancel()
+		for _, chunks := range streams {
+			for range chunks {
+			}
+		}
+		if p.Open() != 0 {
+			t.Errorf("limit %d: Open = %d after the streams closed, want 0", limit, p.Open())
+		}
+	}
+}
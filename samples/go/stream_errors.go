@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// StreamErrorClass says which layer ended a stream abnormally, so consumers
+// can decide whether a retry could help.
+type StreamErrorClass string
+
+// Classes reported by StreamChunk.ErrorClass.
+const (
+	StreamErrorNone      StreamErrorClass = ""          // The stream ended normally
+	StreamErrorTransport StreamErrorClass = "transport" // The connection failed; usually worth retrying
+	StreamErrorProvider  StreamErrorClass = "provider"  // The provider reported an error or sent a malformed payload
+	StreamErrorPolicy    StreamErrorClass = "policy"    // A local guard stopped the output; retrying the same request rarely helps
+	StreamErrorCanceled  StreamErrorClass = "canceled"  // The caller's context ended the stream
+)
+
+// policyErrors are the errors returned by the package's output guards.
+var policyErrors = []error{
+	ErrContentFlagged,
+	ErrRepetitionLoop,
+	ErrResponseTooLarge,
+	ErrLowConfidence,
+	ErrLanguageMismatch,
+	ErrPromptInjection,
+	ErrInvalidStructuredOutput,
+}
+
+// ErrorClass classifies the error on a terminal chunk. Chunks that ended
+// normally, and non-terminal chunks, report StreamErrorNone.
+func (c StreamChunk) ErrorClass() StreamErrorClass {
+	if !c.Done {
+		return StreamErrorNone
+	}
+	switch c.Reason {
+	case StreamFlagged, StreamLatency, StreamRepetition, StreamByteCap:
+		return StreamErrorPolicy
+	case StreamSlow:
+		return StreamErrorProvider
+	}
+	if c.Err == nil {
+		return StreamErrorNone
+	}
+	return classifyStreamError(c.Err)
+}
+
+func classifyStreamError(err error) StreamErrorClass {
+	for _, target := range policyErrors {
+		if errors.Is(err, target) {
+			return StreamErrorPolicy
+		}
+	}
+
+	var perr *ProviderError
+	switch {
+	case errors.As(err, &perr), errors.Is(err, ErrInvalidResponse):
+		return StreamErrorProvider
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return StreamErrorCanceled
+	}
+	return StreamErrorTransport
+}
@@ -0,0 +1,37 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestStreamChunkErrorClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		chunk StreamChunk
+		want  StreamErrorClass
+	}{
+		{"content chunk", StreamChunk{Content: "hi", Err: io.ErrUnexpectedEOF}, StreamErrorNone},
+		{"completed", StreamChunk{Done: true, Reason: StreamCompleted}, StreamErrorNone},
+		{"flagged reason", StreamChunk{Done: true, Reason: StreamFlagged}, StreamErrorPolicy},
+		{"byte cap reason", StreamChunk{Done: true, Reason: StreamByteCap}, StreamErrorPolicy},
+		{"slow generation", StreamChunk{Done: true, Reason: StreamSlow}, StreamErrorProvider},
+		{"wrapped guard error", StreamChunk{Done: true, Reason: StreamError, Err: fmt.Errorf("guard: %w", ErrPromptInjection)}, StreamErrorPolicy},
+		{"provider error", StreamChunk{Done: true, Reason: StreamError, Err: &ProviderError{StatusCode: 500}}, StreamErrorProvider},
+		{"malformed payload", StreamChunk{Done: true, Reason: StreamError, Err: fmt.Errorf("%w: bad json", ErrInvalidResponse)}, StreamErrorProvider},
+		{"canceled", StreamChunk{Done: true, Reason: StreamCanceled, Err: context.Canceled}, StreamErrorCanceled},
+		{"deadline", StreamChunk{Done: true, Reason: StreamTimeout, Err: context.DeadlineExceeded}, StreamErrorCanceled},
+		{"connection dropped", StreamChunk{Done: true, Reason: StreamError, Err: io.ErrUnexpectedEOF}, StreamErrorTransport},
+		{"unknown error", StreamChunk{Done: true, Reason: StreamError, Err: errors.New("boom")}, StreamErrorTransport},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.chunk.ErrorClass(); got != tt.want {
+				t.Errorf("ErrorClass = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// StreamEventType identifies a raw provider stream event.
+type StreamEventType string
+
+// Raw stream event types delivered by ChatStreamEvents. Every event stream ends
+// with exactly one EventDone or EventError.
+const (
+	EventContentDelta    StreamEventType = "content.delta"
+	EventToolCallDelta   StreamEventType = "tool_call.delta"
+	EventToolCallPartial StreamEventType = "tool_call.partial" // See PartialToolArguments
+	EventError           StreamEventType = "error"
+	EventDone            StreamEventType = "done"
+)
+
+// ToolCallDelta is an incremental piece of a streamed tool call. ID and Name
+// arrive on the first delta for a call; Arguments arrive in fragments that
+// concatenate to the call's JSON arguments.
+type ToolCallDelta struct {
+	Index     int    `json:"index"` // Position of the call within the message
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// StreamEvent is a typed event from a provider stream, for consumers that need
+// more than the content carried by StreamChunk.
+type StreamEvent struct {
+	Type         StreamEventType `json:"type"`
+	Index        int             `json:"index"` // Choice index the event belongs to
+	Content      string          `json:"content,omitempty"`
+	ToolCall     *ToolCallDelta  `json:"tool_call,omitempty"`
+	Model        string          `json:"model,omitempty"` // The model serving the stream, if the provider reports it
+	FinishReason string          `json:"finish_reason,omitempty"`
+	Usage        *UsageStats     `json:"usage,omitempty"`
+	Err          error           `json:"-"`
+	Received     time.Time       `json:"received,omitzero"` // When the event arrived from the provider
+
+	// PartialArguments is the best-effort parse of a tool call's arguments so
+	// far, on EventToolCallPartial events.
+	PartialArguments json.RawMessage `json:"partial_arguments,omitempty"`
+
+	// Raw is the provider payload the event was decoded from, if any.
+	Raw json.RawMessage `json:"raw,omitempty"`
+}
+
+// chunksFromEvents adapts a raw event stream to the simpler StreamChunk form,
+// dropping events that StreamChunk cannot represent.
+func chunksFromEvents(ctx context.Context, events <-chan StreamEvent) <-chan StreamChunk {
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+
+		for ev := range events {
+			switch ev.Type {
+			case EventContentDelta:
+				sendChunk(ctx, out, StreamChunk{Index: ev.Index, Content: ev.Content, Model: ev.Model, Received: ev.Received}, 0)
+			case EventDone:
+				sendChunk(ctx, out, StreamChunk{Done: true, Reason: StreamCompleted, Usage: ev.Usage, Model: ev.Model, FinishReason: ev.FinishReason, Received: ev.Received}, terminalGrace)
+			case EventError:
+				reason := StreamError
+				if ctx.Err() != nil {
+					reason = ctxEndReason(ctx)
+				}
+				sendChunk(ctx, out, StreamChunk{Done: true, Reason: reason, Usage: ev.Usage, Err: ev.Err, Model: ev.Model, Received: ev.Received}, terminalGrace)
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOpenAIChatStreamEvents(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeSSE(w,
+			`{"choices":[{"index":0,"delta":{"content":"Checking"}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"weather","arguments":"{\"ci"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ty\":\"Oslo\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		)
+	})
+	events, err := srv.provider(OpenAIConfig{}).ChatStreamEvents(context.Background(), &ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []StreamEventType
+	var args string
+	var last StreamEvent
+	for ev := range events {
+		types = append(types, ev.Type)
+		if ev.ToolCall != nil {
+			args += ev.ToolCall.Arguments
+		}
+		if ev.Type != EventDone && len(ev.Raw) == 0 {
+			t.Errorf("%s event has no raw payload", ev.Type)
+		}
+		last = ev
+	}
+	want := []StreamEventType{EventContentDelta, EventToolCallDelta, EventToolCallDelta, EventDone}
+	if len(types) != len(want) {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("event types = %v, want %v", types, want)
+		}
+	}
+	if args != `{"city":"Oslo"}` || last.FinishReason != "tool_calls" {
+		t.Errorf("arguments %q, finish reason %q", args, last.FinishReason)
+	}
+}
+
+func TestChunksFromEvents(t *testing.T) {
+	failure := errors.New("stream broke")
+	tests := []struct {
+		name        string
+		events      []StreamEvent
+		wantContent string
+		wantReason  StreamEndReason
+		wantErr     error
+	}{
+		{
+			"content and done",
+			[]StreamEvent{{Type: EventContentDelta, Content: "a"}, {Type: EventToolCallDelta, ToolCall: &ToolCallDelta{}}, {Type: EventContentDelta, Content: "b"}, {Type: EventDone, FinishReason: "stop"}},
+			"ab", StreamCompleted, nil,
+		},
+		{
+			"error",
+			[]StreamEvent{{Type: EventContentDelta, Content: "a"}, {Type: EventError, Err: failure}},
+			"a", StreamError, failure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan StreamEvent, len(tt.events))
+			for _, ev := range tt.events {
+				in <- ev
+			}
+			close(in)
+			content, final := collectStream(chunksFromEvents(context.Background(), in))
+			if content != tt.wantContent || final.Reason != tt.wantReason || !errors.Is(final.Err, tt.wantErr) {
+				t.Errorf("content %q, final %+v", content, final)
+			}
+		})
+	}
+}
+
+func TestOpenAIStreamEventsStopForAbandonedConsumer(t *testing.T) {
+	release := make(chan struct{})
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 100; i++ {
+			w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"x"}}]}` + "\n\n"))
+		}
+		w.(http.Flusher).Flush()
+		<-release
+	})
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := srv.provider(OpenAIConfig{}).ChatStreamEvents(ctx, &ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-events
+	cancel()
+
+	// Nobody reads after canceling, yet the producer must still finish.
+	deadline := time.After(terminalGrace + 2*time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("event stream did not close after cancel")
+		}
+		time.Sleep(terminalGrace / 4)
+	}
+}
@@ -0,0 +1,47 @@
+package llm
+
+import "context"
+
+// StreamHandle is a running stream that can be canceled directly, without
+// the caller managing a context of its own.
+type StreamHandle struct {
+	chunks <-chan StreamChunk
+	cancel context.CancelFunc
+}
+
+// StartStream starts a stream on p and returns a handle to it. The stream
+// also ends if ctx is done.
+func StartStream(ctx context.Context, p StreamingProvider, req *ChatRequest) (*StreamHandle, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The relay ends the stream as soon as Cancel is called, even if the
+	// provider is slow to notice, and drains the provider in the background.
+	chunks := relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				return chunk
+			}
+			emit(chunk)
+		}
+	})
+	return &StreamHandle{chunks: chunks, cancel: cancel}, nil
+}
+
+// Chunks returns the stream's chunks. After Cancel, the channel delivers a
+// terminal chunk with reason StreamCanceled, unless the stream had already
+// ended, and closes. As with any stream, consumers must drain it.
+func (h *StreamHandle) Chunks() <-chan StreamChunk {
+	return h.chunks
+}
+
+// Cancel stops the stream. It is safe to call more than once and after the
+// stream has ended.
+func (h *StreamHandle) Cancel() {
+	h.cancel()
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStreamHandle(t *testing.T) {
+	// stuck never sends or notices cancellation until the test ends.
+	release := make(chan struct{})
+	defer close(release)
+	stuck := &fakeStreamer{
+		fakeProvider: &fakeProvider{},
+		stream: func(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+			out := make(chan StreamChunk)
+			go func() {
+				<-release
+				close(out)
+			}()
+			return out, nil
+		},
+	}
+
+	tests := []struct {
+		name        string
+		provider    StreamingProvider
+		cancel      bool // Call Cancel after the first chunk
+		cancelCtx   bool // Cancel the caller's context after the first chunk
+		wantContent string
+		wantReason  StreamEndReason
+	}{
+		{name: "completes", provider: streamingReply("a", "b"), wantContent: "ab", wantReason: StreamCompleted},
+		{name: "cancel", provider: endlessStreamer(), cancel: true, wantContent: "x", wantReason: StreamCanceled},
+		{name: "caller context", provider: endlessStreamer(), cancelCtx: true, wantContent: "x", wantReason: StreamCanceled},
+		{name: "cancel a stuck provider", provider: stuck, cancel: true, wantReason: StreamCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			h, err := StartStream(ctx, tt.provider, &ChatRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.provider == stuck {
+				h.Cancel()
+			}
+
+			var content string
+			var final StreamChunk
+			timeout := time.After(time.Second)
+		read:
+			for {
+				select {
+				case chunk, ok := <-h.Chunks():
+					if !ok {
+						break read
+					}
+					if chunk.Done {
+						final = chunk
+						continue
+					}
+					if content == "" {
+						switch {
+						case tt.cancel:
+							h.Cancel()
+						case tt.cancelCtx:
+							cancel()
+						}
+					}
+					if tt.cancel || tt.cancelCtx {
+						content = "x" // Chunks already in flight may still arrive
+					} else {
+						content += chunk.Content
+					}
+				case <-timeout:
+					t.Fatal("stream did not end")
+				}
+			}
+
+			if content != tt.wantContent || final.Reason != tt.wantReason {
+				t.Errorf("got %q ending %q, want %q ending %q", content, final.Reason, tt.wantContent, tt.wantReason)
+			}
+			h.Cancel() // Safe after the stream has ended
+		})
+	}
+}
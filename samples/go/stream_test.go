@@ -0,0 +1,204 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// endlessStreamer streams "x" chunks until its context ends, then closes the
+// channel without a terminal chunk, like a connection torn down mid-stream.
+func endlessStreamer() *fakeStreamer {
+	return &fakeStreamer{
+		fakeProvider: &fakeProvider{},
+		stream: func(ctx context.Context, _ *ChatRequest) (<-chan StreamChunk, error) {
+			out := make(chan StreamChunk)
+			go func() {
+				defer close(out)
+				for {
+					select {
+					case out <- StreamChunk{Content: "x"}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return out, nil
+		},
+	}
+}
+
+func TestLimitedStreamTerminationReasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		inner   func() StreamingProvider
+		limits  StreamLimits
+		cancel  bool // Cancel the caller's context after the first chunk
+		want    StreamEndReason
+		wantErr bool
+	}{
+		{"completed", func() StreamingProvider { return streamingReply("a", "b") }, StreamLimits{}, false, StreamCompleted, false},
+		{"token cap", func() StreamingProvider { return endlessStreamer() }, StreamLimits{MaxTokens: 3}, false, StreamTokenCap, false},
+		{"stop predicate", func() StreamingProvider { return endlessStreamer() },
+			StreamLimits{Stop: func(s string) bool { return strings.Count(s, "x") == 2 }}, false, StreamStopPredicate, false},
+		{"timeout", func() StreamingProvider { return endlessStreamer() }, StreamLimits{Timeout: 20 * time.Millisecond}, false, StreamTimeout, true},
+		{"canceled", func() StreamingProvider { return endlessStreamer() }, StreamLimits{}, true, StreamCanceled, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			chunks, err := NewLimitedStreamProvider(tt.inner(), tt.limits).ChatStream(ctx, &ChatRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var final StreamChunk
+			terminals := 0
+			for chunk := range chunks {
+				if tt.cancel {
+					cancel()
+				}
+				if chunk.Done {
+					final = chunk
+					terminals++
+				}
+			}
+			if terminals != 1 {
+				t.Fatalf("got %d terminal chunks, want 1", terminals)
+			}
+			if final.Reason != tt.want {
+				t.Errorf("reason = %q, want %q", final.Reason, tt.want)
+			}
+			if (final.Err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", final.Err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRelayStreamStopsForAbandonedConsumer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := NewLimitedStreamProvider(endlessStreamer(), StreamLimits{}).ChatStream(ctx, &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-chunks
+	cancel() // Stop reading without draining
+
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case _, ok := <-chunks:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("relay goroutine did not exit after cancellation")
+		}
+		// Read slowly, so the relay must give up on sends rather than be drained.
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNormalizeClosedChannel(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want StreamEndReason
+	}{
+		{"live context", context.Background(), StreamCompleted},
+		{"canceled context", canceled, StreamCanceled},
+		{"expired context", expired, StreamTimeout},
+	}
+	for _, tt := range tests {
+		if got := normalizeChunk(tt.ctx, StreamChunk{}, false); !got.Done || got.Reason != tt.want {
+			t.Errorf("%s: got %+v, want terminal %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRuneCarry(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunks    []string
+		want      []string // Returned by complete for each chunk
+		wantFlush string
+	}{
+		{"ascii", []string{"ab", "c"}, []string{"ab", "c"}, ""},
+		{"two-byte rune split", []string{"caf\xc3", "\xa9!"}, []string{"caf", "é!"}, ""},
+		{"three-byte rune over three chunks", []string{"\xe6", "\x97", "\xa5x"}, []string{"", "", "日x"}, ""},
+		{"four-byte rune split", []string{"hi \xf0\x9f", "\x98\x80"}, []string{"hi ", "😀"}, ""},
+		{"stray continuation bytes pass through", []string{"a\x80\x80"}, []string{"a\x80\x80"}, ""},
+		{"truncated rune flushed at the end", []string{"ok\xe2\x82"}, []string{"ok"}, "\xe2\x82"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var carry runeCarry
+			for i, chunk := range tt.chunks {
+				if got := carry.complete(chunk); got != tt.want[i] {
+					t.Errorf("complete(%q) = %q, want %q", chunk, got, tt.want[i])
+				}
+			}
+			if got := carry.flush(); got != tt.wantFlush {
+				t.Errorf("flush = %q, want %q", got, tt.wantFlush)
+			}
+		})
+	}
+}
+
+func TestDemuxChoices(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunks     []StreamChunk
+		want       []string
+		wantFinish string
+	}{
+		{"single choice", []StreamChunk{{Content: "a"}, {Content: "b"}}, []string{"ab"}, "stop"},
+		{
+			"interleaved choices",
+			[]StreamChunk{{Index: 0, Content: "He"}, {Index: 1, Content: "Hi"}, {Index: 0, Content: "llo"}, {Index: 1, Content: "!"},
+				{Done: true, Reason: StreamCompleted, FinishReason: "length", Model: "m-2024"}},
+			[]string{"Hello", "Hi!"},
+			"length",
+		},
+		{"choice without content", []StreamChunk{{Index: 1, Content: "x"}}, []string{"", "x"}, "stop"},
+		{"negative index ignored", []StreamChunk{{Content: "a"}, {Index: -1, Content: "?"}}, []string{"a"}, "stop"},
+		{"stream cut short", []StreamChunk{{Content: "a"}, {Done: true, Reason: StreamTokenCap}}, []string{"a"}, string(StreamTokenCap)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses, final := DemuxChoices(streamOf(tt.chunks...))
+			if len(responses) != len(tt.want) {
+				t.Fatalf("got %d responses, want %d", len(responses), len(tt.want))
+			}
+			for i, resp := range responses {
+				if resp.Content != tt.want[i] || resp.FinishReason != tt.wantFinish || resp.Model != final.Model {
+					t.Errorf("response %d = %+v, want %q finishing %q", i, resp, tt.want[i], tt.wantFinish)
+				}
+			}
+		})
+	}
+}
+
+func TestLimitedStreamForwardsReceived(t *testing.T) {
+	received := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	inner := &fakeStreamer{fakeProvider: &fakeProvider{}, stream: func(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+		return streamOf(StreamChunk{Content: "a", Received: received}), nil
+	}}
+	chunks, err := NewLimitedStreamProvider(inner, StreamLimits{}).ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := <-chunks
+	collectStream(chunks)
+	if !first.Received.Equal(received) {
+		t.Errorf("Received = %v, want %v", first.Received, received)
+	}
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrSystemPromptTooLong is returned when a request's system prompt exceeds
+// its token budget.
+var ErrSystemPromptTooLong = errors.New("system prompt exceeds token budget")
+
+// MetadataSystemPromptTruncated is set to the original system-prompt token
+// count on responses whose system prompt was truncated to fit the budget.
+const MetadataSystemPromptTruncated = "system_prompt_truncated"
+
+// SystemPromptBudgetProvider wraps a Provider and caps the tokens spent on
+// system prompts, which are paid for on every call. Oversized system prompts
+// are rejected with ErrSystemPromptTooLong, or in lenient mode truncated: the
+// system messages are kept in order until the budget runs out, the one that
+// crosses it is cut and any later ones are dropped.
+type SystemPromptBudgetProvider struct {
+	Provider
+	budget  int
+	counter TokenCounter
+	lenient bool
+}
+
+// NewSystemPromptBudgetProvider creates a provider that limits system prompts
+// to budget tokens as measured by counter (default HeuristicTokenCounter).
+func NewSystemPromptBudgetProvider(inner Provider, budget int, counter TokenCounter, lenient bool) *SystemPromptBudgetProvider {
+	if counter == nil {
+		counter = HeuristicTokenCounter{}
+	}
+	return &SystemPromptBudgetProvider{Provider: inner, budget: budget, counter: counter, lenient: lenient}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *SystemPromptBudgetProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat enforces the budget on the request's system prompt and forwards it.
+func (p *SystemPromptBudgetProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	tokens := 0
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			tokens += p.counter.CountTokens(req.Model, m.Content)
+		}
+	}
+	if tokens <= p.budget {
+		return p.Provider.Chat(ctx, req)
+	}
+	if !p.lenient {
+		return nil, fmt.Errorf("%w: %d tokens, budget %d", ErrSystemPromptTooLong, tokens, p.budget)
+	}
+
+	resp, err := p.Provider.Chat(ctx, p.truncate(req))
+	if err != nil {
+		return nil, err
+	}
+	resp.SetMetadata(MetadataSystemPromptTruncated, tokens)
+	return resp, nil
+}
+
+// truncate returns req with its system messages cut to the budget.
+func (p *SystemPromptBudgetProvider) truncate(req *ChatRequest) *ChatRequest {
+	out := *req
+	out.Messages = make([]Message, 0, len(req.Messages))
+	left := p.budget
+	for _, m := range req.Messages {
+		if m.Role != "system" {
+			out.Messages = append(out.Messages, m)
+			continue
+		}
+		if left <= 0 {
+			continue
+		}
+		if n := p.counter.CountTokens(req.Model, m.Content); n > left {
+			m.Content = truncateTokens(p.counter, req.Model, m.Content, left)
+		}
+		left -= p.counter.CountTokens(req.Model, m.Content)
+		out.Messages = append(out.Messages, m)
+	}
+	return &out
+}
+
+// truncateTokens returns the longest prefix of s, cut on a rune boundary,
+// that counter measures at no more than budget tokens.
+func truncateTokens(counter TokenCounter, model, s string, budget int) string {
+	n := sort.Search(len(s)+1, func(n int) bool {
+		return counter.CountTokens(model, truncateUTF8(s, n)) > budget
+	})
+	return truncateUTF8(s, n-1)
+}
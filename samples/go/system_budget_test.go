@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSystemPromptBudgetProvider(t *testing.T) {
+	sys := func(s string) Message { return Message{Role: "system", Content: s} }
+	user := Message{Role: "user", Content: "a long user question"}
+
+	tests := []struct {
+		name          string
+		lenient       bool
+		messages      []Message
+		want          []Message // Sent to the inner provider
+		wantTruncated any
+		wantErr       error
+	}{
+		{
+			name:     "within budget",
+			messages: []Message{sys("abc"), user, sys("de")},
+			want:     []Message{sys("abc"), user, sys("de")},
+		},
+		{
+			name:     "rejected",
+			messages: []Message{sys("abcdef"), user},
+			wantErr:  ErrSystemPromptTooLong,
+		},
+		{
+			name:          "truncated in order",
+			lenient:       true,
+			messages:      []Message{sys("abc"), user, sys("defg"), sys("hij")},
+			want:          []Message{sys("abc"), user, sys("de")},
+			wantTruncated: 10,
+		},
+		{
+			name:          "multi-byte runes are cut whole",
+			lenient:       true,
+			messages:      []Message{sys("héllo wörld")},
+			want:          []Message{sys("héllo")},
+			wantTruncated: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			p := NewSystemPromptBudgetProvider(inner, 5, wordCounter{}, tt.lenient)
+			resp, err := p.Chat(context.Background(), &ChatRequest{Messages: tt.messages})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(inner.requests()) != 0 {
+					t.Error("rejected request was forwarded")
+				}
+				return
+			}
+			sent := inner.requests()[0].Messages
+			if !reflect.DeepEqual(sent, tt.want) {
+				t.Errorf("sent %+v, want %+v", sent, tt.want)
+			}
+			if got := resp.Metadata[MetadataSystemPromptTruncated]; got != tt.wantTruncated {
+				t.Errorf("truncated metadata = %v, want %v", got, tt.wantTruncated)
+			}
+		})
+	}
+}
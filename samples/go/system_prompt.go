@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// SystemPromptMode is how SystemPromptProvider handles multiple system messages.
+type SystemPromptMode int
+
+const (
+	// SystemPromptsMerge concatenates all system messages, in order, into one.
+	SystemPromptsMerge SystemPromptMode = iota
+	// SystemPromptsKeepFirst keeps the first system message and drops the rest.
+	SystemPromptsKeepFirst
+)
+
+// SystemPromptProvider wraps a Provider and collapses multiple system messages
+// into one before dispatch, since some backends reject or mishandle more than
+// one. The single system message takes the place of the first.
+type SystemPromptProvider struct {
+	Provider
+	mode SystemPromptMode
+}
+
+// NewSystemPromptProvider creates a provider that deduplicates system messages with mode.
+func NewSystemPromptProvider(inner Provider, mode SystemPromptMode) *SystemPromptProvider {
+	return &SystemPromptProvider{Provider: inner, mode: mode}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *SystemPromptProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat collapses the request's system messages and forwards it.
+func (p *SystemPromptProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	return p.Provider.Chat(ctx, p.normalize(req))
+}
+
+// normalize returns req with at most one system message. Requests that already
+// have at most one are returned unchanged.
+func (p *SystemPromptProvider) normalize(req *ChatRequest) *ChatRequest {
+	var prompts []string
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			prompts = append(prompts, m.Content)
+		}
+	}
+	if len(prompts) < 2 {
+		return req
+	}
+
+	system := prompts[0]
+	if p.mode == SystemPromptsMerge {
+		system = strings.Join(prompts, "\n\n")
+	}
+
+	out := *req
+	out.Messages = make([]Message, 0, len(req.Messages)-len(prompts)+1)
+	seen := false
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			if seen {
+				continue
+			}
+			seen = true
+			m.Content = system
+		}
+		out.Messages = append(out.Messages, m)
+	}
+	return &out
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSystemPromptProvider(t *testing.T) {
+	sys := func(s string) Message { return Message{Role: "system", Content: s} }
+	user := func(s string) Message { return Message{Role: "user", Content: s} }
+	tests := []struct {
+		name     string
+		mode     SystemPromptMode
+		messages []Message
+		want     []Message
+	}{
+		{"single system message", SystemPromptsMerge, []Message{sys("a"), user("hi")}, []Message{sys("a"), user("hi")}},
+		{"no system message", SystemPromptsMerge, []Message{user("hi")}, []Message{user("hi")}},
+		{"merged in order", SystemPromptsMerge, []Message{sys("a"), user("hi"), sys("b")}, []Message{sys("a\n\nb"), user("hi")}},
+		{"merged into the first position", SystemPromptsMerge, []Message{user("hi"), sys("a"), sys("b")}, []Message{user("hi"), sys("a\n\nb")}},
+		{"keep first", SystemPromptsKeepFirst, []Message{sys("a"), user("hi"), sys("b")}, []Message{sys("a"), user("hi")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			original := append([]Message(nil), tt.messages...)
+			if _, err := NewSystemPromptProvider(inner, tt.mode).Chat(context.Background(), &ChatRequest{Model: "m", Messages: tt.messages}); err != nil {
+				t.Fatal(err)
+			}
+			if got := inner.requests()[0].Messages; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.messages, original) {
+				t.Errorf("caller's messages were modified: %+v", tt.messages)
+			}
+		})
+	}
+}
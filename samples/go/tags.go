@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// TagOverflow replaces tag values beyond a TagLimits cardinality bound.
+const TagOverflow = "__other__"
+
+type tagsKey struct{}
+
+// WithTags returns a context labelling requests with tags, such as a cost
+// center or project, for usage attribution. Tags already on ctx are kept
+// unless tags overrides them.
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := maps.Clone(TagsFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// TagsFromContext returns the tags set by WithTags. The map must not be modified.
+func TagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
+
+// TagLimits bounds the cardinality of recorded tags, so a caller putting user
+// IDs or free text in a tag cannot blow up downstream metrics. Zero fields use
+// the defaults.
+type TagLimits struct {
+	MaxKeys   int // Tags kept per request, in key order (default 8)
+	MaxValues int // Distinct values kept per key; later ones become TagOverflow (default 100)
+}
+
+// tagBounder applies TagLimits, remembering the values seen for each key.
+type tagBounder struct {
+	limits TagLimits
+
+	mu   sync.Mutex
+	seen map[string]map[string]bool
+}
+
+func newTagBounder(limits TagLimits) *tagBounder {
+	if limits.MaxKeys <= 0 {
+		limits.MaxKeys = 8
+	}
+	if limits.MaxValues <= 0 {
+		limits.MaxValues = 100
+	}
+	return &tagBounder{limits: limits, seen: make(map[string]map[string]bool)}
+}
+
+// bound returns a copy of tags within the limits, or nil if tags is empty.
+func (b *tagBounder) bound(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := slices.Sorted(maps.Keys(tags))
+	if len(keys) > b.limits.MaxKeys {
+		keys = keys[:b.limits.MaxKeys]
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		v := tags[k]
+		values := b.seen[k]
+		if values == nil {
+			values = make(map[string]bool)
+			b.seen[k] = values
+		}
+		if !values[v] {
+			if len(values) >= b.limits.MaxValues {
+				v = TagOverflow
+			} else {
+				values[v] = true
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// TaggedUsage is the accumulated usage of requests sharing the same tags.
+type TaggedUsage struct {
+	Tags     map[string]string `json:"tags,omitempty"`
+	Usage    UsageStats        `json:"usage"`
+	Requests int               `json:"requests"`
+}
+
+// TagAccountingProvider wraps a Provider and accumulates token usage per
+// distinct set of context tags, for cost attribution by team or project.
+type TagAccountingProvider struct {
+	Provider
+	bounder *tagBounder
+
+	mu    sync.Mutex
+	usage map[string]*TaggedUsage
+}
+
+// NewTagAccountingProvider creates a provider that accounts usage by tag
+// within limits.
+func NewTagAccountingProvider(inner Provider, limits TagLimits) *TagAccountingProvider {
+	return &TagAccountingProvider{
+		Provider: inner,
+		bounder:  newTagBounder(limits),
+		usage:    make(map[string]*TaggedUsage),
+	}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *TagAccountingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and adds its usage to the totals for its tags.
+func (p *TagAccountingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := p.bounder.bound(TagsFromContext(ctx))
+	key := tagSetKey(tags)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	u := p.usage[key]
+	if u == nil {
+		u = &TaggedUsage{Tags: tags}
+		p.usage[key] = u
+	}
+	u.Requests++
+	if resp.Usage != nil {
+		u.Usage.PromptTokens += resp.Usage.PromptTokens
+		u.Usage.CompletionTokens += resp.Usage.CompletionTokens
+		u.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+	return resp, nil
+}
+
+// Usage returns the accumulated usage of every tag set, ordered by tags.
+func (p *TagAccountingProvider) Usage() []TaggedUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := slices.Sorted(maps.Keys(p.usage))
+	out := make([]TaggedUsage, len(keys))
+	for i, k := range keys {
+		out[i] = *p.usage[k]
+		out[i].Tags = maps.Clone(out[i].Tags)
+	}
+	return out
+}
+
+// tagSetKey returns a canonical string for tags.
+func tagSetKey(tags map[string]string) string {
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestWithTags(t *testing.T) {
+	ctx := WithTags(context.Background(), map[string]string{"team": "a", "project": "x"})
+	ctx = WithTags(ctx, map[string]string{"team": "b"})
+	want := map[string]string{"team": "b", "project": "x"}
+	if got := TagsFromContext(ctx); !maps.Equal(got, want) {
+		t.Errorf("tags = %v, want %v", got, want)
+	}
+}
+
+func TestTagBounder(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits TagLimits
+		calls  []map[string]string
+		want   map[string]string // Bounded tags of the last call
+	}{
+		{name: "no tags", calls: []map[string]string{nil}},
+		{
+			name:   "keys beyond the limit are dropped in key order",
+			limits: TagLimits{MaxKeys: 2},
+			calls:  []map[string]string{{"c": "3", "a": "1", "b": "2"}},
+			want:   map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name:   "new values beyond the limit overflow",
+			limits: TagLimits{MaxValues: 2},
+			calls:  []map[string]string{{"user": "u1"}, {"user": "u2"}, {"user": "u3"}},
+			want:   map[string]string{"user": TagOverflow},
+		},
+		{
+			name:   "seen values are kept",
+			limits: TagLimits{MaxValues: 2},
+			calls:  []map[string]string{{"user": "u1"}, {"user": "u2"}, {"user": "u3"}, {"user": "u1"}},
+			want:   map[string]string{"user": "u1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTagBounder(tt.limits)
+			var got map[string]string
+			for _, tags := range tt.calls {
+				got = b.bound(tags)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("bound = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagAccountingProvider(t *testing.T) {
+	inner := &fakeProvider{chat: scripted(outcome{resp: &ChatResponse{Usage: &UsageStats{PromptTokens: 2, CompletionTokens: 3, TotalTokens: 5}}})}
+	p := NewTagAccountingProvider(inner, TagLimits{})
+	teamA := WithTags(context.Background(), map[string]string{"team": "a"})
+	for _, ctx := range []context.Context{teamA, context.Background(), teamA} {
+		if _, err := p.Chat(ctx, &ChatRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage := p.Usage()
+	if len(usage) != 2 {
+		t.Fatalf("usage = %+v, want untagged and team a", usage)
+	}
+	if usage[0].Tags != nil || usage[0].Requests != 1 || usage[0].Usage.TotalTokens != 5 {
+		t.Errorf("untagged = %+v", usage[0])
+	}
+	if usage[1].Tags["team"] != "a" || usage[1].Requests != 2 || usage[1].Usage.TotalTokens != 10 {
+		t.Errorf("team a = %+v", usage[1])
+	}
+}
+
+func TestMeteringProviderTags(t *testing.T) {
+	sink := &recordingSink{}
+	p := NewMeteringProvider(&fakeProvider{}, MeteringConfig{Sink: sink, FlushInterval: time.Hour, TagLimits: TagLimits{MaxKeys: 1}})
+	ctx := WithTags(context.Background(), map[string]string{"project": "x", "team": "a"})
+	if _, err := p.Chat(ctx, &ChatRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events := sink.published()
+	if len(events) != 1 || !maps.Equal(events[0].Tags, map[string]string{"project": "x"}) {
+		t.Errorf("events = %+v, want one tagged with project x only", events)
+	}
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSlowGeneration is reported on the terminal chunk when a stream's
+// throughput stays below the configured floor.
+var ErrSlowGeneration = errors.New("generation throughput below floor")
+
+// ThroughputFloor configures ThroughputProvider.
+type ThroughputFloor struct {
+	// MinTokensPerSecond is the lowest acceptable rate, measured as content
+	// chunks per second over Window.
+	MinTokensPerSecond float64
+
+	// Window is the sliding window the rate is measured over. Defaults to 5s.
+	// Measurement starts with the first token, so time-to-first-token is not
+	// penalized; bound that with LatencyBoundProvider.
+	Window time.Duration
+
+	// Sustain is how long the rate must stay below the floor before the stream
+	// is cut off. Zero cuts off as soon as it drops.
+	Sustain time.Duration
+}
+
+// ThroughputProvider wraps a StreamingProvider and aborts streams whose token
+// rate degrades, ending them with reason StreamSlow and ErrSlowGeneration so
+// the caller can retry elsewhere instead of waiting on a stalled generation.
+type ThroughputProvider struct {
+	StreamingProvider
+	floor ThroughputFloor
+}
+
+// NewThroughputProvider creates a provider that enforces floor on every stream.
+func NewThroughputProvider(inner StreamingProvider, floor ThroughputFloor) *ThroughputProvider {
+	if floor.Window <= 0 {
+		floor.Window = 5 * time.Second
+	}
+	return &ThroughputProvider{StreamingProvider: inner, floor: floor}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ThroughputProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// ChatStream starts a stream that is cut off if its throughput drops too low.
+func (p *ThroughputProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		// Ticks catch streams that stall completely and stop sending chunks.
+		// The interval is clamped so that a tiny window neither spins nor,
+		// under 4ns, makes NewTicker panic.
+		ticker := time.NewTicker(max(p.floor.Window/4, time.Millisecond))
+		defer ticker.Stop()
+
+		var arrivals []time.Time
+		var first, below time.Time
+
+		check := func(now time.Time) error {
+			if first.IsZero() || now.Sub(first) < p.floor.Window {
+				return nil
+			}
+			cutoff := now.Add(-p.floor.Window)
+			for len(arrivals) > 0 && arrivals[0].Before(cutoff) {
+				arrivals = arrivals[1:]
+			}
+
+			rate := float64(len(arrivals)) / p.floor.Window.Seconds()
+			if rate >= p.floor.MinTokensPerSecond {
+				below = time.Time{}
+				return nil
+			}
+			if below.IsZero() {
+				below = now
+			}
+			if now.Sub(below) < p.floor.Sustain {
+				return nil
+			}
+			return fmt.Errorf("%w: %.1f tokens/s < %.1f", ErrSlowGeneration, rate, p.floor.MinTokensPerSecond)
+		}
+
+		for {
+			select {
+			case chunk, ok := <-in:
+				if chunk = normalizeChunk(streamCtx, chunk, ok); chunk.Done {
+					return chunk
+				}
+				now := time.Now()
+				if first.IsZero() {
+					first = now
+				}
+				arrivals = append(arrivals, now)
+				emit(chunk)
+				if err := check(now); err != nil {
+					return StreamChunk{Done: true, Reason: StreamSlow, Err: err}
+				}
+			case now := <-ticker.C:
+				if err := check(now); err != nil {
+					return StreamChunk{Done: true, Reason: StreamSlow, Err: err}
+				}
+			case <-streamCtx.Done():
+				return StreamChunk{Done: true, Reason: ctxEndReason(streamCtx), Err: streamCtx.Err()}
+			}
+		}
+	}), nil
+}
@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThroughputProvider(t *testing.T) {
+	const window = 40 * time.Millisecond
+	tests := []struct {
+		name       string
+		delay      time.Duration // Between chunks
+		chunks     int
+		sustain    time.Duration
+		wantReason StreamEndReason
+	}{
+		{"fast stream completes", time.Millisecond, 60, 0, StreamCompleted},
+		{"slow stream cut off", 20 * time.Millisecond, 10, 0, StreamSlow},
+		{"stalled stream cut off", 300 * time.Millisecond, 2, 0, StreamSlow},
+		{"dip shorter than sustain tolerated", 20 * time.Millisecond, 5, time.Second, StreamCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := pacedStreamer(tt.delay, textChunks(strings.Split(strings.Repeat("x", tt.chunks), "")...)...)
+			p := NewThroughputProvider(inner, ThroughputFloor{MinTokensPerSecond: 100, Window: window, Sustain: tt.sustain})
+			chunks, err := p.ChatStream(context.Background(), &ChatRequest{Model: "m"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, final := collectStream(chunks)
+			if final.Reason != tt.wantReason {
+				t.Fatalf("reason = %s (%v), want %s", final.Reason, final.Err, tt.wantReason)
+			}
+			if tt.wantReason == StreamCompleted {
+				if len(content) != tt.chunks {
+					t.Errorf("got %d chunks, want %d", len(content), tt.chunks)
+				}
+				return
+			}
+			if !errors.Is(final.Err, ErrSlowGeneration) || final.ErrorClass() != StreamErrorProvider {
+				t.Errorf("err = %v (class %q), want ErrSlowGeneration", final.Err, final.ErrorClass())
+			}
+			if len(content) >= tt.chunks {
+				t.Errorf("all %d chunks delivered despite the cutoff", tt.chunks)
+			}
+		})
+	}
+}
+
+func TestThroughputProviderTinyWindow(t *testing.T) {
+	p := NewThroughputProvider(streamingReply("a", "b"), ThroughputFloor{Window: 3 * time.Nanosecond})
+	chunks, err := p.ChatStream(context.Background(), &ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, final := collectStream(chunks); content != "ab" || final.Reason != StreamCompleted {
+		t.Errorf("content %q, reason %s (%v); want ab, completed", content, final.Reason, final.Err)
+	}
+}
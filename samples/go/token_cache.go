@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"strconv"
+	"sync"
+)
+
+// CachingTokenCounter wraps a TokenCounter with an LRU cache of its results,
+// so repeated estimates of identical text, such as a system prompt counted on
+// every request, cost a hash instead of a tokenization. Entries are keyed by
+// model and a hash of the content, so the same text under different models
+// is counted separately. It is safe for concurrent use if the wrapped counter is.
+type CachingTokenCounter struct {
+	counter TokenCounter
+	size    int
+
+	mu      sync.Mutex
+	order   *list.List // Most recently used first; values are tokenCacheKey
+	entries map[tokenCacheKey]*list.Element
+	counts  map[tokenCacheKey]int
+}
+
+type tokenCacheKey struct {
+	model    string
+	messages bool // CountMessages rather than CountTokens
+	hash     [sha256.Size]byte
+}
+
+// NewCachingTokenCounter caches up to size (default 1024) results of counter.
+func NewCachingTokenCounter(counter TokenCounter, size int) *CachingTokenCounter {
+	if size <= 0 {
+		size = 1024
+	}
+	return &CachingTokenCounter{
+		counter: counter,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[tokenCacheKey]*list.Element),
+		counts:  make(map[tokenCacheKey]int),
+	}
+}
+
+// CountTokens returns the cached count for text, computing it on a miss.
+func (c *CachingTokenCounter) CountTokens(model, text string) int {
+	key := tokenCacheKey{model: model, hash: sha256.Sum256([]byte(text))}
+	return c.count(key, func() int { return c.counter.CountTokens(model, text) })
+}
+
+// CountMessages returns the cached count for messages, computing it on a miss.
+// Roles and tool-call IDs are part of the key, since they can affect the
+// formatting overhead.
+func (c *CachingTokenCounter) CountMessages(model string, messages []Message) int {
+	h := sha256.New()
+	write := func(fields ...string) {
+		var n [8]byte
+		for _, f := range fields {
+			binary.LittleEndian.PutUint64(n[:], uint64(len(f)))
+			h.Write(n[:])
+			h.Write([]byte(f))
+		}
+	}
+	for _, m := range messages {
+		write(m.Role, m.Content, m.ToolCallID, strconv.Itoa(len(m.ToolCalls)))
+		for _, call := range m.ToolCalls {
+			write(call.ID, call.Name, call.Arguments)
+		}
+	}
+	key := tokenCacheKey{model: model, messages: true}
+	h.Sum(key.hash[:0])
+	return c.count(key, func() int { return c.counter.CountMessages(model, messages) })
+}
+
+func (c *CachingTokenCounter) count(key tokenCacheKey, compute func() int) int {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		n := c.counts[key]
+		c.mu.Unlock()
+		return n
+	}
+	c.mu.Unlock()
+
+	n := compute()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return n
+	}
+	c.entries[key] = c.order.PushFront(key)
+	c.counts[key] = n
+	if c.order.Len() > c.size {
+		oldest := c.order.Remove(c.order.Back()).(tokenCacheKey)
+		delete(c.entries, oldest)
+		delete(c.counts, oldest)
+	}
+	return n
+}
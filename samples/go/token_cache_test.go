@@ -0,0 +1,93 @@
+package llm
+
+import "testing"
+
+// countingCounter is a wordCounter that counts how often it is asked.
+type countingCounter struct {
+	wordCounter
+	tokens, messages int
+}
+
+func (c *countingCounter) CountTokens(model, text string) int {
+	c.tokens++
+	return c.wordCounter.CountTokens(model, text)
+}
+
+func (c *countingCounter) CountMessages(model string, messages []Message) int {
+	c.messages++
+	return c.wordCounter.CountMessages(model, messages)
+}
+
+func TestCachingTokenCounter(t *testing.T) {
+	type lookup struct {
+		model string
+		text  string
+		want  int
+	}
+	tests := []struct {
+		name         string
+		size         int
+		lookups      []lookup
+		wantComputed int
+	}{
+		{
+			name:         "repeats are cached",
+			size:         4,
+			lookups:      []lookup{{"m", "hello", 5}, {"m", "hello", 5}, {"m", "hi", 2}, {"m", "hello", 5}},
+			wantComputed: 2,
+		},
+		{
+			name:         "models are counted separately",
+			size:         4,
+			lookups:      []lookup{{"m1", "hello", 5}, {"m2", "hello", 5}},
+			wantComputed: 2,
+		},
+		{
+			name:         "least recently used is evicted",
+			size:         2,
+			lookups:      []lookup{{"m", "a", 1}, {"m", "bb", 2}, {"m", "a", 1}, {"m", "ccc", 3}, {"m", "a", 1}, {"m", "bb", 2}},
+			wantComputed: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &countingCounter{}
+			c := NewCachingTokenCounter(inner, tt.size)
+			for _, l := range tt.lookups {
+				if got := c.CountTokens(l.model, l.text); got != l.want {
+					t.Errorf("CountTokens(%q, %q) = %d, want %d", l.model, l.text, got, l.want)
+				}
+			}
+			if inner.tokens != tt.wantComputed {
+				t.Errorf("computed %d counts, want %d", inner.tokens, tt.wantComputed)
+			}
+		})
+	}
+}
+
+func TestCachingTokenCounterMessages(t *testing.T) {
+	inner := &countingCounter{}
+	c := NewCachingTokenCounter(inner, 0)
+	conversation := []Message{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}}
+	c.CountMessages("m", conversation)
+	c.CountMessages("m", []Message{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}})
+	if inner.messages != 1 {
+		t.Errorf("computed %d message counts for identical conversations, want 1", inner.messages)
+	}
+
+	variants := [][]Message{
+		{{Role: "user", Content: "be brief"}, {Role: "user", Content: "hi"}},
+		{{Role: "system", Content: "be brie"}, {Role: "user", Content: "fhi"}},
+		{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi", ToolCalls: []ToolCall{{ID: "1", Name: "f"}}}},
+	}
+	for _, msgs := range variants {
+		c.CountMessages("m", msgs)
+	}
+	if inner.messages != 1+len(variants) {
+		t.Errorf("computed %d message counts, want each variant counted separately", inner.messages)
+	}
+	c.CountTokens("m", "be brief")
+	if inner.tokens != 1 {
+		t.Error("a text count was served from a message count")
+	}
+}
@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidToolArgs is returned when the model's tool-call arguments do not
+// match the tool's declared parameter schema.
+var ErrInvalidToolArgs = errors.New("invalid tool-call arguments")
+
+// InvalidToolArgsError identifies the tool call whose arguments failed validation.
+type InvalidToolArgsError struct {
+	Call   ToolCall
+	Reason string
+}
+
+func (e *InvalidToolArgsError) Error() string {
+	return fmt.Sprintf("%v: %s(%s): %s", ErrInvalidToolArgs, e.Call.Name, e.Call.ID, e.Reason)
+}
+
+// Is reports whether target is ErrInvalidToolArgs.
+func (e *InvalidToolArgsError) Is(target error) bool {
+	return target == ErrInvalidToolArgs
+}
+
+// ToolArgsProvider wraps a Provider and validates the arguments of every
+// returned tool call against the request's ToolDefinition schemas. Invalid
+// calls are sent back to the model as tool errors up to maxRepairs times,
+// after which an *InvalidToolArgsError is returned.
+type ToolArgsProvider struct {
+	Provider
+	maxRepairs int
+}
+
+// NewToolArgsProvider creates a provider that validates tool-call arguments,
+// asking the model to fix invalid ones at most maxRepairs times.
+func NewToolArgsProvider(inner Provider, maxRepairs int) *ToolArgsProvider {
+	return &ToolArgsProvider{Provider: inner, maxRepairs: maxRepairs}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ToolArgsProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and validates the tool calls in the response.
+func (p *ToolArgsProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	attempt := req
+	for repairs := 0; ; repairs++ {
+		resp, err := p.Provider.Chat(ctx, attempt)
+		if err != nil {
+			return nil, err
+		}
+		invalid := validateToolCalls(req.Tools, resp.ToolCalls)
+		if invalid == nil {
+			if repairs > 0 {
+				resp.SetMetadata(MetadataRepairAttempts, repairs)
+			}
+			return resp, nil
+		}
+		if repairs >= p.maxRepairs {
+			return nil, invalid
+		}
+		attempt = toolRepairRequest(attempt, resp, invalid)
+	}
+}
+
+// validateToolCalls returns the first call whose arguments do not match its
+// tool's schema, or that names an undeclared tool.
+func validateToolCalls(tools []ToolDefinition, calls []ToolCall) *InvalidToolArgsError {
+	for _, call := range calls {
+		i := indexOfTool(tools, call.Name)
+		if i < 0 {
+			return &InvalidToolArgsError{Call: call, Reason: "unknown tool"}
+		}
+		if err := validateJSONSchema(tools[i].Parameters, []byte(call.Arguments)); err != nil {
+			return &InvalidToolArgsError{Call: call, Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+func indexOfTool(tools []ToolDefinition, name string) int {
+	for i, t := range tools {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// toolRepairRequest answers every call in resp with a tool result, reporting
+// the validation failure for the invalid call, so the model can reissue them.
+func toolRepairRequest(req *ChatRequest, resp *ChatResponse, invalid *InvalidToolArgsError) *ChatRequest {
+	out := *req
+	out.Messages = append(append([]Message(nil), req.Messages...),
+		Message{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls})
+	for _, call := range resp.ToolCalls {
+		result := "Not executed: another call in this turn had invalid arguments. Reissue all calls."
+		if call.ID == invalid.Call.ID {
+			result = fmt.Sprintf("Error: invalid arguments: %s. Fix the arguments to match the tool's parameter schema and call it again.", invalid.Reason)
+		}
+		out.Messages = append(out.Messages, Message{Role: "tool", Content: result, ToolCallID: call.ID})
+	}
+	return &out
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestToolArgsProvider(t *testing.T) {
+	tools := []ToolDefinition{{
+		Name:       "weather",
+		Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+	}}
+	call := func(name, args string) outcome {
+		return outcome{resp: &ChatResponse{ToolCalls: []ToolCall{{ID: "c1", Name: name, Arguments: args}}}}
+	}
+	valid := call("weather", `{"city":"Oslo"}`)
+	missing := call("weather", `{}`)
+
+	tests := []struct {
+		name        string
+		outcomes    []outcome
+		wantRepairs int
+		wantCalls   int
+		wantReason  string // Set if an *InvalidToolArgsError is expected
+	}{
+		{"valid arguments", []outcome{valid}, 0, 1, ""},
+		{"repaired", []outcome{missing, valid}, 1, 2, ""},
+		{"repairs exhausted", []outcome{missing}, 0, 3, "city"},
+		{"unknown tool", []outcome{call("stocks", `{}`)}, 0, 3, "unknown tool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(tt.outcomes...)}
+			resp, err := NewToolArgsProvider(inner, 2).Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("weather?"), Tools: tools})
+			if got := len(inner.requests()); got != tt.wantCalls {
+				t.Errorf("inner called %d times, want %d", got, tt.wantCalls)
+			}
+			if tt.wantReason != "" {
+				var ierr *InvalidToolArgsError
+				if !errors.As(err, &ierr) || !errors.Is(err, ErrInvalidToolArgs) || !strings.Contains(ierr.Reason, tt.wantReason) {
+					t.Fatalf("err = %v, want InvalidToolArgsError mentioning %q", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := resp.Metadata[MetadataRepairAttempts].(int); got != tt.wantRepairs {
+				t.Errorf("repairs = %d, want %d", got, tt.wantRepairs)
+			}
+			if tt.wantRepairs == 0 {
+				return
+			}
+			repair := inner.requests()[1].Messages
+			if last := repair[len(repair)-1]; last.Role != "tool" || last.ToolCallID != "c1" || !strings.Contains(last.Content, "invalid arguments") {
+				t.Errorf("repair message = %+v", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrDuplicateToolCallID is returned for responses in which two tool calls
+// share an ID, which would make their results impossible to match up.
+var ErrDuplicateToolCallID = errors.New("duplicate tool call ID")
+
+// MetadataRenamedToolCalls maps the new IDs of tool calls renamed by a
+// ToolCallIDProvider to the duplicated IDs they replaced.
+const MetadataRenamedToolCalls = "renamed_tool_calls"
+
+// UniqueToolCallIDs renames every repeat of a tool call ID in calls, keeping
+// the first occurrence, and returns a map from each new ID to the original. A
+// repeat of "call_1" becomes "call_1_2", then "call_1_3", skipping any ID
+// already in use, so the result depends only on the calls' order. Calls
+// without an ID are left alone. calls is not modified.
+func UniqueToolCallIDs(calls []ToolCall) ([]ToolCall, map[string]string) {
+	used := make(map[string]bool, len(calls))
+	for _, tc := range calls {
+		used[tc.ID] = true
+	}
+
+	seen := make(map[string]bool, len(calls))
+	var out []ToolCall
+	var renamed map[string]string
+	for i, tc := range calls {
+		if tc.ID == "" || !seen[tc.ID] {
+			seen[tc.ID] = true
+			continue
+		}
+		if out == nil {
+			out = append([]ToolCall(nil), calls...)
+			renamed = make(map[string]string)
+		}
+		id := tc.ID
+		for n := 2; used[id]; n++ {
+			id = tc.ID + "_" + strconv.Itoa(n)
+		}
+		used[id] = true
+		out[i].ID = id
+		renamed[id] = tc.ID
+	}
+	if out == nil {
+		return calls, nil
+	}
+	return out, renamed
+}
+
+// ToolCallIDProvider wraps a Provider and checks that the tool calls of each
+// response have distinct IDs. Duplicates are rejected with
+// ErrDuplicateToolCallID, or if rename is set, renamed with UniqueToolCallIDs.
+type ToolCallIDProvider struct {
+	Provider
+	rename bool
+}
+
+// NewToolCallIDProvider creates a provider that rejects, or if rename is set
+// renames, duplicate tool call IDs.
+func NewToolCallIDProvider(inner Provider, rename bool) *ToolCallIDProvider {
+	return &ToolCallIDProvider{Provider: inner, rename: rename}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ToolCallIDProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and checks the response's tool call IDs.
+func (p *ToolCallIDProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	calls, renamed := UniqueToolCallIDs(resp.ToolCalls)
+	if renamed == nil {
+		return resp, nil
+	}
+	if !p.rename {
+		for _, tc := range calls {
+			if original, ok := renamed[tc.ID]; ok {
+				return nil, fmt.Errorf("%w: %s: %q", ErrDuplicateToolCallID, p.ID(), original)
+			}
+		}
+	}
+	resp.ToolCalls = calls
+	resp.SetMetadata(MetadataRenamedToolCalls, renamed)
+	return resp, nil
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestUniqueToolCallIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		ids         []string
+		want        []string
+		wantRenamed map[string]string
+	}{
+		{name: "distinct", ids: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "repeat", ids: []string{"a", "a", "a"}, want: []string{"a", "a_2", "a_3"}, wantRenamed: map[string]string{"a_2": "a", "a_3": "a"}},
+		{name: "skips IDs in use", ids: []string{"a", "a", "a_2"}, want: []string{"a", "a_3", "a_2"}, wantRenamed: map[string]string{"a_3": "a"}},
+		{name: "empty IDs left alone", ids: []string{"", ""}, want: []string{"", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make([]ToolCall, len(tt.ids))
+			for i, id := range tt.ids {
+				calls[i] = ToolCall{ID: id, Name: "f"}
+			}
+			out, renamed := UniqueToolCallIDs(calls)
+			var got []string
+			for _, tc := range out {
+				got = append(got, tc.ID)
+			}
+			if !slices.Equal(got, tt.want) || !maps.Equal(renamed, tt.wantRenamed) {
+				t.Errorf("got %v renaming %v, want %v renaming %v", got, renamed, tt.want, tt.wantRenamed)
+			}
+			for i, tc := range calls {
+				if tc.ID != tt.ids[i] {
+					t.Fatalf("input modified: %+v", calls)
+				}
+			}
+		})
+	}
+}
+
+func TestToolCallIDProvider(t *testing.T) {
+	duplicated := []ToolCall{{ID: "a", Name: "f"}, {ID: "a", Name: "g"}}
+	tests := []struct {
+		name        string
+		rename      bool
+		calls       []ToolCall
+		wantErr     error
+		wantIDs     []string
+		wantRenamed bool
+	}{
+		{name: "distinct", calls: []ToolCall{{ID: "a"}, {ID: "b"}}, wantIDs: []string{"a", "b"}},
+		{name: "rejects duplicates", calls: duplicated, wantErr: ErrDuplicateToolCallID},
+		{name: "renames duplicates", rename: true, calls: duplicated, wantIDs: []string{"a", "a_2"}, wantRenamed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: scripted(outcome{resp: &ChatResponse{ToolCalls: tt.calls}})}
+			resp, err := NewToolCallIDProvider(inner, tt.rename).Chat(context.Background(), &ChatRequest{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var got []string
+			for _, tc := range resp.ToolCalls {
+				got = append(got, tc.ID)
+			}
+			if !slices.Equal(got, tt.wantIDs) {
+				t.Errorf("IDs = %v, want %v", got, tt.wantIDs)
+			}
+			if _, ok := resp.Metadata[MetadataRenamedToolCalls]; ok != tt.wantRenamed {
+				t.Errorf("renamed metadata present = %v, want %v", ok, tt.wantRenamed)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrToolConflict is returned when a request declares two different tools with the same name.
+var ErrToolConflict = errors.New("conflicting tool definitions")
+
+// DedupTools removes repeated identical tool definitions, keeping the first,
+// and returns ErrToolConflict if two definitions share a name but differ.
+// Parameter schemas are compared as JSON values, ignoring formatting and key order.
+func DedupTools(tools []ToolDefinition) ([]ToolDefinition, error) {
+	seen := make(map[string]ToolDefinition, len(tools))
+	out := make([]ToolDefinition, 0, len(tools))
+	for _, t := range tools {
+		prev, ok := seen[t.Name]
+		if !ok {
+			seen[t.Name] = t
+			out = append(out, t)
+			continue
+		}
+		if prev.Description != t.Description || !sameJSON(prev.Parameters, t.Parameters) {
+			return nil, fmt.Errorf("%w: %q", ErrToolConflict, t.Name)
+		}
+	}
+	return out, nil
+}
+
+// sameJSON reports whether a and b encode the same JSON value, regardless of
+// formatting and object key order.
+func sameJSON(a, b json.RawMessage) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// ToolDedupProvider wraps a Provider and normalizes the tool definitions of
+// each request with DedupTools, for tools composed from several sources.
+type ToolDedupProvider struct {
+	Provider
+}
+
+// NewToolDedupProvider creates a provider that deduplicates tool definitions.
+func NewToolDedupProvider(inner Provider) *ToolDedupProvider {
+	return &ToolDedupProvider{Provider: inner}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ToolDedupProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat deduplicates the request's tools and forwards it.
+func (p *ToolDedupProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	tools, err := DedupTools(req.Tools)
+	if err != nil {
+		return nil, err
+	}
+	if len(tools) == len(req.Tools) {
+		return p.Provider.Chat(ctx, req)
+	}
+	deduped := *req
+	deduped.Tools = tools
+	return p.Provider.Chat(ctx, &deduped)
+}
@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDedupTools(t *testing.T) {
+	tool := func(name, desc, params string) ToolDefinition {
+		return ToolDefinition{Name: name, Description: desc, Parameters: json.RawMessage(params)}
+	}
+	search := tool("search", "Search the web", `{"type":"object","properties":{"q":{"type":"string"}}}`)
+	tests := []struct {
+		name      string
+		tools     []ToolDefinition
+		wantNames []string
+		wantErr   bool
+	}{
+		{"distinct", []ToolDefinition{search, tool("fetch", "", `{}`)}, []string{"search", "fetch"}, false},
+		{"identical repeat", []ToolDefinition{search, tool("fetch", "", ""), search}, []string{"search", "fetch"}, false},
+		{"reformatted schema", []ToolDefinition{search, tool("search", "Search the web", "{\n  \"properties\": {\"q\": {\"type\": \"string\"}},\n  \"type\": \"object\"\n}")}, []string{"search"}, false},
+		{"different description", []ToolDefinition{search, tool("search", "Search docs", string(search.Parameters))}, nil, true},
+		{"different schema", []ToolDefinition{search, tool("search", "Search the web", `{"type":"object"}`)}, nil, true},
+		{"schema missing on one", []ToolDefinition{search, tool("search", "Search the web", "")}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DedupTools(tt.tools)
+			if tt.wantErr {
+				if !errors.Is(err, ErrToolConflict) {
+					t.Fatalf("err = %v, want ErrToolConflict", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, tool := range got {
+				names = append(names, tool.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("tools = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestToolDedupProvider(t *testing.T) {
+	inner := &fakeProvider{}
+	search := ToolDefinition{Name: "search"}
+	req := &ChatRequest{Model: "m", Tools: []ToolDefinition{search, search}}
+	if _, err := NewToolDedupProvider(inner).Chat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(inner.requests()[0].Tools); got != 1 || len(req.Tools) != 2 {
+		t.Errorf("sent %d tools, caller's request has %d; want 1 and 2", got, len(req.Tools))
+	}
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrMaxToolDepth is returned when a conversation has gone through more
+// tool-call rounds than allowed.
+var ErrMaxToolDepth = errors.New("maximum tool-call depth exceeded")
+
+// ToolDepthProvider wraps a Provider and bounds how many tool-call rounds an
+// agent loop may run. A round is an assistant message that requested tool
+// calls. Once the limit is reached the request is sent without tools so the
+// model must answer in text; conversations already past the limit, or
+// responses that still call tools, fail with ErrMaxToolDepth.
+type ToolDepthProvider struct {
+	Provider
+	maxDepth int
+}
+
+// NewToolDepthProvider creates a provider that allows at most maxDepth
+// tool-call rounds per conversation.
+func NewToolDepthProvider(inner Provider, maxDepth int) *ToolDepthProvider {
+	return &ToolDepthProvider{Provider: inner, maxDepth: maxDepth}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ToolDepthProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request, withholding tools once the depth limit is reached.
+func (p *ToolDepthProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	depth := toolDepth(req.Messages)
+	if depth > p.maxDepth {
+		return nil, fmt.Errorf("%w: %d > %d", ErrMaxToolDepth, depth, p.maxDepth)
+	}
+	if depth < p.maxDepth {
+		return p.Provider.Chat(ctx, req)
+	}
+
+	final := withSystemInstruction(req, "Tool use is no longer available. Answer with the information gathered so far.")
+	final.Tools = nil
+	resp, err := p.Provider.Chat(ctx, final)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.ToolCalls) > 0 {
+		return nil, fmt.Errorf("%w: model requested tools after %d rounds", ErrMaxToolDepth, depth)
+	}
+	return resp, nil
+}
+
+// toolDepth counts the tool-call rounds in a conversation.
+func toolDepth(messages []Message) int {
+	depth := 0
+	for _, m := range messages {
+		if m.Role == "assistant" && len(m.ToolCalls) > 0 {
+			depth++
+		}
+	}
+	return depth
+}
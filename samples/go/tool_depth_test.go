@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestToolDepthProvider(t *testing.T) {
+	// rounds returns a conversation with n completed tool-call rounds.
+	rounds := func(n int) []Message {
+		msgs := userMessages("plan a trip")
+		for range n {
+			msgs = append(msgs,
+				Message{Role: "assistant", ToolCalls: []ToolCall{{ID: "c", Name: "search"}}},
+				Message{Role: "tool", ToolCallID: "c", Content: "result"})
+		}
+		return msgs
+	}
+	tools := []ToolDefinition{{Name: "search"}}
+
+	tests := []struct {
+		name       string
+		depth      int
+		replyTools bool // The model still calls tools
+		wantTools  bool // Tools were sent to the inner provider
+		wantErr    bool
+		wantCalled bool
+	}{
+		{"below the limit", 1, true, true, false, true},
+		{"at the limit", 2, false, false, false, true},
+		{"tools requested at the limit", 2, true, false, true, true},
+		{"past the limit", 3, false, false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+				resp := &ChatResponse{Content: "done"}
+				if tt.replyTools {
+					resp.ToolCalls = []ToolCall{{ID: "d", Name: "search"}}
+				}
+				return resp, nil
+			}}
+			_, err := NewToolDepthProvider(inner, 2).Chat(context.Background(), &ChatRequest{Model: "m", Messages: rounds(tt.depth), Tools: tools})
+			if tt.wantErr != errors.Is(err, ErrMaxToolDepth) || !tt.wantErr && err != nil {
+				t.Fatalf("err = %v, want ErrMaxToolDepth: %v", err, tt.wantErr)
+			}
+			reqs := inner.requests()
+			if called := len(reqs) > 0; called != tt.wantCalled {
+				t.Fatalf("inner called: %v, want %v", called, tt.wantCalled)
+			}
+			if !tt.wantCalled {
+				return
+			}
+			if sent := len(reqs[0].Tools) > 0; sent != tt.wantTools {
+				t.Errorf("tools sent: %v, want %v", sent, tt.wantTools)
+			}
+			if !tt.wantTools && !strings.Contains(reqs[0].Messages[0].Content, "no longer available") {
+				t.Errorf("first message %+v lacks the final-answer instruction", reqs[0].Messages[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// ToolOrderProvider wraps a Provider and sorts the tool calls in each
+// response into a canonical order, so agents execute them the same way every
+// run however the backend ordered them. Calls are ordered by the position of
+// their tool in ChatRequest.Tools, then by arguments; undeclared tools sort
+// last, by name.
+type ToolOrderProvider struct {
+	Provider
+}
+
+// NewToolOrderProvider creates a provider that orders tool calls canonically.
+func NewToolOrderProvider(inner Provider) *ToolOrderProvider {
+	return &ToolOrderProvider{Provider: inner}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ToolOrderProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request and sorts the response's tool calls.
+func (p *ToolOrderProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	SortToolCalls(req.Tools, resp.ToolCalls)
+	return resp, nil
+}
+
+// SortToolCalls sorts calls in place into the canonical order described on
+// ToolOrderProvider.
+func SortToolCalls(tools []ToolDefinition, calls []ToolCall) {
+	rank := func(name string) int {
+		if i := indexOfTool(tools, name); i >= 0 {
+			return i
+		}
+		return len(tools)
+	}
+	slices.SortStableFunc(calls, func(a, b ToolCall) int {
+		return cmp.Or(
+			cmp.Compare(rank(a.Name), rank(b.Name)),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.Arguments, b.Arguments),
+		)
+	})
+}
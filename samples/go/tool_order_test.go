@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSortToolCalls(t *testing.T) {
+	tools := []ToolDefinition{{Name: "search"}, {Name: "fetch"}}
+	tests := []struct {
+		name  string
+		calls []ToolCall
+		want  []string // IDs in sorted order
+	}{
+		{
+			name:  "declaration order",
+			calls: []ToolCall{{ID: "1", Name: "fetch"}, {ID: "2", Name: "search"}},
+			want:  []string{"2", "1"},
+		},
+		{
+			name: "same tool by arguments",
+			calls: []ToolCall{
+				{ID: "1", Name: "search", Arguments: `{"q":"b"}`},
+				{ID: "2", Name: "search", Arguments: `{"q":"a"}`},
+			},
+			want: []string{"2", "1"},
+		},
+		{
+			name: "undeclared tools last by name",
+			calls: []ToolCall{
+				{ID: "1", Name: "zeta"},
+				{ID: "2", Name: "alpha"},
+				{ID: "3", Name: "fetch"},
+			},
+			want: []string{"3", "2", "1"},
+		},
+		{
+			name: "identical calls keep their order",
+			calls: []ToolCall{
+				{ID: "1", Name: "fetch", Arguments: "{}"},
+				{ID: "2", Name: "fetch", Arguments: "{}"},
+			},
+			want: []string{"1", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SortToolCalls(tools, tt.calls)
+			var got []string
+			for _, c := range tt.calls {
+				got = append(got, c.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolOrderProvider(t *testing.T) {
+	inner := &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+		return &ChatResponse{ToolCalls: []ToolCall{{ID: "1", Name: "b"}, {ID: "2", Name: "a"}}}, nil
+	}}
+	resp, err := NewToolOrderProvider(inner).Chat(context.Background(), &ChatRequest{Tools: []ToolDefinition{{Name: "a"}, {Name: "b"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ToolCalls[0].ID != "2" || resp.ToolCalls[1].ID != "1" {
+		t.Errorf("tool calls = %+v, want a before b", resp.ToolCalls)
+	}
+}
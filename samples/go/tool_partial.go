@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PartialToolArguments forwards the events of a ChatStreamEvents stream and,
+// after each tool-call delta, adds an EventToolCallPartial event carrying the
+// call's arguments so far as valid JSON, so a UI can show values such as a
+// search query while they are still being generated. Incomplete strings are
+// closed and incomplete keys or values dropped, so partial values only grow.
+// The event is skipped when the parse has not changed. Partial arguments are
+// a preview: the final call is the concatenation of the deltas, which should
+// be validated as usual. Consumers must drain the channel.
+func PartialToolArguments(events <-chan StreamEvent) <-chan StreamEvent {
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+
+		type callKey struct{ choice, call int }
+		calls := make(map[callKey]*ToolCallDelta)
+		last := make(map[callKey]string)
+
+		for ev := range events {
+			out <- ev
+			if ev.Type != EventToolCallDelta || ev.ToolCall == nil {
+				continue
+			}
+
+			key := callKey{ev.Index, ev.ToolCall.Index}
+			call := calls[key]
+			if call == nil {
+				call = &ToolCallDelta{Index: ev.ToolCall.Index}
+				calls[key] = call
+			}
+			if ev.ToolCall.ID != "" {
+				call.ID = ev.ToolCall.ID
+			}
+			if ev.ToolCall.Name != "" {
+				call.Name = ev.ToolCall.Name
+			}
+			call.Arguments += ev.ToolCall.Arguments
+
+			partial, ok := completePartialJSON(call.Arguments)
+			if !ok || partial == last[key] {
+				continue
+			}
+			last[key] = partial
+			snapshot := *call
+			out <- StreamEvent{
+				Type:             EventToolCallPartial,
+				Index:            ev.Index,
+				ToolCall:         &snapshot,
+				Received:         ev.Received,
+				PartialArguments: json.RawMessage(partial),
+			}
+		}
+	}()
+	return out
+}
+
+// completePartialJSON turns a prefix of a JSON document into valid JSON by
+// closing an open string value, minus any split rune or escape at its end,
+// and the open containers, cutting back to the last complete member if the
+// tail cannot be completed. It reports false if nothing usable has arrived yet.
+func completePartialJSON(s string) (string, bool) {
+	type frame struct {
+		open      byte
+		expectKey bool
+	}
+	var stack []frame
+	inString, escaped, isKey := false, false, false
+
+	// The latest prefix that ends on a complete member, with its open
+	// containers, to fall back to.
+	safe, safeStack := -1, []frame(nil)
+	markSafe := func(i int) {
+		safe, safeStack = i, append([]frame(nil), stack...)
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if !isKey {
+					markSafe(i + 1)
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+			isKey = len(stack) > 0 && stack[len(stack)-1].open == '{' && stack[len(stack)-1].expectKey
+		case '{', '[':
+			stack = append(stack, frame{open: c, expectKey: c == '{'})
+			markSafe(i + 1)
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			markSafe(i + 1)
+		case ':':
+			if len(stack) > 0 {
+				stack[len(stack)-1].expectKey = false
+			}
+		case ',':
+			markSafe(i)
+			if len(stack) > 0 && stack[len(stack)-1].open == '{' {
+				stack[len(stack)-1].expectKey = true
+			}
+		}
+	}
+
+	closers := func(stack []frame) string {
+		var b strings.Builder
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].open == '{' {
+				b.WriteByte('}')
+			} else {
+				b.WriteByte(']')
+			}
+		}
+		return b.String()
+	}
+
+	// Close an open string value, or try the tail as is (a number in
+	// progress, or a complete document).
+	var candidate string
+	switch {
+	case inString && !isKey:
+		var carry runeCarry
+		candidate = trimPartialEscape(carry.complete(s)) + `"` + closers(stack)
+	case !inString:
+		candidate = s + closers(stack)
+	}
+	if candidate != "" && validJSONValue(candidate) {
+		return candidate, true
+	}
+	if safe < 0 {
+		return "", false
+	}
+	candidate = strings.TrimRight(s[:safe], " \t\r\n") + closers(safeStack)
+	return candidate, validJSONValue(candidate)
+}
+
+// trimPartialEscape drops an incomplete escape sequence from the end of an
+// unterminated JSON string.
+func trimPartialEscape(s string) string {
+	i := strings.LastIndexByte(s, '\\')
+	if i < 0 {
+		return s
+	}
+	// Count the backslashes before the last one: an even run means it is
+	// itself escaped.
+	run := 0
+	for j := i; j >= 0 && s[j] == '\\'; j-- {
+		run++
+	}
+	if run%2 == 0 {
+		return s
+	}
+	tail := s[i+1:]
+	if tail == "" || (tail[0] == 'u' && len(tail) < 5) {
+		return s[:i]
+	}
+	return s
+}
+
+func validJSONValue(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && json.Valid([]byte(s))
+}
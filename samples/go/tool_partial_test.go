@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompletePartialJSON(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string // "" when nothing usable has arrived
+	}{
+		{``, ``},
+		{`{`, `{}`},
+		{`{"q`, `{}`},
+		{`{"q":`, `{}`},
+		{`{"q": "wea`, `{"q": "wea"}`},
+		{`{"q":"a\`, `{"q":"a"}`},
+		{`{"q":"a\u00`, `{"q":"a"}`},
+		{`{"q":"a\\`, `{"q":"a\\"}`},
+		{`{"q":"é`[:7], `{"q":""}`},
+		{`{"n":12`, `{"n":12}`},
+		{`{"n":tr`, `{}`},
+		{`{"a":[1,2`, `{"a":[1,2]}`},
+		{`{"a":[1,"x`, `{"a":[1,"x"]}`},
+		{`{"a":"b","c`, `{"a":"b"}`},
+		{`{"a":"b",`, `{"a":"b"}`},
+		{`{"a":{"b":1},"c":[`, `{"a":{"b":1},"c":[]}`},
+		{`{"a":1}`, `{"a":1}`},
+		{`"ab`, `"ab"`},
+		{`{"a":"b"}}`, ``},
+	}
+	for _, tt := range tests {
+		got, ok := completePartialJSON(tt.prefix)
+		if ok != (tt.want != "") || (ok && got != tt.want) {
+			t.Errorf("completePartialJSON(%q) = %q, %v, want %q", tt.prefix, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPartialToolArguments(t *testing.T) {
+	delta := func(choice, call int, id, args string) StreamEvent {
+		return StreamEvent{Type: EventToolCallDelta, Index: choice, ToolCall: &ToolCallDelta{Index: call, ID: id, Arguments: args}}
+	}
+	tests := []struct {
+		name   string
+		events []StreamEvent
+		want   []string // PartialArguments of the partial events, in order
+	}{
+		{
+			name:   "one call",
+			events: []StreamEvent{delta(0, 0, "c1", `{"q":"we`), delta(0, 0, "", `ather`), delta(0, 0, "", `"}`)},
+			want:   []string{`{"q":"we"}`, `{"q":"weather"}`},
+		},
+		{
+			name:   "unchanged parse is skipped",
+			events: []StreamEvent{delta(0, 0, "c1", `{"q":"a"`), delta(0, 0, "", `,"`), delta(0, 0, "", `n`)},
+			want:   []string{`{"q":"a"}`},
+		},
+		{
+			name:   "nothing usable yet",
+			events: []StreamEvent{delta(0, 0, "c1", ``), delta(0, 0, "", `{`)},
+			want:   []string{`{}`},
+		},
+		{
+			name:   "interleaved calls",
+			events: []StreamEvent{delta(0, 0, "c1", `{"a":1`), delta(0, 1, "c2", `{"b":2`), delta(1, 0, "c3", `{"a":1`)},
+			want:   []string{`{"a":1}`, `{"b":2}`, `{"a":1}`},
+		},
+		{
+			name:   "other events pass through",
+			events: []StreamEvent{{Type: EventContentDelta, Content: "x"}, {Type: EventToolCallDelta}, {Type: EventDone}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan StreamEvent, len(tt.events))
+			for _, ev := range tt.events {
+				in <- ev
+			}
+			close(in)
+
+			var forwarded int
+			var got []string
+			for ev := range PartialToolArguments(in) {
+				if ev.Type != EventToolCallPartial {
+					forwarded++
+					continue
+				}
+				if ev.ToolCall == nil || ev.ToolCall.ID == "" {
+					t.Errorf("partial event %+v does not identify its call", ev)
+				}
+				got = append(got, string(ev.PartialArguments))
+			}
+			if forwarded != len(tt.events) {
+				t.Errorf("forwarded %d events, want %d", forwarded, len(tt.events))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("partials = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
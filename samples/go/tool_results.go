@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Errors returned by ToolResultCollector.Add and AddAt.
+var (
+	ErrUnknownToolCall     = errors.New("result for unknown tool call")
+	ErrDuplicateToolResult = errors.New("tool call already has a result")
+	ErrAmbiguousToolCall   = errors.New("tool call ID is empty or not unique")
+)
+
+// ToolResultCollector gathers the results of tool calls executed
+// concurrently, which may finish in any order, and releases them as tool
+// messages in the order the model requested the calls. Results are slotted
+// by the call's position; calls whose ID is empty or shared with another call
+// can only be given results by position, with AddAt. It is safe for
+// concurrent use.
+type ToolResultCollector struct {
+	index map[string]int // Position of each call ID; -1 if it is ambiguous
+
+	mu        sync.Mutex
+	results   []Message
+	filled    []bool
+	remaining int
+	ready     chan struct{}
+}
+
+// NewToolResultCollector creates a collector for the results of calls.
+func NewToolResultCollector(calls []ToolCall) *ToolResultCollector {
+	c := &ToolResultCollector{
+		index:     make(map[string]int, len(calls)),
+		results:   make([]Message, len(calls)),
+		filled:    make([]bool, len(calls)),
+		remaining: len(calls),
+		ready:     make(chan struct{}),
+	}
+	for i, call := range calls {
+		if _, seen := c.index[call.ID]; seen || call.ID == "" {
+			c.index[call.ID] = -1
+		} else {
+			c.index[call.ID] = i
+		}
+		c.results[i] = Message{Role: "tool", ToolCallID: call.ID}
+	}
+	if c.remaining == 0 {
+		close(c.ready)
+	}
+	return c
+}
+
+// Add records the result of the call with callID. It fails with
+// ErrAmbiguousToolCall if callID does not identify a single call.
+func (c *ToolResultCollector) Add(callID, content string) error {
+	i, ok := c.index[callID]
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: %s", ErrUnknownToolCall, callID)
+	case i < 0:
+		return fmt.Errorf("%w: %q", ErrAmbiguousToolCall, callID)
+	}
+	return c.AddAt(i, content)
+}
+
+// AddAt records the result of the i'th call.
+func (c *ToolResultCollector) AddAt(i int, content string) error {
+	if i < 0 || i >= len(c.results) {
+		return fmt.Errorf("%w: index %d of %d", ErrUnknownToolCall, i, len(c.results))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.filled[i] {
+		return fmt.Errorf("%w: %s (index %d)", ErrDuplicateToolResult, c.results[i].ToolCallID, i)
+	}
+	c.results[i].Content = content
+	c.filled[i] = true
+	if c.remaining--; c.remaining == 0 {
+		close(c.ready)
+	}
+	return nil
+}
+
+// Wait blocks until every call has a result, then returns the tool messages
+// in call order.
+func (c *ToolResultCollector) Wait(ctx context.Context) ([]Message, error) {
+	select {
+	case <-c.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.results...), nil
+}
+
+// ExecuteToolCalls runs every call concurrently with run and returns their
+// results as tool messages in call order. If any call fails, the remaining
+// calls are canceled and the first error is returned.
+func ExecuteToolCalls(ctx context.Context, calls []ToolCall, run func(ctx context.Context, call ToolCall) (string, error)) ([]Message, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	collector := NewToolResultCollector(calls)
+	for i, call := range calls {
+		go func() {
+			content, err := run(ctx, call)
+			if err != nil {
+				cancel(fmt.Errorf("tool %s (%s): %w", call.Name, call.ID, err))
+				return
+			}
+			if err := collector.AddAt(i, content); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+
+	results, err := collector.Wait(ctx)
+	if err != nil {
+		return nil, context.Cause(ctx)
+	}
+	return results, nil
+}
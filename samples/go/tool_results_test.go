@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestToolResultCollector(t *testing.T) {
+	type add struct {
+		id      string
+		at      int // Position to add at when id is empty
+		content string
+		wantErr error
+	}
+	tests := []struct {
+		name  string
+		calls []string // Call IDs
+		adds  []add
+		want  []string // Contents in call order; nil if incomplete
+	}{
+		{
+			name:  "out of order",
+			calls: []string{"a", "b", "c"},
+			adds:  []add{{id: "c", content: "3"}, {id: "a", content: "1"}, {id: "b", content: "2"}},
+			want:  []string{"1", "2", "3"},
+		},
+		{
+			name:  "unknown and duplicate results",
+			calls: []string{"a"},
+			adds:  []add{{id: "x", wantErr: ErrUnknownToolCall}, {id: "a", content: "1"}, {id: "a", wantErr: ErrDuplicateToolResult}},
+			want:  []string{"1"},
+		},
+		{
+			name:  "duplicate IDs are slotted by position",
+			calls: []string{"a", "a"},
+			adds:  []add{{id: "a", wantErr: ErrAmbiguousToolCall}, {at: 1, content: "2"}, {at: 0, content: "1"}},
+			want:  []string{"1", "2"},
+		},
+		{
+			name:  "empty IDs are slotted by position",
+			calls: []string{"", "b"},
+			adds:  []add{{id: "b", content: "2"}, {at: 0, content: "1"}, {at: 0, wantErr: ErrDuplicateToolResult}, {at: 2, wantErr: ErrUnknownToolCall}},
+			want:  []string{"1", "2"},
+		},
+		{
+			name:  "incomplete",
+			calls: []string{"a", "b"},
+			adds:  []add{{id: "a", content: "1"}},
+		},
+		{name: "no calls", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make([]ToolCall, len(tt.calls))
+			for i, id := range tt.calls {
+				calls[i] = ToolCall{ID: id, Name: "tool"}
+			}
+			c := NewToolResultCollector(calls)
+			for _, a := range tt.adds {
+				var err error
+				if a.id != "" {
+					err = c.Add(a.id, a.content)
+				} else {
+					err = c.AddAt(a.at, a.content)
+				}
+				if !errors.Is(err, a.wantErr) {
+					t.Errorf("add %+v: err = %v, want %v", a, err, a.wantErr)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			results, err := c.Wait(ctx)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("Wait = %+v, want it to block until ctx ends", results)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for i, m := range results {
+				if m.Role != "tool" || m.ToolCallID != tt.calls[i] {
+					t.Errorf("result %d = %+v, want a tool message for %q", i, m, tt.calls[i])
+				}
+				got = append(got, m.Content)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("contents = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteToolCalls(t *testing.T) {
+	errFailed := errors.New("failed")
+	calls := []ToolCall{{ID: "a", Name: "slow"}, {ID: "a", Name: "fast"}, {Name: "echo", Arguments: "x"}}
+	run := func(ctx context.Context, call ToolCall) (string, error) {
+		switch call.Name {
+		case "slow":
+			time.Sleep(10 * time.Millisecond)
+		case "fail":
+			return "", errFailed
+		case "block":
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return call.Name, nil
+	}
+
+	results, err := ExecuteToolCalls(context.Background(), calls, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range results {
+		got = append(got, m.Content)
+	}
+	if want := []string{"slow", "fast", "echo"}; !slices.Equal(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+
+	_, err = ExecuteToolCalls(context.Background(), []ToolCall{{ID: "1", Name: "block"}, {ID: "2", Name: "fail"}}, run)
+	if !errors.Is(err, errFailed) {
+		t.Errorf("err = %v, want %v", err, errFailed)
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrToolsNotSupported is returned when a request uses tools on a backend that cannot handle them.
+var ErrToolsNotSupported = errors.New("provider does not support tools")
+
+// ToolMessageMode is how ToolRoleProvider handles tool usage for a backend without tool support.
+type ToolMessageMode int
+
+const (
+	// ToolMessagesReject fails requests that carry tools, tool calls or tool results.
+	ToolMessagesReject ToolMessageMode = iota
+	// ToolMessagesConvert folds tool calls and results into plain text messages
+	// and drops tool definitions.
+	ToolMessagesConvert
+)
+
+// ToolRoleProvider wraps a Provider whose backend does not support tools, so
+// that tool-using conversations can be reused on it. Requests are passed
+// through untouched if the wrapped provider declares tool support.
+type ToolRoleProvider struct {
+	Provider
+	mode ToolMessageMode
+}
+
+// NewToolRoleProvider creates a provider that handles tool messages with mode.
+func NewToolRoleProvider(inner Provider, mode ToolMessageMode) *ToolRoleProvider {
+	return &ToolRoleProvider{Provider: inner, mode: mode}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *ToolRoleProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat converts or rejects tool usage as configured and forwards the request.
+func (p *ToolRoleProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if CapabilitiesOf(p.Provider).Tools || !usesTools(req) {
+		return p.Provider.Chat(ctx, req)
+	}
+	if p.mode == ToolMessagesReject {
+		return nil, fmt.Errorf("%w: %s", ErrToolsNotSupported, p.ID())
+	}
+	return p.Provider.Chat(ctx, foldToolMessages(req))
+}
+
+func usesTools(req *ChatRequest) bool {
+	if len(req.Tools) > 0 {
+		return true
+	}
+	for _, m := range req.Messages {
+		if m.Role == "tool" || len(m.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// foldToolMessages returns a copy of req with tool calls described in the
+// assistant's text, tool results sent as user messages and tool definitions removed.
+func foldToolMessages(req *ChatRequest) *ChatRequest {
+	out := *req
+	out.Tools = nil
+	out.Messages = make([]Message, 0, len(req.Messages))
+
+	for _, m := range req.Messages {
+		switch {
+		case m.Role == "tool":
+			out.Messages = append(out.Messages, Message{
+				Role:    "user",
+				Content: fmt.Sprintf("Tool result for call %s:\n%s", m.ToolCallID, m.Content),
+			})
+		case len(m.ToolCalls) > 0:
+			var b strings.Builder
+			b.WriteString(m.Content)
+			for _, tc := range m.ToolCalls {
+				if b.Len() > 0 {
+					b.WriteString("\n")
+				}
+				fmt.Fprintf(&b, "Calling tool %s (call %s) with arguments %s", tc.Name, tc.ID, tc.Arguments)
+			}
+			out.Messages = append(out.Messages, Message{Role: m.Role, Content: b.String()})
+		default:
+			out.Messages = append(out.Messages, m)
+		}
+	}
+	return &out
+}
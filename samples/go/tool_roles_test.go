@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// toolProvider is a fakeProvider that declares tool support.
+type toolProvider struct{ *fakeProvider }
+
+func (toolProvider) Capabilities() Capabilities { return Capabilities{Tools: true} }
+
+func TestToolRoleProvider(t *testing.T) {
+	conversation := []Message{
+		{Role: "user", Content: "Weather in Oslo?"},
+		{Role: "assistant", Content: "Let me check.", ToolCalls: []ToolCall{{ID: "c1", Name: "weather", Arguments: `{"city":"Oslo"}`}}},
+		{Role: "tool", ToolCallID: "c1", Content: "rain"},
+	}
+	defs := []ToolDefinition{{Name: "weather"}}
+	tests := []struct {
+		name      string
+		tools     bool // Whether the wrapped provider supports tools
+		mode      ToolMessageMode
+		messages  []Message
+		defs      []ToolDefinition
+		wantErr   bool
+		wantSent  []Message
+		wantTools bool
+	}{
+		{"plain conversation", false, ToolMessagesReject, userMessages("hi"), nil, false, userMessages("hi"), false},
+		{"supported", true, ToolMessagesReject, conversation, defs, false, conversation, true},
+		{"rejected", false, ToolMessagesReject, conversation, defs, true, nil, false},
+		{"converted", false, ToolMessagesConvert, conversation, defs, false, []Message{
+			{Role: "user", Content: "Weather in Oslo?"},
+			{Role: "assistant", Content: "Let me check.\nCalling tool weather (call c1) with arguments {\"city\":\"Oslo\"}"},
+			{Role: "user", Content: "Tool result for call c1:\nrain"},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeProvider{}
+			var inner Provider = fake
+			if tt.tools {
+				inner = toolProvider{fake}
+			}
+			p := NewToolRoleProvider(inner, tt.mode)
+			_, err := p.Chat(context.Background(), &ChatRequest{Messages: tt.messages, Tools: tt.defs})
+			if tt.wantErr {
+				if !errors.Is(err, ErrToolsNotSupported) || len(fake.requests()) != 0 {
+					t.Fatalf("err = %v, want ErrToolsNotSupported before dispatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			sent := fake.requests()[0]
+			if len(sent.Messages) != len(tt.wantSent) {
+				t.Fatalf("sent %+v, want %+v", sent.Messages, tt.wantSent)
+			}
+			for i := range tt.wantSent {
+				got, want := sent.Messages[i], tt.wantSent[i]
+				if got.Role != want.Role || got.Content != want.Content || len(got.ToolCalls) != len(want.ToolCalls) {
+					t.Errorf("message %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if hasTools := len(sent.Tools) > 0; hasTools != tt.wantTools {
+				t.Errorf("tool definitions sent: %v, want %v", hasTools, tt.wantTools)
+			}
+		})
+	}
+}
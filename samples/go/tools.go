@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrToolFeedbackUnsupported is returned when a provider cannot accept tool
+// results during an in-progress generation.
+var ErrToolFeedbackUnsupported = errors.New("provider does not support streaming tool feedback")
+
+// ToolDefinition declares a tool the model may call.
+type ToolDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"` // JSON schema of the arguments
+}
+
+// ToolCall is a model's request to invoke a tool.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON-encoded arguments
+}
+
+// toolFunctionWire is the OpenAI "function" envelope shared by tool definitions and calls.
+type toolFunctionWire struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Arguments   string          `json:"arguments,omitempty"`
+}
+
+type toolWire struct {
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type"`
+	Function toolFunctionWire `json:"function"`
+}
+
+// MarshalJSON encodes the definition in the OpenAI function-tool shape.
+func (t ToolDefinition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toolWire{
+		Type:     "function",
+		Function: toolFunctionWire{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+	})
+}
+
+// UnmarshalJSON decodes a definition in the OpenAI function-tool shape.
+func (t *ToolDefinition) UnmarshalJSON(data []byte) error {
+	var w toolWire
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*t = ToolDefinition{Name: w.Function.Name, Description: w.Function.Description, Parameters: w.Function.Parameters}
+	return nil
+}
+
+// MarshalJSON encodes the call in the OpenAI function-call shape.
+func (c ToolCall) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toolWire{
+		ID:       c.ID,
+		Type:     "function",
+		Function: toolFunctionWire{Name: c.Name, Arguments: c.Arguments},
+	})
+}
+
+// UnmarshalJSON decodes a call in the OpenAI function-call shape.
+func (c *ToolCall) UnmarshalJSON(data []byte) error {
+	var w toolWire
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	*c = ToolCall{ID: w.ID, Name: w.Function.Name, Arguments: w.Function.Arguments}
+	return nil
+}
+
+// ToolFeedback feeds tool results into a generation that is still in progress.
+type ToolFeedback interface {
+	// Append sends the next piece of the result for toolCallID.
+	Append(toolCallID, content string) error
+
+	// Complete marks the result for toolCallID as finished.
+	Complete(toolCallID string) error
+}
+
+// ToolFeedbackProvider is implemented by streaming backends that accept
+// incremental tool results while they are generating.
+type ToolFeedbackProvider interface {
+	StreamingProvider
+
+	// ChatStreamWithToolFeedback starts a stream and returns a ToolFeedback for
+	// sending tool results back into it.
+	ChatStreamWithToolFeedback(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, ToolFeedback, error)
+}
+
+// StreamWithToolFeedback starts a stream with incremental tool feedback on p,
+// returning ErrToolFeedbackUnsupported if p cannot accept it.
+func StreamWithToolFeedback(ctx context.Context, p Provider, req *ChatRequest) (<-chan StreamChunk, ToolFeedback, error) {
+	fp, ok := p.(ToolFeedbackProvider)
+	if !ok {
+		return nil, nil, ErrToolFeedbackUnsupported
+	}
+	return fp.ChatStreamWithToolFeedback(ctx, req)
+}
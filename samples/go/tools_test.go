@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestToolJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{
+			"definition",
+			ToolDefinition{Name: "search", Description: "Search the web", Parameters: json.RawMessage(`{"type":"object"}`)},
+			`{"type":"function","function":{"name":"search","description":"Search the web","parameters":{"type":"object"}}}`,
+		},
+		{
+			"call",
+			ToolCall{ID: "call_1", Name: "search", Arguments: `{"q":"go"}`},
+			`{"id":"call_1","type":"function","function":{"name":"search","arguments":"{\"q\":\"go\"}"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("encoded %s, want %s", data, tt.want)
+			}
+			switch v := tt.v.(type) {
+			case ToolDefinition:
+				var got ToolDefinition
+				if err := json.Unmarshal(data, &got); err != nil || got.Name != v.Name || string(got.Parameters) != string(v.Parameters) {
+					t.Errorf("decoded %+v (%v), want %+v", got, err, v)
+				}
+			case ToolCall:
+				var got ToolCall
+				if err := json.Unmarshal(data, &got); err != nil || got != v {
+					t.Errorf("decoded %+v (%v), want %+v", got, err, v)
+				}
+			}
+		})
+	}
+}
+
+type feedbackRecorder struct {
+	*fakeStreamer
+	appended []string
+}
+
+func (f *feedbackRecorder) ChatStreamWithToolFeedback(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, ToolFeedback, error) {
+	chunks, err := f.ChatStream(ctx, req)
+	return chunks, f, err
+}
+
+func (f *feedbackRecorder) Append(id, content string) error {
+	f.appended = append(f.appended, id+":"+content)
+	return nil
+}
+
+func (f *feedbackRecorder) Complete(id string) error { return nil }
+
+func TestStreamWithToolFeedback(t *testing.T) {
+	if _, _, err := StreamWithToolFeedback(context.Background(), streamingReply("x"), &ChatRequest{}); !errors.Is(err, ErrToolFeedbackUnsupported) {
+		t.Errorf("plain streamer: err = %v, want ErrToolFeedbackUnsupported", err)
+	}
+
+	rec := &feedbackRecorder{fakeStreamer: streamingReply("x")}
+	chunks, feedback, err := StreamWithToolFeedback(context.Background(), rec, &ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := feedback.Append("call_1", "partial"); err != nil {
+		t.Fatal(err)
+	}
+	collectStream(chunks)
+	if len(rec.appended) != 1 || rec.appended[0] != "call_1:partial" {
+		t.Errorf("appended %q", rec.appended)
+	}
+}
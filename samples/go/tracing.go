@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"math/rand/v2"
+)
+
+// Span is an in-progress trace span, as created by a Tracer.
+type Span interface {
+	SetAttributes(attrs map[string]any)
+	End(err error)
+}
+
+// Tracer starts spans. Adapters for OpenTelemetry or another tracing system
+// implement it; the returned context carries the span to its children.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type traceSampledKey struct{}
+
+// WithTraceSampled records on ctx whether the enclosing trace is sampled, so
+// that nested TracingProviders, and callers propagating an upstream span's
+// decision, keep traces whole.
+func WithTraceSampled(ctx context.Context, sampled bool) context.Context {
+	return context.WithValue(ctx, traceSampledKey{}, sampled)
+}
+
+// TraceSampledFromContext returns the decision set by WithTraceSampled; ok is
+// false if no decision has been made.
+func TraceSampledFromContext(ctx context.Context) (sampled, ok bool) {
+	sampled, ok = ctx.Value(traceSampledKey{}).(bool)
+	return sampled, ok
+}
+
+// TracingProvider wraps a Provider and records a span for a sampled fraction
+// of requests, keeping tracing overhead down. Sampling is decided at the head:
+// a request within a trace follows its parent's decision, so a request whose
+// parent is sampled is always traced, and requests starting a trace are
+// traced with probability rate. The decision is recorded on the context
+// passed down, so inner TracingProviders follow it.
+type TracingProvider struct {
+	Provider
+	tracer Tracer
+	rate   float64
+	random func() float64
+}
+
+// NewTracingProvider creates a provider that traces a rate fraction (0 to 1)
+// of requests with tracer.
+func NewTracingProvider(inner Provider, tracer Tracer, rate float64) *TracingProvider {
+	return &TracingProvider{Provider: inner, tracer: tracer, rate: rate, random: rand.Float64}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *TracingProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request, inside a span if it is sampled.
+func (p *TracingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if !p.sample(ctx) {
+		return p.Provider.Chat(WithTraceSampled(ctx, false), req)
+	}
+
+	ctx, span := p.tracer.Start(WithTraceSampled(ctx, true), "llm.chat")
+	span.SetAttributes(map[string]any{
+		"llm.provider": p.ID(),
+		"llm.model":    req.Model,
+		"llm.messages": len(req.Messages),
+	})
+	resp, err := p.Provider.Chat(ctx, req)
+	if err == nil {
+		attrs := map[string]any{"llm.response_model": resp.Model, "llm.finish_reason": resp.FinishReason}
+		if resp.Usage != nil {
+			attrs["llm.prompt_tokens"] = resp.Usage.PromptTokens
+			attrs["llm.completion_tokens"] = resp.Usage.CompletionTokens
+		}
+		span.SetAttributes(attrs)
+	}
+	span.End(err)
+	return resp, err
+}
+
+// sample makes the head sampling decision for a request.
+func (p *TracingProvider) sample(ctx context.Context) bool {
+	if sampled, ok := TraceSampledFromContext(ctx); ok {
+		return sampled
+	}
+	return p.rate >= 1 || (p.rate > 0 && p.random() < p.rate)
+}
@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// recordingTracer records the spans ended through it.
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &recordedSpan{name: name, attrs: make(map[string]any)}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func (s *recordedSpan) SetAttributes(attrs map[string]any) {
+	for k, v := range attrs {
+		s.attrs[k] = v
+	}
+}
+
+func (s *recordedSpan) End(err error) { s.err = err }
+
+func TestTracingProviderSampling(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      float64
+		draw      float64
+		parent    string // Decision already on the context: "", "sampled" or "unsampled"
+		wantSpans int    // Spans from an outer and an inner TracingProvider
+	}{
+		{name: "always", rate: 1, draw: 0.99, wantSpans: 2},
+		{name: "never", rate: 0, draw: 0, wantSpans: 0},
+		{name: "sampled draw", rate: 0.5, draw: 0.2, wantSpans: 2},
+		{name: "unsampled draw", rate: 0.5, draw: 0.7, wantSpans: 0},
+		{name: "sampled parent", rate: 0, draw: 0.99, parent: "sampled", wantSpans: 2},
+		{name: "unsampled parent", rate: 1, draw: 0, parent: "unsampled", wantSpans: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &recordingTracer{}
+			draws := 0
+			random := func() float64 {
+				draws++
+				return tt.draw
+			}
+			inner := NewTracingProvider(&fakeProvider{}, tracer, tt.rate)
+			inner.random = random
+			outer := NewTracingProvider(inner, tracer, tt.rate)
+			outer.random = random
+
+			ctx := context.Background()
+			if tt.parent != "" {
+				ctx = WithTraceSampled(ctx, tt.parent == "sampled")
+			}
+			if _, err := outer.Chat(ctx, &ChatRequest{Model: "m"}); err != nil {
+				t.Fatal(err)
+			}
+			if len(tracer.spans) != tt.wantSpans {
+				t.Errorf("spans = %d, want %d", len(tracer.spans), tt.wantSpans)
+			}
+			if draws > 1 {
+				t.Errorf("sampled %d times, want the inner provider to follow the outer decision", draws)
+			}
+		})
+	}
+}
+
+func TestTracingProviderSpan(t *testing.T) {
+	errChat := errors.New("chat failed")
+	tests := []struct {
+		name      string
+		outcome   outcome
+		wantAttrs map[string]any
+	}{
+		{
+			name:    "success",
+			outcome: outcome{resp: &ChatResponse{Model: "m-1", FinishReason: "stop", Usage: &UsageStats{PromptTokens: 3, CompletionTokens: 2}}},
+			wantAttrs: map[string]any{
+				"llm.model":             "m",
+				"llm.messages":          1,
+				"llm.response_model":    "m-1",
+				"llm.finish_reason":     "stop",
+				"llm.prompt_tokens":     3,
+				"llm.completion_tokens": 2,
+			},
+		},
+		{
+			name:      "failure",
+			outcome:   outcome{err: errChat},
+			wantAttrs: map[string]any{"llm.model": "m", "llm.messages": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &recordingTracer{}
+			inner := &fakeProvider{id: "fake", chat: scripted(tt.outcome)}
+			p := NewTracingProvider(inner, tracer, 1)
+			_, err := p.Chat(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")})
+			if len(tracer.spans) != 1 {
+				t.Fatalf("spans = %d, want 1", len(tracer.spans))
+			}
+			span := tracer.spans[0]
+			if span.name != "llm.chat" || span.err != err || !errors.Is(err, tt.outcome.err) {
+				t.Errorf("span %q ended with %v, chat returned %v", span.name, span.err, err)
+			}
+			if span.attrs["llm.provider"] != "fake" {
+				t.Errorf("llm.provider = %v, want fake", span.attrs["llm.provider"])
+			}
+			for k, want := range tt.wantAttrs {
+				if span.attrs[k] != want {
+					t.Errorf("%s = %v, want %v", k, span.attrs[k], want)
+				}
+			}
+			if _, ok := span.attrs["llm.response_model"]; ok && tt.outcome.err != nil {
+				t.Error("response attributes recorded for a failed request")
+			}
+		})
+	}
+}
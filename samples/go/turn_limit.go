@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyTurns is returned when a conversation exceeds the configured turn limit.
+var ErrTooManyTurns = errors.New("conversation exceeds maximum turn count")
+
+// TurnLimitProvider wraps a Provider and rejects conversations with too many
+// turns, guarding against runaway agent loops. System messages are not counted.
+type TurnLimitProvider struct {
+	Provider
+	maxTurns      int
+	assistantOnly bool
+}
+
+// NewTurnLimitProvider creates a provider that allows at most maxTurns non-system
+// messages per request. If assistantOnly is set, only assistant messages are counted.
+func NewTurnLimitProvider(inner Provider, maxTurns int, assistantOnly bool) *TurnLimitProvider {
+	return &TurnLimitProvider{Provider: inner, maxTurns: maxTurns, assistantOnly: assistantOnly}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *TurnLimitProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat rejects the request if it exceeds the turn limit, otherwise forwards it.
+func (p *TurnLimitProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if turns := p.countTurns(req.Messages); turns > p.maxTurns {
+		return nil, fmt.Errorf("%w: %d > %d", ErrTooManyTurns, turns, p.maxTurns)
+	}
+	return p.Provider.Chat(ctx, req)
+}
+
+func (p *TurnLimitProvider) countTurns(messages []Message) int {
+	turns := 0
+	for _, m := range messages {
+		switch {
+		case m.Role == "system":
+		case p.assistantOnly && m.Role != "assistant":
+		default:
+			turns++
+		}
+	}
+	return turns
+}
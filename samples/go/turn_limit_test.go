@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTurnLimitProvider(t *testing.T) {
+	conversation := []Message{
+		{Role: "system", Content: "s"},
+		{Role: "user", Content: "u1"},
+		{Role: "assistant", Content: "a1"},
+		{Role: "user", Content: "u2"},
+	}
+	tests := []struct {
+		name          string
+		max           int
+		assistantOnly bool
+		wantErr       bool
+	}{
+		{"under limit", 4, false, false},
+		{"at limit ignoring system", 3, false, false},
+		{"over limit", 2, false, true},
+		{"assistant turns under limit", 1, true, false},
+		{"assistant turns over limit", 0, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			_, err := NewTurnLimitProvider(inner, tt.max, tt.assistantOnly).Chat(context.Background(), &ChatRequest{Messages: conversation})
+			if got := errors.Is(err, ErrTooManyTurns); got != tt.wantErr {
+				t.Fatalf("err = %v, want ErrTooManyTurns %v", err, tt.wantErr)
+			}
+			if called := len(inner.requests()) > 0; called == tt.wantErr {
+				t.Errorf("inner called = %v, want %v", called, !tt.wantErr)
+			}
+		})
+	}
+}
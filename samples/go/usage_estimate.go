@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// MetadataUsageEstimated is set on responses and terminal stream chunks whose
+// Usage was estimated rather than reported by the provider.
+const MetadataUsageEstimated = "usage_estimated"
+
+// EstimateUsage estimates the usage of a request and its completion with
+// counter, counting the prompt from req's messages and the completion from
+// the generated content separately.
+func EstimateUsage(counter TokenCounter, req *ChatRequest, completion string) *UsageStats {
+	prompt := counter.CountMessages(req.Model, req.Messages)
+	generated := counter.CountTokens(req.Model, completion)
+	return &UsageStats{PromptTokens: prompt, CompletionTokens: generated, TotalTokens: prompt + generated}
+}
+
+// UsageEstimatingProvider wraps a StreamingProvider and fills in Usage when the
+// provider does not report it, for example when streaming against servers
+// that ignore stream_options. Reported usage is always passed through as is.
+type UsageEstimatingProvider struct {
+	StreamingProvider
+	counter TokenCounter
+}
+
+// NewUsageEstimatingProvider creates a provider that estimates missing usage
+// with counter, or HeuristicTokenCounter if counter is nil.
+func NewUsageEstimatingProvider(inner StreamingProvider, counter TokenCounter) *UsageEstimatingProvider {
+	if counter == nil {
+		counter = HeuristicTokenCounter{}
+	}
+	return &UsageEstimatingProvider{StreamingProvider: inner, counter: counter}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *UsageEstimatingProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// Chat forwards the request and estimates usage if the response has none.
+func (p *UsageEstimatingProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	resp, err := p.StreamingProvider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Usage == nil {
+		resp.Usage = EstimateUsage(p.counter, req, resp.Content)
+		resp.SetMetadata(MetadataUsageEstimated, true)
+	}
+	return resp, nil
+}
+
+// ChatStream relays the stream and estimates usage on the terminal chunk if
+// the provider did not report any.
+func (p *UsageEstimatingProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	in, err := p.StreamingProvider.ChatStream(streamCtx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return relayStream(streamCtx, cancel, in, func(emit func(StreamChunk)) StreamChunk {
+		var content strings.Builder
+		for {
+			chunk := receive(streamCtx, in)
+			if chunk.Done {
+				if chunk.Usage == nil {
+					chunk.Usage = EstimateUsage(p.counter, req, content.String())
+					chunk.SetMetadata(MetadataUsageEstimated, true)
+				}
+				return chunk
+			}
+			emit(chunk)
+			content.WriteString(chunk.Content)
+		}
+	}), nil
+}
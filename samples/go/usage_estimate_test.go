@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUsageEstimatingProvider(t *testing.T) {
+	reported := &UsageStats{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}
+	tests := []struct {
+		name          string
+		usage         *UsageStats // Reported by the provider
+		want          UsageStats
+		wantEstimated bool
+	}{
+		{"reported usage passed through", reported, *reported, false},
+		{"missing usage estimated", nil, UsageStats{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8}, true},
+	}
+	req := &ChatRequest{Model: "m", Messages: userMessages("hello")}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeStreamer{
+				fakeProvider: &fakeProvider{chat: func(context.Context, *ChatRequest) (*ChatResponse, error) {
+					return &ChatResponse{Content: "abc", Usage: tt.usage}, nil
+				}},
+				stream: func(context.Context, *ChatRequest) (<-chan StreamChunk, error) {
+					return streamOf(StreamChunk{Content: "ab"}, StreamChunk{Content: "c"}, StreamChunk{Done: true, Usage: tt.usage}), nil
+				},
+			}
+			p := NewUsageEstimatingProvider(inner, wordCounter{})
+
+			resp, err := p.Chat(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *resp.Usage != tt.want || (resp.Metadata[MetadataUsageEstimated] == true) != tt.wantEstimated {
+				t.Errorf("Chat usage = %+v, metadata %v", resp.Usage, resp.Metadata)
+			}
+
+			chunks, err := p.ChatStream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, final := collectStream(chunks)
+			if final.Usage == nil || *final.Usage != tt.want || (final.Metadata[MetadataUsageEstimated] == true) != tt.wantEstimated {
+				t.Errorf("stream usage = %+v, metadata %v", final.Usage, final.Metadata)
+			}
+		})
+	}
+}
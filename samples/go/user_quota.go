@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUserQuotaExceeded is returned when a user has used up their request quota.
+var ErrUserQuotaExceeded = errors.New("user request quota exceeded")
+
+type userIDKey struct{}
+
+// WithUserID returns a context whose requests are attributed to user.
+func WithUserID(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, user)
+}
+
+// UserIDFromContext returns the user set by WithUserID.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userIDKey{}).(string)
+	return user, ok && user != ""
+}
+
+// QuotaStore tracks per-user request counts for UserQuotaProvider. Reserve
+// must check and record atomically, so that concurrent requests, possibly on
+// different servers sharing the store, cannot exceed the limit.
+type QuotaStore interface {
+	// Reserve records a request by user at now, unless user has already made
+	// limit requests in the window ending at now. It reports whether the
+	// request was recorded.
+	Reserve(ctx context.Context, user string, now time.Time, window time.Duration, limit int) (bool, error)
+}
+
+// MemoryQuotaStore is an in-process QuotaStore.
+type MemoryQuotaStore struct {
+	mu       sync.Mutex
+	requests map[string][]time.Time // Per user, oldest first
+}
+
+// NewMemoryQuotaStore creates an empty in-process quota store.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{requests: make(map[string][]time.Time)}
+}
+
+// Reserve implements QuotaStore.
+func (s *MemoryQuotaStore) Reserve(_ context.Context, user string, now time.Time, window time.Duration, limit int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	times := s.requests[user]
+	cutoff := now.Add(-window)
+	for len(times) > 0 && !times[0].After(cutoff) {
+		times = times[1:]
+	}
+	if len(times) >= limit {
+		s.requests[user] = times
+		return false, nil
+	}
+	s.requests[user] = append(times, now)
+	return true, nil
+}
+
+// UserQuotaProvider wraps a Provider and limits each user, identified with
+// WithUserID, to a number of requests per rolling 24-hour window. Requests
+// without a user are not limited.
+type UserQuotaProvider struct {
+	Provider
+	store QuotaStore
+	limit int
+	now   func() time.Time
+}
+
+// NewUserQuotaProvider creates a provider allowing each user limit requests a day.
+func NewUserQuotaProvider(inner Provider, store QuotaStore, limit int) *UserQuotaProvider {
+	return &UserQuotaProvider{Provider: inner, store: store, limit: limit, now: time.Now}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *UserQuotaProvider) Unwrap() Provider {
+	return p.Provider
+}
+
+// Chat forwards the request if the user has quota left.
+func (p *UserQuotaProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if user, ok := UserIDFromContext(ctx); ok {
+		allowed, err := p.store.Reserve(ctx, user, p.now(), 24*time.Hour, p.limit)
+		if err != nil {
+			return nil, fmt.Errorf("check quota: %w", err)
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%w: %s has made %d requests in 24h", ErrUserQuotaExceeded, user, p.limit)
+		}
+	}
+	return p.Provider.Chat(ctx, req)
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUserQuotaProvider(t *testing.T) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	type call struct {
+		user    string
+		at      time.Duration // After start
+		allowed bool
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{"within quota", []call{{"ann", 0, true}, {"ann", time.Hour, true}}},
+		{"over quota", []call{{"ann", 0, true}, {"ann", time.Minute, true}, {"ann", 2 * time.Minute, false}}},
+		{"users counted separately", []call{{"ann", 0, true}, {"ann", 0, true}, {"bob", 0, true}, {"ann", 0, false}}},
+		{"anonymous requests unlimited", []call{{"", 0, true}, {"", 0, true}, {"", 0, true}}},
+		{"rolling window frees quota", []call{
+			{"ann", 0, true}, {"ann", 12 * time.Hour, true}, {"ann", 23 * time.Hour, false},
+			{"ann", 24 * time.Hour, true}, // The first request has aged out
+			{"ann", 25 * time.Hour, false},
+		}},
+		{"rejected requests do not count", []call{
+			{"ann", 0, true}, {"ann", time.Hour, true}, {"ann", 2 * time.Hour, false},
+			{"ann", 24 * time.Hour, true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeProvider{}
+			p := NewUserQuotaProvider(inner, NewMemoryQuotaStore(), 2)
+			allowed := 0
+			for i, c := range tt.calls {
+				p.now = func() time.Time { return start.Add(c.at) }
+				ctx := context.Background()
+				if c.user != "" {
+					ctx = WithUserID(ctx, c.user)
+				}
+				_, err := p.Chat(ctx, &ChatRequest{Model: "m"})
+				if c.allowed {
+					allowed++
+					if err != nil {
+						t.Errorf("call %d: err = %v", i, err)
+					}
+				} else if !errors.Is(err, ErrUserQuotaExceeded) {
+					t.Errorf("call %d: err = %v, want ErrUserQuotaExceeded", i, err)
+				}
+			}
+			if got := len(inner.requests()); got != allowed {
+				t.Errorf("forwarded %d requests, want %d", got, allowed)
+			}
+		})
+	}
+}
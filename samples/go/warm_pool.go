@@ -0,0 +1,280 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// StreamPreparer is implemented by streaming providers that can establish a
+// stream's connection before the request is known.
+type StreamPreparer interface {
+	PrepareStream(ctx context.Context) (PreparedStream, error)
+}
+
+// PreparedStream is a connection ready to carry one streaming request.
+type PreparedStream interface {
+	// ChatStream sends req over the prepared connection. It may be called once.
+	ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error)
+
+	// Close releases a prepared connection that will not be used.
+	Close() error
+}
+
+// WarmPoolProvider wraps a StreamingProvider and keeps a pool of prepared
+// streams, handing one to each ChatStream call so the first token is not
+// delayed by connection setup. Call Run to fill the pool and keep it topped
+// up; calls that find the pool empty stream directly.
+//
+// Providers that do not implement StreamPreparer are only warmed like
+// ProviderRegistry.Warmup does. That leaves an idle keep-alive connection in
+// their HTTP client's pool, but whether the stream reuses it is up to the
+// client, so such streams count as misses in Stats.
+type WarmPoolProvider struct {
+	StreamingProvider
+	maxIdle time.Duration
+	pool    chan pooledStream
+	used    chan struct{}
+
+	hits, misses atomic.Int64
+}
+
+type pooledStream struct {
+	stream   PreparedStream
+	prepared time.Time
+}
+
+// NewWarmPoolProvider creates a provider that keeps size prepared streams.
+// Prepared streams idle longer than maxIdle are discarded rather than used,
+// since servers close idle connections; zero keeps them indefinitely.
+func NewWarmPoolProvider(inner StreamingProvider, size int, maxIdle time.Duration) *WarmPoolProvider {
+	return &WarmPoolProvider{
+		StreamingProvider: inner,
+		maxIdle:           maxIdle,
+		pool:              make(chan pooledStream, size),
+		used:              make(chan struct{}, 1),
+	}
+}
+
+// Unwrap returns the wrapped provider.
+func (p *WarmPoolProvider) Unwrap() Provider {
+	return p.StreamingProvider
+}
+
+// Run fills the pool and refills it as streams are used or expire, until ctx
+// is done. Pooled streams are closed when it returns.
+func (p *WarmPoolProvider) Run(ctx context.Context) {
+	defer func() {
+		for {
+			select {
+			case s := <-p.pool:
+				s.stream.Close()
+			default:
+				return
+			}
+		}
+	}()
+
+	var expiry <-chan time.Time
+	if p.maxIdle > 0 {
+		ticker := time.NewTicker(p.maxIdle / 2)
+		defer ticker.Stop()
+		expiry = ticker.C
+	}
+
+	for {
+		p.fill(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.used:
+		case <-expiry:
+			p.evictExpired()
+		}
+	}
+}
+
+// ChatStream sends req over a prepared stream if one is available.
+func (p *WarmPoolProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	for {
+		select {
+		case s := <-p.pool:
+			p.notifyUsed()
+			if p.expired(s) {
+				s.stream.Close()
+				continue
+			}
+			if _, warmedOnly := s.stream.(warmedStream); warmedOnly {
+				p.misses.Add(1)
+			} else {
+				p.hits.Add(1)
+			}
+			return s.stream.ChatStream(ctx, req)
+		default:
+			p.misses.Add(1)
+			return p.StreamingProvider.ChatStream(ctx, req)
+		}
+	}
+}
+
+// Stats returns how many streams used a prepared connection and how many did
+// not, because the pool was empty or the provider could only be warmed.
+func (p *WarmPoolProvider) Stats() (hits, misses int64) {
+	return p.hits.Load(), p.misses.Load()
+}
+
+func (p *WarmPoolProvider) fill(ctx context.Context) {
+	for len(p.pool) < cap(p.pool) && ctx.Err() == nil {
+		stream, err := p.prepare(ctx)
+		if err != nil {
+			return // Retried on the next use or expiry tick
+		}
+		select {
+		case p.pool <- pooledStream{stream: stream, prepared: time.Now()}:
+		default:
+			stream.Close()
+			return
+		}
+	}
+}
+
+func (p *WarmPoolProvider) prepare(ctx context.Context) (PreparedStream, error) {
+	if sp, ok := p.StreamingProvider.(StreamPreparer); ok {
+		return sp.PrepareStream(ctx)
+	}
+	if err := warm(ctx, p.StreamingProvider); err != nil {
+		return nil, err
+	}
+	return warmedStream{p.StreamingProvider}, nil
+}
+
+func (p *WarmPoolProvider) evictExpired() {
+	for range len(p.pool) {
+		select {
+		case s := <-p.pool:
+			if p.expired(s) {
+				s.stream.Close()
+				continue
+			}
+			p.pool <- s
+		default:
+			return
+		}
+	}
+}
+
+func (p *WarmPoolProvider) expired(s pooledStream) bool {
+	return p.maxIdle > 0 && time.Since(s.prepared) > p.maxIdle
+}
+
+func (p *WarmPoolProvider) notifyUsed() {
+	select {
+	case p.used <- struct{}{}:
+	default:
+	}
+}
+
+// warmedStream streams through a provider whose connection was just warmed.
+type warmedStream struct {
+	StreamingProvider
+}
+
+func (warmedStream) Close() error { return nil }
+
+// PrepareStream starts a chat completions request whose body is sent once the
+// request is known, so the connection and TLS handshake are done ahead of
+// time. The request carries the headers set on ctx; a prepared stream used
+// with WithHeaders on its own context is discarded for a fresh request, since
+// its headers are already fixed.
+func (p *OpenAIProvider) PrepareStream(ctx context.Context) (PreparedStream, error) {
+	// The request outlives ctx, which is the pool's rather than the caller's;
+	// ChatStream ties it to the caller's context instead.
+	reqCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	body, send := io.Pipe()
+	httpReq, err := p.newRequest(reqCtx, http.MethodPost, "/chat/completions", body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The request has no Content-Length, so the client sends it chunked.
+	s := &openAIPreparedStream{p: p, req: httpReq, send: send, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		s.resp, s.err = p.client.Do(httpReq)
+	}()
+	return s, nil
+}
+
+// openAIPreparedStream is a chat completions request waiting for its body.
+type openAIPreparedStream struct {
+	p      *OpenAIProvider
+	req    *http.Request
+	send   *io.PipeWriter
+	cancel context.CancelFunc
+
+	done chan struct{} // Closed once the response, or an error, is in
+	resp *http.Response
+	err  error
+}
+
+// ChatStream sends req as the prepared request's body and streams the
+// response. If the prepared connection already failed, or ctx carries its own
+// headers, it streams on a fresh request instead.
+func (s *openAIPreparedStream) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	select {
+	case <-s.done:
+		if s.err != nil {
+			s.Close()
+			return s.p.ChatStream(ctx, req)
+		}
+	default:
+	}
+	if len(HeadersFromContext(ctx)) > 0 {
+		s.Close()
+		return s.p.ChatStream(ctx, req)
+	}
+
+	body, err := s.p.encodeStream(req)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, s.cancel)
+	go func() {
+		_, err := s.send.Write(body)
+		s.send.CloseWithError(err)
+	}()
+	<-s.done
+
+	release := func() {
+		stop()
+		s.cancel()
+	}
+	if s.err != nil {
+		release()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, s.err
+	}
+	httpResp, err := s.p.checkResponse(s.req, s.resp, req.Model, body)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	httpResp.Body = &meteredBody{ReadCloser: httpResp.Body, onClose: func(int64) { release() }}
+	return chunksFromEvents(ctx, s.p.streamEvents(ctx, httpResp)), nil
+}
+
+// Close abandons the prepared request.
+func (s *openAIPreparedStream) Close() error {
+	s.cancel()
+	s.send.Close()
+	<-s.done
+	if s.resp != nil {
+		s.resp.Body.Close()
+	}
+	return nil
+}
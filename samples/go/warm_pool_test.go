@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// preparingStreamer is a fakeStreamer that implements StreamPreparer.
+type preparingStreamer struct {
+	*fakeStreamer
+	prepared, closed atomic.Int32
+}
+
+func (p *preparingStreamer) PrepareStream(context.Context) (PreparedStream, error) {
+	p.prepared.Add(1)
+	return &preparedFake{owner: p}, nil
+}
+
+type preparedFake struct {
+	owner *preparingStreamer
+}
+
+func (s *preparedFake) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	return s.owner.fakeStreamer.ChatStream(ctx, req)
+}
+
+func (s *preparedFake) Close() error {
+	s.owner.closed.Add(1)
+	return nil
+}
+
+func TestWarmPoolProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		preparer   bool
+		fill       bool          // Fill the pool before streaming
+		idle       time.Duration // Wait before streaming
+		streams    int
+		wantHits   int64
+		wantMisses int64
+		wantClosed int32
+	}{
+		{"empty pool", true, false, 0, 1, 0, 1, 0},
+		{"prepared streams", true, true, 0, 3, 2, 1, 0},
+		{"expired streams discarded", true, true, 30 * time.Millisecond, 1, 0, 1, 2},
+		{"warmed-only streams are misses", false, true, 0, 2, 0, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &preparingStreamer{fakeStreamer: streamingReply("hi")}
+			var streamer StreamingProvider = inner.fakeStreamer
+			if tt.preparer {
+				streamer = inner
+			}
+			p := NewWarmPoolProvider(streamer, 2, 20*time.Millisecond)
+			if tt.fill {
+				p.fill(context.Background())
+			}
+			time.Sleep(tt.idle)
+
+			for range tt.streams {
+				chunks, err := p.ChatStream(context.Background(), &ChatRequest{Model: "m"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if content, _ := collectStream(chunks); content != "hi" {
+					t.Errorf("content = %q", content)
+				}
+			}
+			if hits, misses := p.Stats(); hits != tt.wantHits || misses != tt.wantMisses {
+				t.Errorf("Stats = %d hits, %d misses; want %d, %d", hits, misses, tt.wantHits, tt.wantMisses)
+			}
+			if got := inner.closed.Load(); got != tt.wantClosed {
+				t.Errorf("closed %d prepared streams, want %d", got, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestWarmPoolProviderRun(t *testing.T) {
+	inner := &preparingStreamer{fakeStreamer: streamingReply("hi")}
+	p := NewWarmPoolProvider(inner, 2, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for inner.prepared.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	chunks, err := p.ChatStream(context.Background(), &ChatRequest{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectStream(chunks)
+	for inner.prepared.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond) // The used stream is replaced
+	}
+	cancel()
+	<-done
+
+	if hits, _ := p.Stats(); hits != 1 {
+		t.Errorf("hits = %d, want 1", hits)
+	}
+	if prepared, closed := inner.prepared.Load(), inner.closed.Load(); prepared != 3 || closed != 2 {
+		t.Errorf("prepared %d and closed %d streams, want 3 and the 2 left in the pool", prepared, closed)
+	}
+}
+
+func TestOpenAIPrepareStream(t *testing.T) {
+	srv := newOpenAIServer(t, func(w http.ResponseWriter, _ map[string]any) {
+		writeSSE(w, `{"choices":[{"index":0,"delta":{"content":"hi"}}]}`)
+	})
+	var dials atomic.Int32
+	transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}}
+	t.Cleanup(transport.CloseIdleConnections)
+	p := NewOpenAIProvider(OpenAIConfig{BaseURL: srv.URL, HTTPClient: &http.Client{Transport: transport}})
+
+	prepared, err := p.PrepareStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for dials.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if dials.Load() != 1 {
+		t.Fatal("PrepareStream did not connect ahead of the request")
+	}
+
+	chunks, err := prepared.ChatStream(context.Background(), &ChatRequest{Model: "m", Messages: userMessages("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, final := collectStream(chunks); content != "hi" || final.Reason != StreamCompleted {
+		t.Errorf("content %q, final %+v; want hi, completed", content, final)
+	}
+	if body := srv.lastBody(); body["model"] != "m" || body["stream"] != true {
+		t.Errorf("sent %v", body)
+	}
+	if n := dials.Load(); n != 1 {
+		t.Errorf("dialed %d connections, want the prepared one only", n)
+	}
+
+	unused, err := p.PrepareStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unused.Close(); err != nil {
+		t.Errorf("Close = %v", err)
+	}
+}